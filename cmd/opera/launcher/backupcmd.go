@@ -0,0 +1,199 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-opera/gossip"
+	"github.com/Fantom-foundation/go-opera/integration"
+	"github.com/Fantom-foundation/go-opera/utils/dbbackup"
+)
+
+var (
+	backupCommand = cli.Command{
+		Name:     "backup",
+		Usage:    "A set of commands for backups of databases",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+Backups contain all the databases of the datadir, checkpointed at the same flush ID.
+Table files are hardlinked when the backup directory is on the same filesystem as
+the datadir, so a backup takes little time and space. Keystores aren't backed up.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "create",
+				Usage:     "Create a backup of a running or a stopped node",
+				ArgsUsage: "<dir>",
+				Action:    utils.MigrateFlags(createBackup),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera backup create <dir>
+
+Creates a backup in the empty directory. If the node is running, the backup is
+made over the IPC endpoint with the admin_backupDatadir RPC method, which pauses
+processing of events only while the databases are checkpointed.`,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Verify a backup",
+				ArgsUsage: "<dir>",
+				Action:    utils.MigrateFlags(verifyBackup),
+				Description: `
+    opera backup verify <dir>
+
+Checks the files of the backup against its manifest, reads all the records of
+all the databases and checks that the databases are synced at the flush ID of
+the manifest.`,
+			},
+			{
+				Name:      "restore",
+				Usage:     "Restore databases of the datadir from a backup",
+				ArgsUsage: "<dir>",
+				Action:    utils.MigrateFlags(restoreBackup),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera backup restore <dir>
+
+Verifies the backup and restores the databases into the datadir, which must not
+contain databases.`,
+			},
+		},
+	}
+)
+
+// PrivateBackupAPI provides backups of a running node.
+type PrivateBackupAPI struct {
+	svc          *gossip.Service
+	chaindataDir string
+}
+
+// NewPrivateBackupAPI creates a new backup API.
+func NewPrivateBackupAPI(svc *gossip.Service, chaindataDir string) *PrivateBackupAPI {
+	return &PrivateBackupAPI{
+		svc:          svc,
+		chaindataDir: chaindataDir,
+	}
+}
+
+// BackupDatadir checkpoints all the databases into the empty directory at a consistent flush ID.
+func (api *PrivateBackupAPI) BackupDatadir(dir string) (*dbbackup.Manifest, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, errors.New("backup directory must be an absolute path")
+	}
+	var manifest *dbbackup.Manifest
+	err := api.svc.WithFlushedDBs(func() (err error) {
+		manifest, err = dbbackup.Checkpoint(api.chaindataDir, dir, integration.FlushIDKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	total, linked := manifest.Size()
+	log.Info("Backup is created", "dir", dir, "flushID", manifest.FlushID, "size", common.StorageSize(total), "linked", common.StorageSize(linked))
+	return manifest, nil
+}
+
+func backupAPIs(svc *gossip.Service, chaindataDir string) []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   NewPrivateBackupAPI(svc, chaindataDir),
+			Public:    false,
+		},
+	}
+}
+
+func printBackupManifest(dir string, m *dbbackup.Manifest) {
+	total, linked := m.Size()
+	fmt.Printf("Backup:   %s\n", dir)
+	fmt.Printf("Time:     %s\n", m.Time.String())
+	fmt.Printf("Flush ID: %s\n", m.FlushID.String())
+	fmt.Printf("Size:     %s (hardlinked %s)\n", common.StorageSize(total).String(), common.StorageSize(linked).String())
+	for _, db := range m.Databases {
+		fmt.Printf("  %s: %d files\n", db.Name, len(db.Files))
+	}
+}
+
+func backupDirArg(ctx *cli.Context) string {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	dir, err := filepath.Abs(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Invalid directory: %v", err)
+	}
+	return dir
+}
+
+func createBackup(ctx *cli.Context) error {
+	dir := backupDirArg(ctx)
+	cfg := makeAllConfigs(ctx)
+
+	// backup a running node over IPC
+	if endpoint := cfg.Node.IPCEndpoint(); endpoint != "" {
+		if client, err := rpc.Dial(endpoint); err == nil {
+			defer client.Close()
+			log.Info("Creating backup of the running node", "endpoint", endpoint)
+			var m dbbackup.Manifest
+			if err := client.Call(&m, "admin_backupDatadir", dir); err != nil {
+				return err
+			}
+			printBackupManifest(dir, &m)
+			return nil
+		}
+	}
+
+	chaindataDir := path.Join(cfg.Node.DataDir, "chaindata")
+	release, err := dbbackup.LockDBs(chaindataDir)
+	if err != nil {
+		return fmt.Errorf("failed to lock databases, the node may be running without IPC endpoint: %v", err)
+	}
+	defer release()
+	log.Info("Creating backup of the stopped node", "datadir", cfg.Node.DataDir)
+	m, err := dbbackup.Checkpoint(chaindataDir, dir, integration.FlushIDKey)
+	if err != nil {
+		return err
+	}
+	printBackupManifest(dir, m)
+	return nil
+}
+
+func verifyBackup(ctx *cli.Context) error {
+	dir := backupDirArg(ctx)
+	m, err := dbbackup.Verify(dir, integration.FlushIDKey)
+	if err != nil {
+		return fmt.Errorf("backup is corrupted: %v", err)
+	}
+	printBackupManifest(dir, m)
+	fmt.Println("Backup is verified")
+	return nil
+}
+
+func restoreBackup(ctx *cli.Context) error {
+	dir := backupDirArg(ctx)
+	cfg := makeAllConfigs(ctx)
+
+	log.Info("Verifying backup", "dir", dir)
+	m, err := dbbackup.Verify(dir, integration.FlushIDKey)
+	if err != nil {
+		return fmt.Errorf("backup is corrupted: %v", err)
+	}
+	chaindataDir := path.Join(cfg.Node.DataDir, "chaindata")
+	if err := dbbackup.Restore(dir, chaindataDir); err != nil {
+		return err
+	}
+	log.Info("Backup is restored", "datadir", cfg.Node.DataDir, "flushID", m.FlushID)
+	return nil
+}
@@ -10,6 +10,19 @@ var (
 		Name:  "check",
 		Usage: "true if events should be fully checked before importing",
 	}
+	EventsFormatFlag = cli.IntFlag{
+		Name:  "format",
+		Usage: "version of the events file format (1 or 2)",
+		Value: 1,
+	}
+	EventsZstdFlag = cli.BoolFlag{
+		Name:  "zstd",
+		Usage: "compress chunks of the version 2 events file with zstd",
+	}
+	ExportEpochsFlag = cli.BoolFlag{
+		Name:  "epochs",
+		Usage: "range arguments are epochs instead of blocks",
+	}
 	importCommand = cli.Command{
 		Name:      "import",
 		Usage:     "Import a blockchain file",
@@ -33,7 +46,9 @@ Events are fully verified by default, unless overridden by check=false flag.`,
 				},
 				Description: `
 The import command imports events from RLP-encoded files.
-Events are fully verified by default, unless overridden by --check=false flag.`,
+Events are fully verified by default, unless overridden by --check=false flag.
+Chunks of the version 2 files are verified against their checksums before
+importing, and the import is resumed from the current epoch of the node.`,
 			},
 			{
 				Action:    utils.MigrateFlags(importEvm),
@@ -49,6 +64,51 @@ Events are fully verified by default, unless overridden by --check=false flag.`,
 
 The import command imports EVM storage (trie nodes, code, preimages) from files.`,
 			},
+			{
+				Action:    utils.MigrateFlags(importBlocks),
+				Name:      "blocks",
+				Usage:     "Import finalized blocks with their transactions",
+				ArgsUsage: "<filename> (<filename 2> ... <filename N>)",
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera import blocks
+
+The import command imports blocks written by 'opera export blocks' without
+running consensus. Blocks are imported with their transactions and the
+payloads of their events, the events aren't connected to the DAG of the
+node. Existing blocks aren't overwritten, and the last block of the node
+isn't changed.`,
+			},
+			{
+				Action:    utils.MigrateFlags(importReceipts),
+				Name:      "receipts",
+				Usage:     "Import receipts of finalized blocks",
+				ArgsUsage: "<filename> (<filename 2> ... <filename N>)",
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera import receipts
+
+The import command imports receipts written by 'opera export receipts'.
+Logs of the receipts are indexed if their blocks are already imported.`,
+			},
+			{
+				Action:    utils.MigrateFlags(importState),
+				Name:      "state",
+				Usage:     "Import EVM state",
+				ArgsUsage: "<filename>",
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera import state
+
+The import command imports EVM state written by 'opera export state' and
+checks that the state root is available afterwards.`,
+			},
 		},
 	}
 	exportCommand = cli.Command{
@@ -64,6 +124,8 @@ The import command imports EVM storage (trie nodes, code, preimages) from files.
 				Action:    utils.MigrateFlags(exportEvents),
 				Flags: []cli.Flag{
 					DataDirFlag,
+					EventsFormatFlag,
+					EventsZstdFlag,
 				},
 				Description: `
     opera export events
@@ -71,7 +133,63 @@ The import command imports EVM storage (trie nodes, code, preimages) from files.
 Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
 last epoch to write. If the file ends with .gz, the output will
-be gzipped
+be gzipped.
+With --format=2, events are written in per-epoch chunks with checksums,
+followed by an index of chunks. Chunks may be compressed with --zstd.
+`,
+			},
+			{
+				Name:      "blocks",
+				Usage:     "Export finalized blocks with their transactions and events",
+				ArgsUsage: "<filename> [<blockFrom> <blockTo>]",
+				Action:    utils.MigrateFlags(exportBlocks),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					ExportEpochsFlag,
+				},
+				Description: `
+    opera export blocks
+
+Requires a first argument of the file to write to.
+Optional second and third arguments control the first and
+last block to write, or the first and last epoch if --epochs is set.
+If the file ends with .gz, the output will be gzipped.
+`,
+			},
+			{
+				Name:      "receipts",
+				Usage:     "Export receipts of finalized blocks",
+				ArgsUsage: "<filename> [<blockFrom> <blockTo>]",
+				Action:    utils.MigrateFlags(exportReceipts),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					ExportEpochsFlag,
+				},
+				Description: `
+    opera export receipts
+
+Requires a first argument of the file to write to.
+Optional second and third arguments control the first and
+last block to write, or the first and last epoch if --epochs is set.
+If the file ends with .gz, the output will be gzipped.
+`,
+			},
+			{
+				Name:      "state",
+				Usage:     "Export EVM state of a block",
+				ArgsUsage: "<filename> [<block>]",
+				Action:    utils.MigrateFlags(exportState),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					ExportEpochsFlag,
+				},
+				Description: `
+    opera export state
+
+Requires a first argument of the file to write to.
+Optional second argument is the block of the state, or the epoch
+sealed by the block if --epochs is set. The last block is used by default.
+The state must not be pruned. If the file ends with .gz, the output will be gzipped.
 `,
 			},
 		},
@@ -93,6 +211,78 @@ be gzipped
     opera check evm
 
 Checks EVM storage roots and code hashes
+`,
+			},
+			{
+				Name:   "db",
+				Usage:  "Check cross-table consistency of gossip and lachesis DBs",
+				Action: utils.MigrateFlags(checkDB),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					CheckRepairFlag,
+				},
+				Description: `
+    opera check db [--repair]
+
+Checks that events of every block exist, block hashes match blocks,
+receipts and tx positions match block txs (if txs index is enabled),
+DAG heads, last events and highest lamport match events of current epoch,
+the last block state and EVM state root exist and lachesis DB is at the same epoch.
+Inconsistent block hashes, tx positions, heads, last events and highest lamport
+are rewritten if --repair is specified.
+`,
+			},
+		},
+	}
+	CheckRepairFlag = cli.BoolFlag{
+		Name:  "repair",
+		Usage: "rewrite the inconsistent records of derivable indexes",
+	}
+	IndexFromBlockFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "first block to index",
+	}
+	IndexToBlockFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "last block to index (0 means the latest block)",
+	}
+	indexCommand = cli.Command{
+		Name:     "index",
+		Usage:    "Build optional indexes",
+		Category: "MISCELLANEOUS COMMANDS",
+
+		Subcommands: []cli.Command{
+			{
+				Name:   "accounts",
+				Usage:  "Index transactions by sender and recipient",
+				Action: utils.MigrateFlags(indexAccountTxs),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					IndexFromBlockFlag,
+					IndexToBlockFlag,
+				},
+				Description: `
+    opera index accounts [--from=<block>] [--to=<block>]
+
+Indexes transactions of the already processed blocks by sender and recipient.
+Required to serve ftm_getTransactionsByAddress for blocks processed without --index.accounts.
+`,
+			},
+			{
+				Name:   "transfers",
+				Usage:  "Index internal value transfers",
+				Action: utils.MigrateFlags(indexInternalTransfers),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					IndexFromBlockFlag,
+					IndexToBlockFlag,
+				},
+				Description: `
+    opera index transfers [--from=<block>] [--to=<block>]
+
+Records internal value transfers of the already processed blocks by re-executing their transactions.
+Requires EVM state of the re-executed blocks, i.e. not pruned history.
+Required to serve ftm_getInternalTransfers for blocks processed without --index.transfers.
 `,
 			},
 		},
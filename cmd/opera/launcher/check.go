@@ -2,10 +2,13 @@ package launcher
 
 import (
 	"bytes"
+	"fmt"
 	"path"
 	"time"
 
+	"github.com/Fantom-foundation/lachesis-base/abft"
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb"
 	"github.com/Fantom-foundation/lachesis-base/kvdb/table"
 	"github.com/Fantom-foundation/lachesis-base/utils/simplewlru"
 	"github.com/ethereum/go-ethereum/cmd/utils"
@@ -16,6 +19,7 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"gopkg.in/urfave/cli.v1"
 
+	"github.com/Fantom-foundation/go-opera/gossip"
 	"github.com/Fantom-foundation/go-opera/integration"
 	"github.com/Fantom-foundation/go-opera/inter"
 )
@@ -177,3 +181,99 @@ func checkEvm(ctx *cli.Context) error {
 
 	return nil
 }
+
+func checkDB(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		utils.Fatalf("This command doesn't require an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+	cdb := makeRawLachesisStore(rawProducer, cfg)
+	defer cdb.Close()
+
+	checkCfg := gossip.CheckConfig{
+		TxIndex: cfg.Opera.TxIndex,
+		Repair:  ctx.Bool(CheckRepairFlag.Name),
+	}
+
+	start, reported := time.Now(), time.Now()
+	issues, unrepaired := 0, 0
+	onIssue := func(issue gossip.Inconsistency) {
+		issues++
+		if issue.Repaired {
+			log.Warn("Inconsistency is repaired", "table", issue.Table, "msg", issue.Msg)
+			return
+		}
+		unrepaired++
+		log.Error("Inconsistency is found", "table", issue.Table, "msg", issue.Msg)
+	}
+
+	log.Info("Checking lachesis DB")
+	checkLachesisDB(gdb, cdb, onIssue)
+
+	log.Info("Checking gossip DB")
+	gdb.CheckConsistency(checkCfg, onIssue, func(n idx.Block) {
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Checking blocks", "last", n, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	})
+
+	if unrepaired != 0 {
+		return fmt.Errorf("found %d inconsistencies, %d are not repaired", issues, unrepaired)
+	}
+	log.Info("DB is consistent", "repaired", issues, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func makeRawLachesisStore(rawProducer kvdb.IterableDBProducer, cfg *config) *abft.Store {
+	openDB := func(name string) kvdb.DropableStore {
+		db, err := rawProducer.OpenDB(name)
+		if err != nil {
+			utils.Fatalf("Failed to open '%s' database: %v", name, err)
+		}
+		return db
+	}
+	getEpochDB := func(epoch idx.Epoch) kvdb.DropableStore {
+		return openDB(fmt.Sprintf("lachesis-%d", epoch))
+	}
+	crit := func(err error) {
+		log.Crit("Lachesis store error", "err", err)
+	}
+	return abft.NewStore(openDB("lachesis"), getEpochDB, crit, cfg.LachesisStore)
+}
+
+// checkLachesisDB ensures that lachesis DB is at the same epoch and with the same validators as gossip DB.
+func checkLachesisDB(gdb *gossip.Store, cdb *abft.Store, onIssue func(gossip.Inconsistency)) {
+	report := func(format string, args ...interface{}) {
+		onIssue(gossip.Inconsistency{
+			Table: "Lachesis",
+			Msg:   fmt.Sprintf(format, args...),
+		})
+	}
+	if cdb.GetEpochState() == nil {
+		report("epoch state isn't written")
+		return
+	}
+	if cdb.GetEpoch() != gdb.GetEpoch() {
+		report("epoch %d != %d", cdb.GetEpoch(), gdb.GetEpoch())
+		return
+	}
+	cValidators, gValidators := cdb.GetValidators(), gdb.GetValidators()
+	if cValidators.Len() != gValidators.Len() {
+		report("epoch %d has %d validators instead of %d", cdb.GetEpoch(), cValidators.Len(), gValidators.Len())
+		return
+	}
+	for i, id := range gValidators.SortedIDs() {
+		if cValidators.GetIdx(id) != idx.Validator(i) || cValidators.Get(id) != gValidators.Get(id) {
+			report("epoch %d validator %d mismatch", cdb.GetEpoch(), id)
+		}
+	}
+}
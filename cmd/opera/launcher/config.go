@@ -17,6 +17,7 @@ import (
 	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/Fantom-foundation/go-opera/evmcore"
 	"github.com/Fantom-foundation/go-opera/gossip"
+	"github.com/Fantom-foundation/go-opera/gossip/emitter"
 	"github.com/Fantom-foundation/go-opera/gossip/gasprice"
 	"github.com/Fantom-foundation/go-opera/integration"
 	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
@@ -76,7 +78,7 @@ var (
 	// GenesisFlag specifies network genesis configuration
 	GenesisFlag = cli.StringFlag{
 		Name:  "genesis",
-		Usage: "'path to genesis file' - sets the network genesis configuration.",
+		Usage: "'path to genesis file' - sets the network genesis configuration. A comma-separated list of paths, file:// and http(s):// URLs may be specified, the first source with the pinned genesis hash is used",
 	}
 
 	RPCGlobalGasCapFlag = cli.Uint64Flag{
@@ -90,6 +92,16 @@ var (
 		Value: gossip.DefaultConfig(cachescale.Identity).RPCTxFeeCap,
 	}
 
+	AccountTxIndexFlag = cli.BoolFlag{
+		Name:  "index.accounts",
+		Usage: "Enables indexing of transactions by sender and recipient (run 'opera index accounts' to index the existing blocks)",
+	}
+
+	InternalTransfersIndexFlag = cli.BoolFlag{
+		Name:  "index.transfers",
+		Usage: "Enables recording of internal value transfers (run 'opera index transfers' to index the existing blocks)",
+	}
+
 	AllowedOperaGenesisHashes = map[uint64]hash.Hash{
 		opera.MainNetworkID: hash.HexToHash("0x4a53c5445584b3bfc20dbfb2ec18ae20037c716f3ba2d9e1da768a9deca17cb4"),
 		opera.TestNetworkID: hash.HexToHash("0xc4a5fc96e575a16a9a0c7349d44dc4d0f602a54e0a8543360c2fee4c3937b49e"),
@@ -156,7 +168,7 @@ func loadAllConfigs(file string, cfg *config) error {
 	return err
 }
 
-func getOperaGenesis(ctx *cli.Context) integration.InputGenesis {
+func getOperaGenesis(ctx *cli.Context, cfg *config) integration.InputGenesis {
 
 	var genesis integration.InputGenesis
 	switch {
@@ -181,7 +193,24 @@ func getOperaGenesis(ctx *cli.Context) integration.InputGenesis {
 			},
 		}
 	case ctx.GlobalIsSet(GenesisFlag.Name):
-		genesisPath := ctx.GlobalString(GenesisFlag.Name)
+		sources := &genesisSources{
+			sources:    splitGenesisSources(ctx.GlobalString(GenesisFlag.Name)),
+			cacheDir:   filepath.Join(cfg.Node.DataDir, "genesis"),
+			cacheScale: cacheScaler(ctx),
+			client:     newGenesisHTTPClient(),
+		}
+		if ctx.GlobalIsSet(GenesisHashFlag.Name) {
+			b, err := hexutil.Decode(ctx.GlobalString(GenesisHashFlag.Name))
+			if err != nil || len(b) != len(hash.Hash{}) {
+				utils.Fatalf("Invalid --%s flag: must be a 32 bytes hex hash", GenesisHashFlag.Name)
+			}
+			pinned := hash.BytesToHash(b)
+			sources.pinned = &pinned
+		}
+		genesisPath, err := sources.Select()
+		if err != nil {
+			utils.Fatalf("Failed to get genesis file: %v", err)
+		}
 
 		genesisFile, err := os.Open(genesisPath)
 		if err != nil {
@@ -296,11 +325,31 @@ func gossipConfigWithFlags(ctx *cli.Context, src gossip.Config) (gossip.Config,
 	if ctx.GlobalIsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.GlobalFloat64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.GlobalIsSet(AccountTxIndexFlag.Name) {
+		cfg.AccountTxIndex = ctx.GlobalBool(AccountTxIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(InternalTransfersIndexFlag.Name) {
+		cfg.InternalTransfersIndex = ctx.GlobalBool(InternalTransfersIndexFlag.Name)
+	}
 
 	err := setValidator(ctx, &cfg.Emitter)
 	if err != nil {
 		return cfg, err
 	}
+	setValidatorKeystore(ctx, &cfg.Emitter.Validator.Keystore)
+	setValidatorFailover(ctx, &cfg.Emitter.Failover)
+	if ctx.GlobalIsSet(validatorSlashingProtectionFlag.Name) {
+		cfg.Emitter.SlashingProtection.Path = ctx.GlobalString(validatorSlashingProtectionFlag.Name)
+	}
+	if ctx.GlobalIsSet(validatorExtraFlag.Name) {
+		if len(cfg.ExtraEmitters) != 0 {
+			return cfg, fmt.Errorf("--%s cannot be combined with ExtraEmitters of the config file", validatorExtraFlag.Name)
+		}
+		cfg.ExtraEmitters, err = makeExtraValidators(ctx, cfg.Emitter)
+		if err != nil {
+			return cfg, err
+		}
+	}
 
 	return cfg, nil
 }
@@ -372,8 +421,18 @@ func mayMakeAllConfigs(ctx *cli.Context) (*config, error) {
 		return nil, err
 	}
 	cfg.Node = nodeConfigWithFlags(ctx, cfg.Node)
-	if cfg.Opera.Emitter.Validator.ID != 0 && len(cfg.Opera.Emitter.PrevEmittedEventFile.Path) == 0 {
-		cfg.Opera.Emitter.PrevEmittedEventFile.Path = cfg.Node.ResolvePath(path.Join("emitter", fmt.Sprintf("last-%d", cfg.Opera.Emitter.Validator.ID)))
+	setEmitterPaths(cfg.Node, &cfg.Opera.Emitter)
+	for i := range cfg.Opera.ExtraEmitters {
+		setEmitterPaths(cfg.Node, &cfg.Opera.ExtraEmitters[i])
+	}
+	fakenet := ctx.GlobalIsSet(FakeNetFlag.Name)
+	if err := checkSlashingProtectionPath(cfg.Node, cfg.Opera.Emitter, fakenet); err != nil {
+		return nil, err
+	}
+	for _, extra := range cfg.Opera.ExtraEmitters {
+		if err := checkSlashingProtectionPath(cfg.Node, extra, fakenet); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := cfg.Opera.Validate(); err != nil {
@@ -383,6 +442,51 @@ func mayMakeAllConfigs(ctx *cli.Context) (*config, error) {
 	return &cfg, nil
 }
 
+// setEmitterPaths places the unspecified files of the validator inside the datadir.
+func setEmitterPaths(nodeCfg node.Config, cfg *emitter.Config) {
+	if cfg.Validator.ID == 0 {
+		return
+	}
+	if len(cfg.PrevEmittedEventFile.Path) == 0 {
+		cfg.PrevEmittedEventFile.Path = nodeCfg.ResolvePath(path.Join("emitter", fmt.Sprintf("last-%d", cfg.Validator.ID)))
+	}
+	if len(cfg.SlashingProtection.Path) == 0 {
+		cfg.SlashingProtection.Path = nodeCfg.ResolvePath(path.Join("emitter", fmt.Sprintf("slashing-%d", cfg.Validator.ID)))
+	}
+	if len(cfg.OverridesPath) == 0 {
+		cfg.OverridesPath = nodeCfg.ResolvePath(path.Join("emitter", fmt.Sprintf("overrides-%d.json", cfg.Validator.ID)))
+	}
+}
+
+// checkSlashingProtectionPath refuses the slashing protection of a validator inside the datadir,
+// because the signing history must survive the datadir restoration. Fake networks are only warned.
+func checkSlashingProtectionPath(nodeCfg node.Config, cfg emitter.Config, fakenet bool) error {
+	if cfg.Validator.ID == 0 || len(nodeCfg.DataDir) == 0 || !isInsideDir(cfg.SlashingProtection.Path, nodeCfg.DataDir) {
+		return nil
+	}
+	if fakenet {
+		log.Warn("Slashing protection is inside the datadir, it's lost if the datadir is restored",
+			"validator", cfg.Validator.ID, "path", cfg.SlashingProtection.Path)
+		return nil
+	}
+	return fmt.Errorf("slashing protection of validator %d (%s) must be outside the datadir, specify it with --%s",
+		cfg.Validator.ID, cfg.SlashingProtection.Path, validatorSlashingProtectionFlag.Name)
+}
+
+// isInsideDir checks whether the path resolves inside the directory.
+func isInsideDir(p, dir string) bool {
+	absPath, err := filepath.Abs(p)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func makeAllConfigs(ctx *cli.Context) *config {
 	cfg, err := mayMakeAllConfigs(ctx)
 	if err != nil {
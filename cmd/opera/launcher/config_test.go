@@ -0,0 +1,67 @@
+package launcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/emitter"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+)
+
+func TestCheckSlashingProtectionPath(t *testing.T) {
+	require := require.New(t)
+
+	datadir := filepath.Join("tmp", "opera")
+	nodeCfg := node.Config{DataDir: datadir}
+	cfg := emitter.DefaultConfig()
+
+	// not a validator
+	cfg.SlashingProtection.Path = filepath.Join(datadir, "emitter", "slashing-1")
+	require.NoError(checkSlashingProtectionPath(nodeCfg, cfg, false))
+
+	cfg.Validator.ID = 1
+	require.Error(checkSlashingProtectionPath(nodeCfg, cfg, false))
+	require.NoError(checkSlashingProtectionPath(nodeCfg, cfg, true))
+
+	cfg.SlashingProtection.Path = filepath.Join(datadir, "..", "opera", "slashing-1")
+	require.Error(checkSlashingProtectionPath(nodeCfg, cfg, false))
+
+	cfg.SlashingProtection.Path = filepath.Join("tmp", "opera-slashing", "slashing-1")
+	require.NoError(checkSlashingProtectionPath(nodeCfg, cfg, false))
+
+	cfg.SlashingProtection.Path = filepath.Join(datadir, "..", "slashing-1")
+	require.NoError(checkSlashingProtectionPath(nodeCfg, cfg, false))
+}
+
+func TestParseExtraKeystore(t *testing.T) {
+	require := require.New(t)
+
+	main := valkeystore.Config{
+		Backend:     valkeystore.FileBackend,
+		PKCS11Lib:   "/usr/lib/softhsm/libsofthsm2.so",
+		PKCS11Token: "main",
+	}
+
+	cfg, err := parseExtraKeystore("file", main)
+	require.NoError(err)
+	require.Equal(main, cfg)
+
+	cfg, err = parseExtraKeystore("pkcs11", main)
+	require.NoError(err)
+	require.Equal(valkeystore.PKCS11Backend, cfg.Backend)
+	require.Equal("main", cfg.PKCS11Token)
+
+	cfg, err = parseExtraKeystore("pkcs11=extra", main)
+	require.NoError(err)
+	require.Equal(valkeystore.PKCS11Backend, cfg.Backend)
+	require.Equal(main.PKCS11Lib, cfg.PKCS11Lib)
+	require.Equal("extra", cfg.PKCS11Token)
+
+	for _, spec := range []string{"", "file=x", "remote", "pkcs11="} {
+		_, err = parseExtraKeystore(spec, main)
+		require.Error(err, spec)
+	}
+}
@@ -77,7 +77,7 @@ JavaScript API. See https://github.com/ethereum/go-ethereum/wiki/JavaScript-Cons
 func localConsole(ctx *cli.Context) error {
 	// Create and start the node based on the CLI flags
 	cfg := makeAllConfigs(ctx)
-	genesis := getOperaGenesis(ctx)
+	genesis := getOperaGenesis(ctx, cfg)
 	node, _, nodeClose := makeNode(ctx, cfg, genesis)
 	startNode(ctx, node)
 	defer nodeClose()
@@ -172,7 +172,7 @@ func dialRPC(endpoint string) (*rpc.Client, error) {
 func ephemeralConsole(ctx *cli.Context) error {
 	// Create and start the node based on the CLI flags
 	cfg := makeAllConfigs(ctx)
-	genesis := getOperaGenesis(ctx)
+	genesis := getOperaGenesis(ctx, cfg)
 	node, _, nodeClose := makeNode(ctx, cfg, genesis)
 	startNode(ctx, node)
 	defer nodeClose()
@@ -0,0 +1,469 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/kvdb"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-opera/integration"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:     "db",
+		Usage:    "A set of commands for the maintenance of databases",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+The commands operate over every database of the datadir (gossip, gossip-async,
+lachesis, genesis and the epoch databases). The node must be stopped.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "stats",
+				Usage:     "Print LevelDB statistics of databases",
+				ArgsUsage: "[<db>]",
+				Action:    utils.MigrateFlags(dbStats),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera db stats [<db>]
+
+Prints LevelDB statistics of all the databases or of the specified one.`,
+			},
+			{
+				Name:      "compact",
+				Usage:     "Compact databases",
+				ArgsUsage: "[<db>]",
+				Action:    utils.MigrateFlags(dbCompact),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera db compact [<db>]
+
+Flattens all the databases or the specified one, removing deleted records.`,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Print sizes of tables",
+				ArgsUsage: "[<db>]",
+				Action:    utils.MigrateFlags(dbInspect),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera db inspect [<db>]
+
+Iterates over all the records and prints the number of records and the size of
+keys and values of every known table, including the tables of the EVM store
+and the logs index. Nested tables are separated by '/', e.g. gossip/L/t.`,
+			},
+			{
+				Name:      "get",
+				Usage:     "Print a value of a key",
+				ArgsUsage: "<table> <key>",
+				Action:    utils.MigrateFlags(dbGet),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera db get gossip/b 0x0000000000000001
+
+Prints the value of the hex key in the table. The table is a database name,
+optionally followed by table prefixes as printed by 'opera db inspect'.`,
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a key",
+				ArgsUsage: "<table> <key>",
+				Action:    utils.MigrateFlags(dbDelete),
+				Flags: []cli.Flag{
+					DataDirFlag,
+				},
+				Description: `
+    opera db delete gossip/b 0x0000000000000001
+
+Deletes the hex key from the table. The table is a database name, optionally
+followed by table prefixes as printed by 'opera db inspect'.
+WARNING: deleting records may corrupt the database.`,
+			},
+		},
+	}
+)
+
+// dbTable describes a table of a database, tables are identified by key prefixes.
+type dbTable struct {
+	prefix string
+	name   string
+	// keyLen is the length of keys (without the prefix), if non-zero then only keys of the length belong to the table
+	keyLen int
+	tables []dbTable
+}
+
+var (
+	vectorIndexTables = []dbTable{
+		{prefix: "b", name: "EventBranch"},
+		{prefix: "B", name: "BranchesInfo"},
+		{prefix: "S", name: "HighestBeforeSeq"},
+		{prefix: "s", name: "LowestAfterSeq"},
+		{prefix: "T", name: "HighestBeforeTime"},
+	}
+
+	gossipTables = []dbTable{
+		{prefix: "_", name: "Version"},
+		{prefix: "D", name: "BlockEpochState"},
+		{prefix: "e", name: "Events"},
+		{prefix: "b", name: "Blocks"},
+		{prefix: "g", name: "Genesis"},
+		{prefix: "l", name: "HighestLamport"},
+		{prefix: "V", name: "NetworkVersion"},
+		{prefix: "B", name: "BlockHashes"},
+		{prefix: "S", name: "SfcAPI", tables: []dbTable{
+			{prefix: "R", name: "GasPowerRefund"},
+			{prefix: "1", name: "Validators"},
+			{prefix: "2", name: "Stakers"},
+			{prefix: "3", name: "Delegations"},
+			{prefix: "6", name: "DelegationOldRewards"},
+			{prefix: "7", name: "StakerOldRewards"},
+			{prefix: "8", name: "StakerDelegationsOldRewards"},
+		}},
+		// evmstore tables
+		{prefix: "r", name: "Receipts"},
+		{prefix: "x", name: "TxPositions"},
+		{prefix: "X", name: "Txs"},
+		{prefix: "a", name: "AccountTxs"},
+		{prefix: "T", name: "InternalTransfers"},
+		{prefix: "t", name: "AccountTransfers"},
+		{prefix: "M", name: "EvmState", tables: []dbTable{
+			{prefix: "", name: "TrieNodes", keyLen: common.HashLength},
+			{prefix: "c", name: "Code", keyLen: common.HashLength},
+			{prefix: "a", name: "SnapshotAccounts", keyLen: common.HashLength},
+			{prefix: "o", name: "SnapshotStorage", keyLen: 2 * common.HashLength},
+			{prefix: "secure-key-", name: "Preimages"},
+		}},
+		// topicsdb tables
+		{prefix: "L", name: "EvmLogs", tables: []dbTable{
+			{prefix: "t", name: "Topic"},
+			{prefix: "r", name: "Logrec"},
+		}},
+	}
+
+	gossipAsyncTables = []dbTable{
+		{prefix: "Z", name: "Peers"},
+	}
+
+	gossipEpochTables = []dbTable{
+		{prefix: "t", name: "LastEvents"},
+		{prefix: "H", name: "Heads"},
+		{prefix: "v", name: "DagIndex", tables: vectorIndexTables},
+	}
+
+	lachesisTables = []dbTable{
+		{prefix: "c", name: "LastDecidedState"},
+		{prefix: "e", name: "EpochState"},
+	}
+
+	lachesisEpochTables = []dbTable{
+		{prefix: "r", name: "Roots"},
+		{prefix: "v", name: "VectorIndex", tables: vectorIndexTables},
+		{prefix: "C", name: "ConfirmedEvent"},
+	}
+
+	genesisTables = []dbTable{
+		{prefix: "c", name: "Rules"},
+		{prefix: "b", name: "Blocks"},
+		{prefix: "a", name: "EvmAccounts"},
+		{prefix: "s", name: "EvmStorage"},
+		{prefix: "M", name: "RawEvmItems"},
+		{prefix: "d", name: "Delegations"},
+		{prefix: "m", name: "Metadata"},
+	}
+)
+
+// getDBTables returns the known tables of a database by its name.
+func getDBTables(name string) []dbTable {
+	switch {
+	case name == "gossip":
+		return gossipTables
+	case name == "gossip-async":
+		return gossipAsyncTables
+	case strings.HasPrefix(name, "gossip-"):
+		return gossipEpochTables
+	case name == "lachesis":
+		return lachesisTables
+	case strings.HasPrefix(name, "lachesis-"):
+		return lachesisEpochTables
+	case name == "genesis":
+		return genesisTables
+	}
+	return nil
+}
+
+// findDBTable returns the path of the deepest known table of the key, e.g. "L/t".
+// An empty path is returned for unknown keys.
+func findDBTable(tables []dbTable, key []byte) string {
+	for _, t := range tables {
+		if !bytes.HasPrefix(key, []byte(t.prefix)) {
+			continue
+		}
+		rest := key[len(t.prefix):]
+		if t.keyLen != 0 && len(rest) != t.keyLen {
+			continue
+		}
+		if sub := findDBTable(t.tables, rest); sub != "" {
+			if t.prefix == "" {
+				return sub
+			}
+			return t.prefix + "/" + sub
+		}
+		if t.prefix == "" {
+			return "-"
+		}
+		return t.prefix
+	}
+	return ""
+}
+
+// dbTableName returns the name of the table by its path, e.g. "EvmLogs/Topic".
+func dbTableName(tables []dbTable, tablePath string) string {
+	var names []string
+	for _, prefix := range strings.Split(tablePath, "/") {
+		found := false
+		for _, t := range tables {
+			if t.prefix == prefix || (t.prefix == "" && prefix == "-") {
+				names = append(names, t.name)
+				tables = t.tables
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ""
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+func makeDBProducer(ctx *cli.Context) kvdb.IterableDBProducer {
+	cfg := makeAllConfigs(ctx)
+	return integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+}
+
+// dbNames returns the names of existing databases, or only the specified one.
+func dbNames(producer kvdb.IterableDBProducer, name string) ([]string, error) {
+	names := producer.Names()
+	if len(names) == 0 {
+		return nil, errors.New("datadir is not initialized")
+	}
+	sort.Strings(names)
+	if name == "" {
+		return names, nil
+	}
+	for _, n := range names {
+		if n == name {
+			return []string{name}, nil
+		}
+	}
+	return nil, fmt.Errorf("database %s not found, existing databases: %s", name, strings.Join(names, ", "))
+}
+
+// forEachDB opens the databases one by one.
+func forEachDB(ctx *cli.Context, fn func(name string, db kvdb.Store) error) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command requires at most one argument.")
+	}
+	producer := makeDBProducer(ctx)
+	names, err := dbNames(producer, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		db, err := producer.OpenDB(name)
+		if err != nil {
+			return fmt.Errorf("failed to open %s DB: %v", name, err)
+		}
+		err = fn(name, db)
+		_ = db.Close()
+		if err != nil {
+			return fmt.Errorf("%s DB: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func dbStats(ctx *cli.Context) error {
+	return forEachDB(ctx, func(name string, db kvdb.Store) error {
+		stats, err := db.Stat("leveldb.stats")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s:\n%s\n", name, stats)
+		return nil
+	})
+}
+
+func dbCompact(ctx *cli.Context) error {
+	return forEachDB(ctx, func(name string, db kvdb.Store) error {
+		start := time.Now()
+		log.Info("Compacting database", "name", name)
+		for b := byte(0); b < 255; b++ {
+			if err := db.Compact([]byte{b}, []byte{b + 1}); err != nil {
+				return err
+			}
+		}
+		if err := db.Compact([]byte{255}, nil); err != nil {
+			return err
+		}
+		log.Info("Compacted database", "name", name, "elapsed", common.PrettyDuration(time.Since(start)))
+		return nil
+	})
+}
+
+// flushIDTablePath is the pseudo table of integration.FlushIDKey, which is written into every database
+const flushIDTablePath = "!"
+
+type dbTableStats struct {
+	path      string
+	keys      uint64
+	keysSize  uint64
+	valueSize uint64
+}
+
+func dbInspect(ctx *cli.Context) error {
+	return forEachDB(ctx, func(name string, db kvdb.Store) error {
+		tables := getDBTables(name)
+		stats := make(map[string]*dbTableStats)
+		total := dbTableStats{}
+
+		start, reported := time.Now(), time.Now()
+		it := db.NewIterator(nil, nil)
+		defer it.Release()
+		for it.Next() {
+			tablePath := findDBTable(tables, it.Key())
+			if bytes.Equal(it.Key(), integration.FlushIDKey) {
+				tablePath = flushIDTablePath
+			}
+			s := stats[tablePath]
+			if s == nil {
+				s = &dbTableStats{path: tablePath}
+				stats[tablePath] = s
+			}
+			for _, s := range []*dbTableStats{s, &total} {
+				s.keys++
+				s.keysSize += uint64(len(it.Key()))
+				s.valueSize += uint64(len(it.Value()))
+			}
+			if time.Since(reported) >= statsReportLimit {
+				log.Info("Inspecting database", "name", name, "keys", total.keys, "elapsed", common.PrettyDuration(time.Since(start)))
+				reported = time.Now()
+			}
+		}
+		if it.Error() != nil {
+			return it.Error()
+		}
+
+		sorted := make([]*dbTableStats, 0, len(stats))
+		for _, s := range stats {
+			sorted = append(sorted, s)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].path < sorted[j].path
+		})
+		fmt.Printf("%s:\n", name)
+		fmt.Printf("  %-16s %-40s %12s %12s %12s\n", "TABLE", "NAME", "KEYS", "KEYS SIZE", "VALUES SIZE")
+		for _, s := range sorted {
+			tablePath, tableName := s.path, dbTableName(tables, s.path)
+			switch tablePath {
+			case "":
+				tablePath, tableName = "?", "unknown"
+			case flushIDTablePath:
+				tableName = "FlushID"
+			}
+			fmt.Printf("  %-16s %-40s %12d %12s %12s\n", tablePath, tableName, s.keys,
+				common.StorageSize(s.keysSize).String(), common.StorageSize(s.valueSize).String())
+		}
+		fmt.Printf("  %-16s %-40s %12d %12s %12s\n\n", "", "total", total.keys,
+			common.StorageSize(total.keysSize).String(), common.StorageSize(total.valueSize).String())
+		return nil
+	})
+}
+
+// parseDBKey parses <table> <key> arguments into the database name and the full key.
+func parseDBKey(ctx *cli.Context) (name string, key []byte) {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	tablePath := strings.Split(ctx.Args().Get(0), "/")
+	name = tablePath[0]
+	for _, prefix := range tablePath[1:] {
+		if prefix != "-" {
+			key = append(key, prefix...)
+		}
+	}
+	keyHex := ctx.Args().Get(1)
+	if !strings.HasPrefix(keyHex, "0x") {
+		keyHex = "0x" + keyHex
+	}
+	k, err := hexutil.Decode(keyHex)
+	if err != nil {
+		utils.Fatalf("Invalid key %s: %v", ctx.Args().Get(1), err)
+	}
+	return name, append(key, k...)
+}
+
+func withDB(ctx *cli.Context, name string, fn func(db kvdb.Store) error) error {
+	producer := makeDBProducer(ctx)
+	if _, err := dbNames(producer, name); err != nil {
+		return err
+	}
+	db, err := producer.OpenDB(name)
+	if err != nil {
+		return fmt.Errorf("failed to open %s DB: %v", name, err)
+	}
+	defer db.Close()
+	return fn(db)
+}
+
+func dbGet(ctx *cli.Context) error {
+	name, key := parseDBKey(ctx)
+	return withDB(ctx, name, func(db kvdb.Store) error {
+		value, err := db.Get(key)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			return fmt.Errorf("key %s not found", hexutil.Encode(key))
+		}
+		fmt.Println(hexutil.Encode(value))
+		return nil
+	})
+}
+
+func dbDelete(ctx *cli.Context) error {
+	name, key := parseDBKey(ctx)
+	return withDB(ctx, name, func(db kvdb.Store) error {
+		ok, err := db.Has(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("key %s not found", hexutil.Encode(key))
+		}
+		if err := db.Delete(key); err != nil {
+			return err
+		}
+		log.Info("Deleted key", "db", name, "key", hexutil.Encode(key))
+		return nil
+	})
+}
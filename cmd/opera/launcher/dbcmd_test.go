@@ -0,0 +1,34 @@
+package launcher
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDBTable(t *testing.T) {
+	h := common.Hash{0xaa}
+	for _, tt := range []struct {
+		key  []byte
+		path string
+		name string
+	}{
+		{[]byte("b\x00\x01"), "b", "Blocks"},
+		{[]byte("Lt\x01"), "L/t", "EvmLogs/Topic"},
+		{[]byte("S1\x01"), "S/1", "SfcAPI/Validators"},
+		{append([]byte("M"), h[:]...), "M/-", "EvmState/TrieNodes"},
+		{append([]byte("Mc"), h[:]...), "M/c", "EvmState/Code"},
+		{append([]byte("Mo"), append(h[:], h[:]...)...), "M/o", "EvmState/SnapshotStorage"},
+		{[]byte("MSnapshotRoot"), "M", "EvmState"},
+		{[]byte("?"), "", ""},
+	} {
+		path := findDBTable(gossipTables, tt.key)
+		require.Equal(t, tt.path, path, string(tt.key))
+		require.Equal(t, tt.name, dbTableName(gossipTables, path), string(tt.key))
+	}
+
+	require.Equal(t, "v/S", findDBTable(getDBTables("lachesis-5"), []byte("vS\x01")))
+	require.Equal(t, "v/T", findDBTable(getDBTables("gossip-5"), []byte("vT\x01")))
+	require.Equal(t, "Z", findDBTable(getDBTables("gossip-async"), []byte("Z\x01")))
+}
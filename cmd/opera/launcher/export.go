@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"bufio"
 	"compress/gzip"
 	"errors"
 	"io"
@@ -22,11 +23,20 @@ import (
 
 	"github.com/Fantom-foundation/go-opera/gossip"
 	"github.com/Fantom-foundation/go-opera/integration"
+	"github.com/Fantom-foundation/go-opera/utils/eventsfile"
+	"github.com/Fantom-foundation/go-opera/utils/iodb"
 )
 
 var (
 	eventsFileHeader  = hexutils.HexToBytes("7e995678")
 	eventsFileVersion = hexutils.HexToBytes("00010001")
+
+	blocksFileHeader    = hexutils.HexToBytes("7e995679")
+	blocksFileVersion   = hexutils.HexToBytes("00010001")
+	receiptsFileHeader  = hexutils.HexToBytes("7e99567a")
+	receiptsFileVersion = hexutils.HexToBytes("00010001")
+	stateFileHeader     = hexutils.HexToBytes("7e99567b")
+	stateFileVersion    = hexutils.HexToBytes("00010001")
 )
 
 // statsReportLimit is the time limit during import and export after which we
@@ -48,6 +58,13 @@ func exportEvents(ctx *cli.Context) error {
 	defer gdb.Close()
 
 	fn := ctx.Args().First()
+	format := ctx.Int(EventsFormatFlag.Name)
+	if format != 1 && format != 2 {
+		utils.Fatalf("Unknown events file format %d", format)
+	}
+	if format == 2 && strings.HasSuffix(fn, ".gz") {
+		utils.Fatalf("Version 2 events files cannot be gzipped, use --%s instead", EventsZstdFlag.Name)
+	}
 
 	// Open the file handle and potentially wrap with a gzip stream
 	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
@@ -79,7 +96,23 @@ func exportEvents(ctx *cli.Context) error {
 		to = idx.Epoch(n)
 	}
 
-	log.Info("Exporting events to file", "file", fn)
+	log.Info("Exporting events to file", "file", fn, "format", format)
+	if format == 2 {
+		compression := eventsfile.CompressionNone
+		if ctx.Bool(EventsZstdFlag.Name) {
+			compression = eventsfile.CompressionZstd
+		}
+		bufWriter := bufio.NewWriter(writer)
+		err = exportToV2(bufWriter, compression, gdb, from, to)
+		if err == nil {
+			err = bufWriter.Flush()
+		}
+		if err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+		return nil
+	}
+
 	// Write header and version
 	_, err = writer.Write(append(eventsFileHeader, eventsFileVersion...))
 	if err != nil {
@@ -125,6 +158,28 @@ func makeRawGossipStore(rawProducer kvdb.IterableDBProducer, cfg *config) (*goss
 
 // exportTo writer the active chain.
 func exportTo(w io.Writer, gdb *gossip.Store, from, to idx.Epoch) (err error) {
+	return exportEventsRLP(gdb, from, to, func(id hash.Event, event rlp.RawValue) error {
+		_, err := w.Write(event)
+		return err
+	})
+}
+
+// exportToV2 writes the active chain in the version 2 format.
+func exportToV2(w io.Writer, compression byte, gdb *gossip.Store, from, to idx.Epoch) error {
+	fw, err := eventsfile.NewWriter(w, compression)
+	if err != nil {
+		return err
+	}
+	err = exportEventsRLP(gdb, from, to, func(id hash.Event, event rlp.RawValue) error {
+		return fw.Write(id.Epoch(), event)
+	})
+	if err != nil {
+		return err
+	}
+	return fw.Close()
+}
+
+func exportEventsRLP(gdb *gossip.Store, from, to idx.Epoch, write func(id hash.Event, event rlp.RawValue) error) (err error) {
 	start, reported := time.Now(), time.Time{}
 
 	var (
@@ -136,7 +191,7 @@ func exportTo(w io.Writer, gdb *gossip.Store, from, to idx.Epoch) (err error) {
 			return false
 		}
 		counter++
-		_, err = w.Write(event)
+		err = write(id, event)
 		if err != nil {
 			return false
 		}
@@ -151,3 +206,205 @@ func exportTo(w io.Writer, gdb *gossip.Store, from, to idx.Epoch) (err error) {
 
 	return
 }
+
+// openExportFile creates the file, potentially wrapped with a gzip stream, and writes the header and version.
+func openExportFile(fn string, header, version []byte) (io.Writer, func() error, error) {
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return nil, nil, err
+	}
+	bufWriter := bufio.NewWriter(fh)
+	var (
+		writer io.Writer = bufWriter
+		gz     *gzip.Writer
+	)
+	if strings.HasSuffix(fn, ".gz") {
+		gz = gzip.NewWriter(bufWriter)
+		writer = gz
+	}
+	closeFn := func() error {
+		if gz != nil {
+			if err := gz.Close(); err != nil {
+				return err
+			}
+		}
+		if err := bufWriter.Flush(); err != nil {
+			return err
+		}
+		return fh.Close()
+	}
+	_, err = writer.Write(append(append([]byte{}, header...), version...))
+	if err != nil {
+		_ = fh.Close()
+		return nil, nil, err
+	}
+	return writer, closeFn, nil
+}
+
+// parseExportRange parses the optional range arguments starting from the position.
+// If --epochs is set, then the epochs range is converted into the blocks range.
+func parseExportRange(ctx *cli.Context, gdb *gossip.Store, pos int) (from, to idx.Block) {
+	var args [2]uint64
+	for i := range args {
+		if len(ctx.Args()) <= pos+i {
+			break
+		}
+		n, err := strconv.ParseUint(ctx.Args().Get(pos+i), 10, 64)
+		if err != nil {
+			utils.Fatalf("Invalid range argument: %v", err)
+		}
+		args[i] = n
+	}
+	latest := gdb.GetLatestBlockIndex()
+	if !ctx.Bool(ExportEpochsFlag.Name) {
+		from, to = idx.Block(args[0]), idx.Block(args[1])
+		if to == 0 || to > latest {
+			to = latest
+		}
+		return from, to
+	}
+
+	fromEpoch, toEpoch := idx.Epoch(args[0]), idx.Epoch(args[1])
+	if toEpoch == 0 {
+		toEpoch = gdb.GetEpoch()
+	}
+	from, to, ok := gdb.FindEpochBlocks(fromEpoch, toEpoch)
+	if !ok {
+		utils.Fatalf("No blocks in epochs %d-%d", fromEpoch, toEpoch)
+	}
+	return from, to
+}
+
+func exportBlocks(ctx *cli.Context) error {
+	return exportBlockRecords(ctx, "blocks", blocksFileHeader, blocksFileVersion, func(gdb *gossip.Store, n idx.Block) interface{} {
+		rec, err := gdb.GetBlockRecord(n)
+		if err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+		if rec != nil {
+			return rec
+		}
+		return nil
+	})
+}
+
+func exportReceipts(ctx *cli.Context) error {
+	return exportBlockRecords(ctx, "receipts", receiptsFileHeader, receiptsFileVersion, func(gdb *gossip.Store, n idx.Block) interface{} {
+		if rec := gdb.GetReceiptsRecord(n); rec != nil {
+			return rec
+		}
+		return nil
+	})
+}
+
+// exportBlockRecords writes RLP-encoded records of the blocks range, get returns nil for missing records.
+func exportBlockRecords(ctx *cli.Context, name string, header, version []byte, get func(gdb *gossip.Store, n idx.Block) interface{}) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	fn := ctx.Args().First()
+	from, to := parseExportRange(ctx, gdb, 1)
+
+	writer, closeFile, err := openExportFile(fn, header, version)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Exporting "+name+" to file", "file", fn, "from", from, "to", to)
+	start, reported := time.Now(), time.Now()
+	counter := 0
+	for n := from; n <= to; n++ {
+		rec := get(gdb, n)
+		if rec == nil {
+			continue
+		}
+		err = rlp.Encode(writer, rec)
+		if err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+		counter++
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Exporting "+name, "last", n, "exported", counter, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	err = closeFile()
+	if err != nil {
+		return err
+	}
+	log.Info("Exported "+name, "from", from, "to", to, "exported", counter, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportState writes the state root of the block followed by the trie nodes and codes in the iodb format.
+func exportState(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	fn := ctx.Args().First()
+	n := gdb.GetLatestBlockIndex()
+	if len(ctx.Args()) > 1 {
+		v, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		if err != nil {
+			utils.Fatalf("Invalid block argument: %v", err)
+		}
+		n = idx.Block(v)
+		if ctx.Bool(ExportEpochsFlag.Name) {
+			_, last, ok := gdb.FindEpochBlocks(idx.Epoch(v), idx.Epoch(v))
+			if !ok {
+				utils.Fatalf("No blocks in epoch %d", v)
+			}
+			n = last
+		}
+	}
+	block := gdb.GetBlock(n)
+	if block == nil {
+		utils.Fatalf("Block %d not found", n)
+	}
+
+	writer, closeFile, err := openExportFile(fn, stateFileHeader, stateFileVersion)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Exporting EVM state to file", "file", fn, "block", n, "root", block.Root.String())
+	start := time.Now()
+	_, err = writer.Write(block.Root.Bytes())
+	if err != nil {
+		return err
+	}
+	items := 0
+	err = gdb.ForEachEvmStateItem(block.Root, func(key, value []byte) error {
+		items++
+		return iodb.WriteItem(writer, key, value)
+	})
+	if err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	err = closeFile()
+	if err != nil {
+		return err
+	}
+	log.Info("Exported EVM state", "block", n, "root", block.Root.String(), "items", items, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
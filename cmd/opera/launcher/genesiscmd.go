@@ -0,0 +1,417 @@
+package launcher
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-opera/integration"
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/opera/genesis"
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+)
+
+var (
+	GenesisEpochFlag = cli.Uint64Flag{
+		Name:  "epoch",
+		Usage: "sealed epoch to export (0 means the last sealed epoch)",
+	}
+	GenesisSignKeyFlag = cli.StringFlag{
+		Name:  "genesis.signkey",
+		Usage: "file of the hex private key to sign the genesis file, the signature is written into <filename>.sig",
+	}
+	GenesisSignerFlag = cli.StringFlag{
+		Name:  "genesis.signer",
+		Usage: "address of the expected genesis signer, the signature is read from <filename>.sig",
+	}
+	genesisCommand = cli.Command{
+		Name:     "genesis",
+		Usage:    "Manage genesis files",
+		Category: "MISCELLANEOUS COMMANDS",
+
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export a sealed epoch into a genesis file",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(exportGenesis),
+				Flags: []cli.Flag{
+					DataDirFlag,
+					GenesisEpochFlag,
+				},
+				Description: `
+    opera genesis export --epoch N <filename>
+
+Writes the EVM state, validators, delegations, the last block and rules of the
+sealed epoch N into a genesis file, which may be used to start new nodes from
+this checkpoint. The system contracts are re-initialized from the exported
+validators and delegations, so SFC parameters changed by the owner and pending
+withdrawal requests aren't exported. The EVM state of the epoch must not be pruned.
+`,
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Print contents summary of a genesis file",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(inspectGenesis),
+				Description: `
+    opera genesis inspect <filename>
+
+Prints metadata, rules and validators of the genesis file, total supply,
+delegation totals, account and storage counts, and the first and last blocks.
+`,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Verify hash of a genesis file",
+				ArgsUsage: "<filename>",
+				Action:    utils.MigrateFlags(verifyGenesis),
+				Flags: []cli.Flag{
+					GenesisSignerFlag,
+				},
+				Description: `
+    opera genesis verify [--genesis.signer <address>] <filename>
+
+Recomputes hash of the genesis file contents and checks it against the file
+header hash and the known hash of the genesis network. If the signer is
+specified, the signature of the genesis hash is checked too.
+`,
+			},
+			{
+				Name:      "build",
+				Usage:     "Build a genesis file from a JSON or TOML spec",
+				ArgsUsage: "<spec> <filename>",
+				Action:    utils.MigrateFlags(buildGenesis),
+				Flags: []cli.Flag{
+					GenesisSignKeyFlag,
+				},
+				Description: `
+    opera genesis build [--genesis.signkey <keyfile>] spec.json genesis.g
+
+Builds a genesis file of a private network from the spec, which declares the
+validators (pubkey, optional address and ID, stake), accounts (balance, nonce,
+code and storage), base rules ("main", "test" or "fake") with overrides, genesis
+time, extra data and driver owner. The driver, SFC and network initializer
+contracts are pre-deployed as in the fake genesis. Spec example:
+
+{
+  "BaseRules": "fake",
+  "Rules": {"Name": "private", "NetworkID": 4100, "Economy": {"MinGasPrice": 1000000000}},
+  "Time": 1608600000,
+  "ExtraData": "private",
+  "Validators": [
+    {"PubKey": "0xc004...", "Stake": "5000000000000000000000000"}
+  ],
+  "Accounts": [
+    {"Address": "0x239f...", "Balance": "1000000000000000000000000000"}
+  ]
+}
+
+Files with the .toml extension are decoded as TOML with the same field names.
+If the key file is specified, the genesis hash is signed and the signature is
+written into genesis.g.sig, 'opera genesis verify --genesis.signer' checks it.
+`,
+			},
+		},
+	}
+)
+
+func exportGenesis(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	epoch := idx.Epoch(ctx.Uint64(GenesisEpochFlag.Name))
+	if epoch == 0 {
+		epoch = gdb.GetEpoch() - 1
+	}
+
+	// the exported state may be too large to keep it in memory
+	tmpDir, err := ioutil.TempDir("", "opera-genesis-export")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpDB, err := integration.DBProducer(tmpDir, cacheScaler(ctx)).OpenDB("genesis")
+	if err != nil {
+		return err
+	}
+	genesisStore := genesisstore.NewStore(tmpDB)
+	defer genesisStore.Close()
+
+	start := time.Now()
+	log.Info("Exporting genesis", "epoch", epoch)
+	err = gdb.ExportGenesis(epoch, genesisStore)
+	if err != nil {
+		return err
+	}
+
+	fn := ctx.Args().First()
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	log.Info("Writing genesis file", "file", fn)
+	err = genesisstore.WriteGenesisStore(fh, genesisStore)
+	if err != nil {
+		return err
+	}
+	log.Info("Exported genesis", "epoch", epoch, "hash", genesisStore.Hash().String(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// openGenesisFile decodes the genesis file into a temporary DB.
+func openGenesisFile(ctx *cli.Context, fn string) (headerHash hash.Hash, genesisStore *genesisstore.Store, closeFn func(), err error) {
+	return decodeGenesisFile(fn, cacheScaler(ctx))
+}
+
+// decodeGenesisFile reads the genesis file into a temporary store, which is erased by closeFn.
+func decodeGenesisFile(fn string, scale cachescale.Func) (headerHash hash.Hash, genesisStore *genesisstore.Store, closeFn func(), err error) {
+	fh, err := os.Open(fn)
+	if err != nil {
+		return hash.Zero, nil, nil, err
+	}
+	defer fh.Close()
+	headerHash, readGenesisStore, err := genesisstore.OpenGenesisStore(fh)
+	if err != nil {
+		return hash.Zero, nil, nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "opera-genesis")
+	if err != nil {
+		return hash.Zero, nil, nil, err
+	}
+	tmpDB, err := integration.DBProducer(tmpDir, scale).OpenDB("genesis")
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return hash.Zero, nil, nil, err
+	}
+	genesisStore = genesisstore.NewStore(tmpDB)
+	closeFn = func() {
+		genesisStore.Close()
+		_ = os.RemoveAll(tmpDir)
+	}
+	log.Info("Decoding genesis file", "file", fn)
+	err = readGenesisStore(genesisStore)
+	if err != nil {
+		closeFn()
+		return hash.Zero, nil, nil, err
+	}
+	return headerHash, genesisStore, closeFn, nil
+}
+
+func inspectGenesis(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	headerHash, genesisStore, closeFn, err := openGenesisFile(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	g := genesisStore.GetGenesis()
+
+	fmt.Printf("Header hash:     %s\n", headerHash.String())
+	fmt.Printf("Network:         %s (ID %d)\n", g.Rules.Name, g.Rules.NetworkID)
+	fmt.Printf("First epoch:     %d\n", g.FirstEpoch)
+	fmt.Printf("Time:            %s\n", g.Time.Time().UTC())
+	fmt.Printf("Prev epoch time: %s\n", g.PrevEpochTime.Time().UTC())
+	fmt.Printf("Extra data:      %s\n", hexutil.Encode(g.ExtraData))
+	fmt.Printf("Driver owner:    %s\n", g.DriverOwner.String())
+	fmt.Printf("Total supply:    %s\n", g.TotalSupply)
+	fmt.Printf("Rules:           %s\n", g.Rules.String())
+
+	fmt.Printf("Validators:      %d\n", len(g.Validators))
+	for _, v := range g.Validators {
+		fmt.Printf("  #%d %s pubkey=%s status=%d created=%d deactivated=%d\n",
+			v.ID, v.Address.String(), v.PubKey.String(), v.Status, v.CreationEpoch, v.DeactivatedEpoch)
+	}
+
+	delegations := 0
+	stake, lockedStake, rewards := new(big.Int), new(big.Int), new(big.Int)
+	g.Delegations.ForEach(func(_ common.Address, _ idx.ValidatorID, d genesis.Delegation) {
+		delegations++
+		stake.Add(stake, d.Stake)
+		lockedStake.Add(lockedStake, d.LockedStake)
+		rewards.Add(rewards, d.Rewards)
+	})
+	fmt.Printf("Delegations:     %d, stake=%s locked=%s rewards=%s\n", delegations, stake, lockedStake, rewards)
+
+	accounts, balance := 0, new(big.Int)
+	g.Accounts.ForEach(func(_ common.Address, acc genesis.Account) {
+		accounts++
+		balance.Add(balance, acc.Balance)
+	})
+	fmt.Printf("Accounts:        %d, balance=%s\n", accounts, balance)
+	storage := 0
+	g.Storage.ForEach(func(common.Address, common.Hash, common.Hash) {
+		storage++
+	})
+	fmt.Printf("Storage slots:   %d\n", storage)
+	rawItems := 0
+	it := g.RawEvmItems.NewIterator(nil, nil)
+	for it.Next() {
+		rawItems++
+	}
+	it.Release()
+	fmt.Printf("Raw EVM items:   %d\n", rawItems)
+
+	var (
+		blocks      int
+		first, last idx.Block
+		firstBlock  genesis.Block
+		lastBlock   genesis.Block
+	)
+	g.Blocks.ForEach(func(n idx.Block, b genesis.Block) {
+		if blocks == 0 {
+			first, firstBlock = n, b
+		}
+		last, lastBlock = n, b
+		blocks++
+	})
+	fmt.Printf("Blocks:          %d\n", blocks)
+	if blocks != 0 {
+		printBlock := func(name string, n idx.Block, b genesis.Block) {
+			fmt.Printf("  %s #%d atropos=%s root=%s time=%s txs=%d internal=%d\n",
+				name, n, b.Atropos.String(), b.Root.String(), b.Time.Time().UTC(), len(b.Txs), len(b.InternalTxs))
+		}
+		printBlock("first", first, firstBlock)
+		printBlock("last", last, lastBlock)
+	}
+	return nil
+}
+
+func verifyGenesis(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	headerHash, genesisStore, closeFn, err := openGenesisFile(ctx, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	log.Info("Calculating genesis hash")
+	contentHash := genesisStore.Hash()
+	if contentHash != headerHash {
+		return fmt.Errorf("genesis hash mismatch: header %s, contents %s", headerHash.String(), contentHash.String())
+	}
+	rules := genesisStore.GetRules()
+	if want, ok := AllowedOperaGenesisHashes[rules.NetworkID]; ok {
+		if want != contentHash {
+			return fmt.Errorf("genesis hash isn't allowed for the network %d: want %s, got %s", rules.NetworkID, want.String(), contentHash.String())
+		}
+		log.Info("Genesis is the known genesis of the network", "network", rules.Name, "hash", contentHash.String())
+	} else {
+		log.Warn("Genesis network is unknown, its hash isn't pinned", "network", rules.Name, "id", rules.NetworkID)
+	}
+	if ctx.IsSet(GenesisSignerFlag.Name) {
+		signer := ctx.String(GenesisSignerFlag.Name)
+		if !common.IsHexAddress(signer) {
+			return fmt.Errorf("invalid --%s flag: must be an address", GenesisSignerFlag.Name)
+		}
+		err = genesisstore.VerifySignatureFile(ctx.Args().First(), contentHash, common.HexToAddress(signer))
+		if err != nil {
+			return fmt.Errorf("genesis signature isn't valid: %v", err)
+		}
+		log.Info("Genesis signature is valid", "signer", signer)
+	}
+	log.Info("Genesis file is valid", "hash", contentHash.String())
+	return nil
+}
+
+func loadGenesisSpec(fn string) (*makegenesis.Spec, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	spec := &makegenesis.Spec{}
+	if strings.HasSuffix(fn, ".toml") {
+		err = tomlSettings.NewDecoder(bufio.NewReader(f)).Decode(spec)
+	} else {
+		dec := json.NewDecoder(bufio.NewReader(f))
+		dec.DisallowUnknownFields()
+		// keep big numbers of the rules overrides precise
+		dec.UseNumber()
+		err = dec.Decode(spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn, err)
+	}
+	return spec, nil
+}
+
+func buildGenesis(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	spec, err := loadGenesisSpec(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	var signKey *ecdsa.PrivateKey
+	if ctx.IsSet(GenesisSignKeyFlag.Name) {
+		signKey, err = crypto.LoadECDSA(ctx.String(GenesisSignKeyFlag.Name))
+		if err != nil {
+			return fmt.Errorf("failed to load genesis sign key: %v", err)
+		}
+	}
+
+	genesisStore := genesisstore.NewMemStore()
+	defer genesisStore.Close()
+	err = spec.Build(genesisStore)
+	if err != nil {
+		return err
+	}
+
+	fn := ctx.Args().Get(1)
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	err = genesisstore.WriteGenesisStore(fh, genesisStore)
+	if err != nil {
+		return err
+	}
+	rules := genesisStore.GetRules()
+	h := genesisStore.Hash()
+	log.Info("Built genesis", "file", fn, "network", rules.Name, "id", rules.NetworkID, "hash", h.String())
+	if signKey != nil {
+		err = genesisstore.WriteSignatureFile(fn, h, signKey)
+		if err != nil {
+			return err
+		}
+		log.Info("Signed genesis", "file", fn+genesisstore.SignatureFileSuffix, "signer", crypto.PubkeyToAddress(signKey.PublicKey).String())
+	}
+	return nil
+}
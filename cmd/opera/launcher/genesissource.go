@@ -0,0 +1,217 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+	"github.com/Fantom-foundation/go-opera/utils/download"
+)
+
+// GenesisHashFlag pins the hash of genesis file
+var GenesisHashFlag = cli.StringFlag{
+	Name:  "genesis.hash",
+	Usage: "Pinned genesis hash, the first genesis source with this hash is used (known networks are pinned by default)",
+}
+
+const (
+	// genesisConnectTimeout limits connecting to a genesis mirror and waiting for its response headers
+	genesisConnectTimeout = 30 * time.Second
+	// genesisDownloadTimeout limits a genesis download, an interrupted download is resumed by the next start
+	genesisDownloadTimeout = 2 * time.Hour
+)
+
+// genesisSources selects a genesis file from a list of local files, file:// and http(s):// mirrors.
+// Remote files are downloaded into the cache directory, and interrupted downloads are resumed.
+// Contents of downloaded files are verified against the genesis hash, local files are checked by the header only.
+type genesisSources struct {
+	sources    []string
+	pinned     *hash.Hash
+	cacheDir   string
+	cacheScale cachescale.Func
+	client     *http.Client
+}
+
+func newGenesisHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSHandshakeTimeout = genesisConnectTimeout
+	transport.ResponseHeaderTimeout = genesisConnectTimeout
+	transport.DialContext = (&net.Dialer{
+		Timeout:   genesisConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   genesisDownloadTimeout,
+	}
+}
+
+func splitGenesisSources(s string) []string {
+	var sources []string
+	for _, src := range strings.Split(s, ",") {
+		if src = strings.TrimSpace(src); src != "" {
+			sources = append(sources, src)
+		}
+	}
+	return sources
+}
+
+// checkHash returns an error if the genesis hash isn't pinned by the flag or by the known networks.
+// An unpinned hash is allowed only if there's a single source.
+func (g *genesisSources) checkHash(h hash.Hash) error {
+	if g.pinned != nil {
+		if h != *g.pinned {
+			return fmt.Errorf("genesis hash %s mismatches the pinned hash %s", h.String(), g.pinned.String())
+		}
+		return nil
+	}
+	for _, known := range AllowedOperaGenesisHashes {
+		if h == known {
+			return nil
+		}
+	}
+	if len(g.sources) > 1 {
+		return fmt.Errorf("genesis hash %s isn't pinned, specify it with --%s", h.String(), GenesisHashFlag.Name)
+	}
+	return nil
+}
+
+// Select returns path of the first genesis file with the pinned hash.
+func (g *genesisSources) Select() (string, error) {
+	for _, src := range g.sources {
+		path, err := g.get(src)
+		if err == nil {
+			return path, nil
+		}
+		log.Warn("Genesis source is skipped", "source", src, "err", err)
+	}
+	return "", errors.New("no genesis source with the pinned hash is available")
+}
+
+func (g *genesisSources) get(src string) (string, error) {
+	u, err := url.Parse(src)
+	if err != nil || !strings.Contains(src, "://") {
+		return g.getFile(src)
+	}
+	switch u.Scheme {
+	case "file":
+		return g.getFile(u.Path)
+	case "http", "https":
+		return g.download(src)
+	default:
+		return "", fmt.Errorf("unsupported scheme %s", u.Scheme)
+	}
+}
+
+func (g *genesisSources) getFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h, err := genesisstore.ReadGenesisHash(f)
+	if err != nil {
+		return "", err
+	}
+	return path, g.checkHash(h)
+}
+
+func (g *genesisSources) cachePath(h hash.Hash) string {
+	return filepath.Join(g.cacheDir, h.String()+".g")
+}
+
+// getCached returns the cached genesis file if its contents match the hash, a mismatched file is removed.
+func (g *genesisSources) getCached(h hash.Hash) (string, error) {
+	path := g.cachePath(h)
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	err := g.checkContents(path, h)
+	if err != nil {
+		log.Warn("Cached genesis is removed", "path", path, "err", err)
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// checkContents decodes the genesis file and checks that both its header and its contents match the hash.
+func (g *genesisSources) checkContents(path string, h hash.Hash) error {
+	headerHash, genesisStore, closeFn, err := decodeGenesisFile(path, g.cacheScale)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	if headerHash != h {
+		return fmt.Errorf("genesis header hash %s mismatches %s", headerHash.String(), h.String())
+	}
+	log.Info("Calculating genesis hash", "path", path)
+	if contentHash := genesisStore.Hash(); contentHash != h {
+		return fmt.Errorf("genesis hash mismatch: header %s, contents %s", h.String(), contentHash.String())
+	}
+	return nil
+}
+
+func (g *genesisSources) download(src string) (string, error) {
+	// a cached file doesn't need network
+	if g.pinned != nil {
+		if path, err := g.getCached(*g.pinned); err == nil {
+			return path, nil
+		}
+	}
+
+	head, err := download.Head(g.client, src, genesisstore.HeaderSize())
+	if err != nil {
+		return "", err
+	}
+	h, err := genesisstore.ReadGenesisHash(bytes.NewReader(head))
+	if err != nil {
+		return "", err
+	}
+	if err := g.checkHash(h); err != nil {
+		return "", err
+	}
+	if path, err := g.getCached(h); err == nil {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(g.cacheDir, 0700); err != nil {
+		return "", err
+	}
+	path := g.cachePath(h)
+	log.Info("Downloading genesis", "source", src, "hash", h.String(), "path", path)
+	start := time.Now()
+	reported := start
+	err = download.File(g.client, src, path, func(done, total int64) {
+		if time.Since(reported) < statsReportLimit {
+			return
+		}
+		reported = time.Now()
+		if total < 0 {
+			log.Info("Downloading genesis", "done", common.StorageSize(done), "elapsed", common.PrettyDuration(time.Since(start)))
+			return
+		}
+		log.Info("Downloading genesis", "done", common.StorageSize(done), "total", common.StorageSize(total),
+			"progress", fmt.Sprintf("%.2f%%", 100*float64(done)/float64(total)), "elapsed", common.PrettyDuration(time.Since(start)))
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Info("Genesis is downloaded", "path", path, "elapsed", common.PrettyDuration(time.Since(start)))
+	// the remote file may be replaced or corrupted during the download
+	return g.getCached(h)
+}
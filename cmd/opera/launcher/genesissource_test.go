@@ -0,0 +1,112 @@
+package launcher
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+)
+
+func TestGenesisSources(t *testing.T) {
+	require := require.New(t)
+
+	genesis := bytes.NewBuffer(nil)
+	genesisStore := makegenesis.FakeGenesisStore(1, big.NewInt(1), big.NewInt(1))
+	require.NoError(genesisstore.WriteGenesisStore(genesis, genesisStore))
+	genesisHash := genesisStore.Hash()
+	otherHash := hash.Hash{1}
+
+	// the contents are corrupted, but the header is correct
+	corrupted := append([]byte{}, genesis.Bytes()...)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.ServeContent(w, r, "fake.g", time.Time{}, bytes.NewReader(genesis.Bytes()))
+	}))
+	defer srv.Close()
+	corruptedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "fake.g", time.Time{}, bytes.NewReader(corrupted))
+	}))
+	defer corruptedSrv.Close()
+
+	dir, err := ioutil.TempDir("", "genesis_sources_test")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	local := filepath.Join(dir, "local.g")
+	require.NoError(ioutil.WriteFile(local, genesis.Bytes(), 0600))
+	cacheDir := filepath.Join(dir, "cache")
+
+	newSources := func(pinned *hash.Hash, sources ...string) *genesisSources {
+		return &genesisSources{
+			sources:    sources,
+			pinned:     pinned,
+			cacheDir:   cacheDir,
+			cacheScale: cachescale.Identity,
+			client:     srv.Client(),
+		}
+	}
+
+	// mismatched and missing sources are skipped
+	_, err = newSources(&otherHash, srv.URL, local).Select()
+	require.Error(err)
+	path, err := newSources(&genesisHash, filepath.Join(dir, "missing.g"), "file://"+local).Select()
+	require.NoError(err)
+	require.Equal(local, path)
+
+	// unpinned hash is allowed only for a single source
+	_, err = newSources(nil, local, srv.URL).Select()
+	require.Error(err)
+	path, err = newSources(nil, local).Select()
+	require.NoError(err)
+	require.Equal(local, path)
+
+	// download is resumed
+	cached := filepath.Join(cacheDir, genesisHash.String()+".g")
+	require.NoError(os.MkdirAll(cacheDir, 0700))
+	require.NoError(ioutil.WriteFile(cached+".part", genesis.Bytes()[:genesis.Len()/2], 0600))
+	path, err = newSources(&genesisHash, srv.URL, local).Select()
+	require.NoError(err)
+	require.Equal(cached, path)
+	got, err := ioutil.ReadFile(cached)
+	require.NoError(err)
+	require.Equal(genesis.Bytes(), got)
+
+	// cached file is used without network
+	requests = 0
+	path, err = newSources(&genesisHash, srv.URL).Select()
+	require.NoError(err)
+	require.Equal(cached, path)
+	require.Zero(requests)
+
+	// corrupted cached file is removed and downloaded again
+	require.NoError(ioutil.WriteFile(cached, corrupted, 0600))
+	path, err = newSources(&genesisHash, srv.URL).Select()
+	require.NoError(err)
+	require.Equal(cached, path)
+	require.NotZero(requests)
+	got, err = ioutil.ReadFile(cached)
+	require.NoError(err)
+	require.Equal(genesis.Bytes(), got)
+
+	// corrupted download is removed, and the next source is used
+	require.NoError(os.Remove(cached))
+	path, err = newSources(&genesisHash, corruptedSrv.URL, local).Select()
+	require.NoError(err)
+	require.Equal(local, path)
+	_, err = os.Stat(cached)
+	require.True(os.IsNotExist(err))
+}
@@ -1,6 +1,7 @@
 package launcher
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"errors"
@@ -28,6 +29,7 @@ import (
 	"github.com/Fantom-foundation/go-opera/gossip/emitter"
 	"github.com/Fantom-foundation/go-opera/integration"
 	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/utils/eventsfile"
 	"github.com/Fantom-foundation/go-opera/utils/iodb"
 	"github.com/Fantom-foundation/go-opera/utils/ioread"
 )
@@ -95,12 +97,25 @@ func importEvents(ctx *cli.Context) error {
 		utils.Fatalf("This command requires an argument.")
 	}
 
-	// avoid P2P interaction, API calls and events emitting
-	genesis := getOperaGenesis(ctx)
 	cfg := makeAllConfigs(ctx)
+	genesis := getOperaGenesis(ctx, cfg)
+	setImportConfig(cfg)
+
+	err := importEventsToNode(ctx, cfg, genesis, ctx.Args()...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setImportConfig avoids P2P interaction, API calls and events emitting.
+func setImportConfig(cfg *config) {
 	cfg.Opera.Protocol.EventsSemaphoreLimit.Size = math.MaxUint32
 	cfg.Opera.Protocol.EventsSemaphoreLimit.Num = math.MaxUint32
+	// the validators may be running on another node, all the emitters are disabled
 	cfg.Opera.Emitter.Validator = emitter.ValidatorConfig{}
+	cfg.Opera.ExtraEmitters = nil
 	cfg.Opera.TxPool.Journal = ""
 	cfg.Node.IPCPath = ""
 	cfg.Node.HTTPHost = ""
@@ -113,13 +128,6 @@ func importEvents(ctx *cli.Context) error {
 	cfg.Node.P2P.BootstrapNodesV5 = nil
 	cfg.Node.P2P.StaticNodes = nil
 	cfg.Node.P2P.TrustedNodes = nil
-
-	err := importEventsToNode(ctx, cfg, genesis, ctx.Args()...)
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
 func importEventsToNode(ctx *cli.Context, cfg *config, genesis integration.InputGenesis, args ...string) error {
@@ -138,18 +146,22 @@ func importEventsToNode(ctx *cli.Context, cfg *config, genesis integration.Input
 }
 
 func checkEventsFileHeader(reader io.Reader) error {
-	headerAndVersion := make([]byte, len(eventsFileHeader)+len(eventsFileVersion))
+	return checkFileHeader(reader, eventsFileHeader, eventsFileVersion, "events")
+}
+
+func checkFileHeader(reader io.Reader, header, version []byte, kind string) error {
+	headerAndVersion := make([]byte, len(header)+len(version))
 	err := ioread.ReadAll(reader, headerAndVersion)
 	if err != nil {
 		return err
 	}
-	if bytes.Compare(headerAndVersion[:len(eventsFileHeader)], eventsFileHeader) != 0 {
-		return errors.New("expected an events file, mismatched file header")
+	if bytes.Compare(headerAndVersion[:len(header)], header) != 0 {
+		return errors.New(fmt.Sprintf("expected %s file, mismatched file header", kind))
 	}
-	if bytes.Compare(headerAndVersion[len(eventsFileHeader):], eventsFileVersion) != 0 {
-		got := hexutils.BytesToHex(headerAndVersion[len(eventsFileHeader):])
-		expected := hexutils.BytesToHex(eventsFileVersion)
-		return errors.New(fmt.Sprintf("wrong version of events file, got=%s, expected=%s", got, expected))
+	if bytes.Compare(headerAndVersion[len(header):], version) != 0 {
+		got := hexutils.BytesToHex(headerAndVersion[len(header):])
+		expected := hexutils.BytesToHex(version)
+		return errors.New(fmt.Sprintf("wrong version of %s file, got=%s, expected=%s", kind, got, expected))
 	}
 	return nil
 }
@@ -174,6 +186,8 @@ func importEventsFile(srv *gossip.Service, fn string) error {
 			return err
 		}
 		defer reader.(*gzip.Reader).Close()
+	} else if isEventsFileV2(fh) {
+		return importEventsFileV2(srv, fh, interrupt)
 	}
 
 	// Check file version and header
@@ -188,7 +202,6 @@ func importEventsFile(srv *gossip.Service, fn string) error {
 
 	batch := make(inter.EventPayloads, 0, 8*1024)
 	batchSize := 0
-	maxBatchSize := 8 * 1024 * 1024
 	epoch := idx.Epoch(0)
 	txs := 0
 	events := 0
@@ -197,14 +210,10 @@ func importEventsFile(srv *gossip.Service, fn string) error {
 		if batch.Len() == 0 {
 			return nil
 		}
-		done := make(chan struct{})
-		err := srv.DagProcessor().Enqueue("", batch.Bases(), true, nil, func() {
-			done <- struct{}{}
-		})
+		err := enqueueEvents(srv, batch)
 		if err != nil {
 			return err
 		}
-		<-done
 		last = batch[batch.Len()-1].ID()
 		batch = batch[:0]
 		batchSize = 0
@@ -229,7 +238,7 @@ func importEventsFile(srv *gossip.Service, fn string) error {
 		if err != nil {
 			return err
 		}
-		if e.Epoch() != epoch || batchSize >= maxBatchSize {
+		if e.Epoch() != epoch || batchSize >= maxImportBatchSize {
 			err = processBatch()
 			if err != nil {
 				return err
@@ -246,3 +255,278 @@ func importEventsFile(srv *gossip.Service, fn string) error {
 
 	return nil
 }
+
+// maxImportBatchSize limits the approximate size of events enqueued at once
+const maxImportBatchSize = 8 * 1024 * 1024
+
+// enqueueEvents enqueues the events into DagProcessor and waits until they are processed.
+func enqueueEvents(srv *gossip.Service, events inter.EventPayloads) error {
+	done := make(chan struct{})
+	err := srv.DagProcessor().Enqueue("", events.Bases(), true, nil, func() {
+		done <- struct{}{}
+	})
+	if err != nil {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// isEventsFileV2 checks the version of the events file and rewinds it.
+func isEventsFileV2(fh io.ReadSeeker) bool {
+	headerAndVersion := make([]byte, len(eventsFileHeader)+len(eventsfile.FileVersion))
+	err := ioread.ReadAll(fh, headerAndVersion)
+	_, _ = fh.Seek(0, io.SeekStart)
+	return err == nil &&
+		bytes.Equal(headerAndVersion[:len(eventsFileHeader)], eventsFileHeader) &&
+		bytes.Equal(headerAndVersion[len(eventsFileHeader):], eventsfile.FileVersion)
+}
+
+// importEventsFileV2 imports events of the version 2 file starting from the current epoch,
+// so an interrupted import is resumed. Every chunk is verified before enqueueing its events.
+func importEventsFileV2(srv *gossip.Service, fh *os.File, interrupt <-chan os.Signal) error {
+	reader, err := eventsfile.NewReader(fh)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// events of the previous epochs are already imported
+	startEpoch := srv.Store().GetEpoch()
+	index, err := eventsfile.ReadIndex(fh)
+	offset := uint64(eventsfile.HeaderSize)
+	if err == nil {
+		offset = 0
+		for _, entry := range index {
+			if entry.Epoch >= startEpoch {
+				offset = entry.Offset
+				break
+			}
+		}
+		if offset == 0 {
+			log.Info("Events file is already imported", "file", fh.Name(), "epoch", startEpoch)
+			return nil
+		}
+	} else {
+		log.Warn("Events file index is unavailable, reading the file from the start", "file", fh.Name(), "err", err)
+	}
+	if err := reader.SeekChunk(offset); err != nil {
+		return err
+	}
+	log.Info("Importing events", "file", fh.Name(), "from epoch", startEpoch)
+
+	start, reported := time.Now(), time.Time{}
+	last := hash.Event{}
+	txs := 0
+	events := 0
+	skipped := 0
+	for {
+		select {
+		case <-interrupt:
+			return fmt.Errorf("interrupted")
+		default:
+		}
+		chunk, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if chunk.Epoch < srv.Store().GetEpoch() {
+			skipped += len(chunk.Events)
+			continue
+		}
+
+		batch := make(inter.EventPayloads, 0, len(chunk.Events))
+		batchSize := 0
+		for _, raw := range chunk.Events {
+			e := new(inter.EventPayload)
+			if err := rlp.DecodeBytes(raw, e); err != nil {
+				return fmt.Errorf("chunk at offset %d: %v", chunk.Offset, err)
+			}
+			if e.Epoch() != chunk.Epoch {
+				return fmt.Errorf("chunk at offset %d of epoch %d contains event %s", chunk.Offset, chunk.Epoch, e.ID().String())
+			}
+			// events of an interrupted import
+			if srv.Store().HasEvent(e.ID()) {
+				skipped++
+				continue
+			}
+			batch = append(batch, e)
+			batchSize += 1024 + e.Size()
+			txs += e.Txs().Len()
+			events++
+			if batchSize >= maxImportBatchSize {
+				if err := enqueueEvents(srv, batch); err != nil {
+					return err
+				}
+				last = batch[batch.Len()-1].ID()
+				batch = batch[:0]
+				batchSize = 0
+			}
+		}
+		if batch.Len() != 0 {
+			if err := enqueueEvents(srv, batch); err != nil {
+				return err
+			}
+			last = batch[batch.Len()-1].ID()
+		}
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Importing events", "last", last.String(), "imported", events, "skipped", skipped, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	srv.WaitBlockEnd()
+	log.Info("Events import is finished", "file", fh.Name(), "last", last.String(), "imported", events, "skipped", skipped, "txs", txs, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	return nil
+}
+
+// openImportFile opens the file, potentially unwraps the gzip stream, and checks the header and version.
+func openImportFile(fn string, header, version []byte, kind string) (io.Reader, func(), error) {
+	fh, err := os.Open(fn)
+	if err != nil {
+		return nil, nil, err
+	}
+	var reader io.Reader = bufio.NewReader(fh)
+	closeFn := func() {
+		_ = fh.Close()
+	}
+	if strings.HasSuffix(fn, ".gz") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			closeFn()
+			return nil, nil, err
+		}
+		reader = gz
+		closeFn = func() {
+			_ = gz.Close()
+			_ = fh.Close()
+		}
+	}
+	if err := checkFileHeader(reader, header, version, kind); err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	return reader, closeFn, nil
+}
+
+func importBlocks(ctx *cli.Context) error {
+	return importBlockRecords(ctx, "blocks", blocksFileHeader, blocksFileVersion, func(gdb *gossip.Store, stream *rlp.Stream) (bool, error) {
+		rec := &gossip.BlockRecord{}
+		if err := stream.Decode(rec); err != nil {
+			return false, err
+		}
+		return gdb.ImportBlockRecord(rec)
+	})
+}
+
+func importReceipts(ctx *cli.Context) error {
+	notIndexed := 0
+	err := importBlockRecords(ctx, "receipts", receiptsFileHeader, receiptsFileVersion, func(gdb *gossip.Store, stream *rlp.Stream) (bool, error) {
+		rec := &gossip.ReceiptsRecord{}
+		if err := stream.Decode(rec); err != nil {
+			return false, err
+		}
+		indexed, err := gdb.ImportReceiptsRecord(rec)
+		if !indexed {
+			notIndexed++
+		}
+		return true, err
+	})
+	if notIndexed != 0 {
+		log.Warn("Logs of receipts aren't indexed because their blocks are missing, import blocks before receipts", "blocks", notIndexed)
+	}
+	return err
+}
+
+// importBlockRecords reads RLP-encoded records of the files, read returns false if the record is skipped.
+func importBlockRecords(ctx *cli.Context, kind string, header, version []byte, read func(gdb *gossip.Store, stream *rlp.Stream) (bool, error)) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	for _, fn := range ctx.Args() {
+		log.Info("Importing "+kind+" from file", "file", fn)
+		reader, closeFile, err := openImportFile(fn, header, version, kind)
+		if err != nil {
+			log.Error("Import error", "file", fn, "err", err)
+			return err
+		}
+		stream := rlp.NewStream(reader, 0)
+		start, reported := time.Now(), time.Now()
+		imported, skipped := 0, 0
+		for {
+			ok, err := read(gdb, stream)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				closeFile()
+				log.Error("Import error", "file", fn, "err", err)
+				return err
+			}
+			if ok {
+				imported++
+			} else {
+				skipped++
+			}
+			if time.Since(reported) >= statsReportLimit {
+				log.Info("Importing "+kind, "imported", imported, "skipped", skipped, "elapsed", common.PrettyDuration(time.Since(start)))
+				reported = time.Now()
+			}
+		}
+		closeFile()
+		log.Info("Imported "+kind+" from file", "file", fn, "imported", imported, "skipped", skipped, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
+
+func importState(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	fn := ctx.Args().First()
+	reader, closeFile, err := openImportFile(fn, stateFileHeader, stateFileVersion, "state")
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+	root := hash.Hash{}
+	err = ioread.ReadAll(reader, root[:])
+	if err != nil {
+		return err
+	}
+
+	log.Info("Importing EVM state from file", "file", fn, "root", root.String())
+	start := time.Now()
+	err = iodb.Read(reader, &restrictedEvmBatch{gdb.EvmStore().EvmKvdbTable().NewBatch()})
+	if err != nil {
+		return err
+	}
+	if _, err := gdb.EvmStore().EvmDatabase().OpenTrie(common.Hash(root)); err != nil {
+		return fmt.Errorf("imported state of root %s is unavailable: %v", root.String(), err)
+	}
+	log.Info("Imported EVM state from file", "file", fn, "root", root.String(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,34 @@
+package launcher
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip"
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+)
+
+func TestSetImportConfig(t *testing.T) {
+	require := require.New(t)
+
+	validators := makegenesis.GetFakeValidators(2)
+	cfg := &config{
+		Node:  defaultNodeConfig(),
+		Opera: gossip.DefaultConfig(cachescale.Identity),
+	}
+	cfg.Opera.Emitter.Validator.ID = validators[0].ID
+	cfg.Opera.Emitter.Validator.PubKey = validators[0].PubKey
+	extra := cfg.Opera.Emitter
+	extra.Validator.ID = validators[1].ID
+	extra.Validator.PubKey = validators[1].PubKey
+	cfg.Opera.ExtraEmitters = append(cfg.Opera.ExtraEmitters, extra)
+	require.NoError(cfg.Opera.Validate())
+
+	setImportConfig(cfg)
+	for _, em := range cfg.Opera.Emitters() {
+		require.Zero(em.Validator.ID)
+	}
+	require.NoError(cfg.Opera.Validate())
+}
@@ -0,0 +1,96 @@
+package launcher
+
+import (
+	"path"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-opera/integration"
+)
+
+func indexAccountTxs(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		utils.Fatalf("This command doesn't require an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	from := idx.Block(ctx.Uint64(IndexFromBlockFlag.Name))
+	to := idx.Block(ctx.Uint64(IndexToBlockFlag.Name))
+	if to == 0 {
+		to = gdb.GetLatestBlockIndex()
+	}
+	if from > to {
+		utils.Fatalf("Invalid blocks range: from=%d to=%d", from, to)
+	}
+
+	log.Info("Indexing account transactions", "from", from, "to", to)
+	start, reported := time.Now(), time.Now()
+	txs := 0
+	gdb.IndexAccountTxs(from, to, func(n idx.Block, blockTxs int) {
+		txs += blockTxs
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Indexing account transactions", "last", n, "txs", txs, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	})
+	log.Info("Indexed account transactions", "blocks", to-from+1, "txs", txs, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	return nil
+}
+
+func indexInternalTransfers(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		utils.Fatalf("This command doesn't require an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	from := idx.Block(ctx.Uint64(IndexFromBlockFlag.Name))
+	to := idx.Block(ctx.Uint64(IndexToBlockFlag.Name))
+	if to == 0 {
+		to = gdb.GetLatestBlockIndex()
+	}
+	if from > to {
+		utils.Fatalf("Invalid blocks range: from=%d to=%d", from, to)
+	}
+
+	log.Info("Indexing internal transfers", "from", from, "to", to)
+	start, reported := time.Now(), time.Now()
+	txs, mismatches := 0, 0
+	err = gdb.IndexInternalTransfers(from, to, func(n idx.Block, blockTxs int) {
+		txs += blockTxs
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Indexing internal transfers", "last", n, "txs", txs, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}, func(n idx.Block) {
+		mismatches++
+		log.Error("Block re-execution resulted in a different state root, block isn't indexed", "block", n)
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("Indexed internal transfers", "txs", txs, "skipped_blocks", mismatches, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	return nil
+}
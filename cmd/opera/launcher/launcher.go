@@ -102,6 +102,7 @@ func initFlags() {
 	}
 	operaFlags = []cli.Flag{
 		GenesisFlag,
+		GenesisHashFlag,
 		utils.IdentityFlag,
 		DataDirFlag,
 		utils.MinFreeDiskSpaceFlag,
@@ -113,7 +114,21 @@ func initFlags() {
 		configFileFlag,
 		validatorIDFlag,
 		validatorPubkeyFlag,
+		validatorNextPubkeyFlag,
+		validatorExtraFlag,
 		validatorPasswordFlag,
+		validatorKeystoreFlag,
+		validatorPKCS11LibFlag,
+		validatorPKCS11TokenFlag,
+		validatorSignerFlag,
+		validatorSignerCertFlag,
+		validatorSignerKeyFlag,
+		validatorSignerCAFlag,
+		validatorSlashingProtectionFlag,
+		validatorFailoverLeaseFlag,
+		validatorFailoverTTLFlag,
+		AccountTxIndexFlag,
+		InternalTransfersIndexFlag,
 	}
 	legacyRpcFlags = []cli.Flag{
 		utils.NoUSBFlag,
@@ -203,8 +218,17 @@ func init() {
 		importCommand,
 		exportCommand,
 		checkCommand,
+		indexCommand,
+		// See genesiscmd.go
+		genesisCommand,
 		// See snapshot.go
 		snapshotCommand,
+		// See replay.go
+		replayCommand,
+		// See dbcmd.go
+		dbCommand,
+		// See backupcmd.go
+		backupCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -255,7 +279,7 @@ func lachesisMain(ctx *cli.Context) error {
 	//defer tracingStop()
 
 	cfg := makeAllConfigs(ctx)
-	genesisPath := getOperaGenesis(ctx)
+	genesisPath := getOperaGenesis(ctx, cfg)
 	node, _, nodeClose := makeNode(ctx, cfg, genesisPath)
 	defer nodeClose()
 	startNode(ctx, node)
@@ -274,26 +298,41 @@ func makeNode(ctx *cli.Context, cfg *config, genesis integration.InputGenesis) (
 	if err := os.MkdirAll(chaindataDir, 0700); err != nil {
 		utils.Fatalf("Failed to create chaindata directory: %v", err)
 	}
-	engine, dagIndex, gdb, cdb, genesisStore, blockProc := integration.MakeEngine(integration.DBProducer(chaindataDir, cacheScaler(ctx)), genesis, cfg.AppConfigs())
+	rawProducer := integration.DBProducer(chaindataDir, cacheScaler(ctx))
+	recovery, err := integration.RecoverDBs(rawProducer, cfg.AppConfigs())
+	if err != nil {
+		utils.Fatalf("Failed to recover databases: %v", err)
+	}
+	engine, dagIndex, gdb, cdb, genesisStore, blockProc := integration.MakeEngine(rawProducer, genesis, cfg.AppConfigs())
 	_ = genesis.Close()
 	metrics.SetDataDir(cfg.Node.DataDir)
 
-	valKeystore := valkeystore.NewDefaultFileKeystore(path.Join(getValKeystoreDir(cfg.Node), "validator"))
 	valPubkey := cfg.Opera.Emitter.Validator.PubKey
+	remoteSigner := ctx.GlobalIsSet(validatorSignerFlag.Name)
+	for _, emitterCfg := range cfg.Opera.Emitters() {
+		if remoteSigner && emitterCfg.Validator.Keystore.IsPKCS11() {
+			utils.Fatalf("Remote signer cannot be used together with PKCS#11 validator keystore")
+		}
+	}
+	valKeystores := newValidatorKeystores(cfg.Node)
+	valKeystore, _ := valKeystores.get(cfg.Opera.Emitter.Validator.Keystore)
 	if key := getFakeValidatorKey(ctx); key != nil && cfg.Opera.Emitter.Validator.ID != 0 {
-		addFakeValidatorKey(ctx, key, valPubkey, valKeystore)
+		if !remoteSigner {
+			addFakeValidatorKey(ctx, key, valPubkey, valKeystore)
+		}
 		coinbase := integration.SetAccountKey(stack.AccountManager(), key, "fakepassword")
 		log.Info("Unlocked fake validator account", "address", coinbase.Address.Hex())
 	}
 
-	// unlock validator key
-	if !valPubkey.Empty() {
-		err := unlockValidatorKey(ctx, valPubkey, valKeystore)
+	var signer valkeystore.SignerI
+	if remoteSigner {
+		signer, err = makeRemoteSigner(ctx, validatorPubKeys(cfg.Opera.Emitters())...)
 		if err != nil {
-			utils.Fatalf("Failed to unlock validator key: %v", err)
+			utils.Fatalf("Failed to connect to remote signer: %v", err)
 		}
+	} else {
+		signer = unlockValidatorKeys(ctx, cfg.Opera.Emitters(), valKeystores)
 	}
-	signer := valkeystore.NewSigner(valKeystore)
 
 	// Create and register a gossip network service.
 
@@ -307,8 +346,10 @@ func makeNode(ctx *cli.Context, cfg *config, genesis integration.InputGenesis) (
 	}
 
 	stack.RegisterAPIs(svc.APIs())
+	stack.RegisterAPIs(backupAPIs(svc, chaindataDir))
 	stack.RegisterProtocols(svc.Protocols())
 	stack.RegisterLifecycle(svc)
+	svc.SetRecoveredEvents(recovery.Events)
 
 	return stack, svc, func() {
 		_ = stack.Close()
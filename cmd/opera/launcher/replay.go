@@ -0,0 +1,171 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-opera/gossip"
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc/evmmodule"
+	"github.com/Fantom-foundation/go-opera/integration"
+)
+
+var (
+	ReplayFromBlockFlag = cli.Uint64Flag{
+		Name:  "from-block",
+		Usage: "First block to re-execute (the first block after genesis by default)",
+	}
+	ReplayToBlockFlag = cli.Uint64Flag{
+		Name:  "to-block",
+		Usage: "Last block to re-execute (the latest block by default)",
+	}
+	ReplayHotContractsFlag = cli.IntFlag{
+		Name:  "hot-contracts",
+		Usage: "Number of contracts with the highest gas usage to report",
+		Value: 10,
+	}
+
+	replayCommand = cli.Command{
+		Name:     "replay",
+		Usage:    "Re-execute stored blocks offline",
+		Action:   utils.MigrateFlags(replayBlocks),
+		Category: "MISCELLANEOUS COMMANDS",
+		Flags: []cli.Flag{
+			DataDirFlag,
+			ReplayFromBlockFlag,
+			ReplayToBlockFlag,
+			ReplayHotContractsFlag,
+		},
+		Description: `
+    opera replay --from-block 1000 --to-block 2000
+
+Re-executes the stored blocks through the EVM on top of the stored state of
+previous blocks, without networking and without modifying the database.
+Reports gas throughput of every block, mismatches of state roots, gas usage and
+skipped transactions, and the contracts with the highest gas usage.
+It may be used as a determinism check after code changes.
+The current network rules are used for all the blocks, and internal transactions
+are taken from the stored blocks. Requires the historical EVM state of the range.
+`,
+	}
+)
+
+type contractGas struct {
+	addr common.Address
+	gas  uint64
+	txs  int
+}
+
+func replayBlocks(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		utils.Fatalf("This command doesn't require an argument.")
+	}
+
+	cfg := makeAllConfigs(ctx)
+
+	rawProducer := integration.DBProducer(path.Join(cfg.Node.DataDir, "chaindata"), cacheScaler(ctx))
+	gdb, err := makeRawGossipStore(rawProducer, cfg)
+	if err != nil {
+		log.Crit("DB opening error", "datadir", cfg.Node.DataDir, "err", err)
+	}
+	defer gdb.Close()
+
+	from := idx.Block(ctx.Uint64(ReplayFromBlockFlag.Name))
+	to := gdb.GetLatestBlockIndex()
+	if ctx.IsSet(ReplayToBlockFlag.Name) {
+		to = idx.Block(ctx.Uint64(ReplayToBlockFlag.Name))
+	}
+	if to < from {
+		utils.Fatalf("Last block %d is lower than first block %d", to, from)
+	}
+
+	var (
+		blocks     int
+		txs        int
+		gasUsed    uint64
+		elapsed    time.Duration
+		mismatches int
+		contracts  = make(map[common.Address]*contractGas)
+	)
+	start := time.Now()
+	err = gdb.ReplayBlocks(evmmodule.New(), from, to, func(r *gossip.BlockReplay) error {
+		blocks++
+		txs += len(r.Txs)
+		gasUsed += r.GasUsed
+		elapsed += r.Elapsed
+
+		for i, receipt := range r.Receipts {
+			addr := receipt.ContractAddress
+			if to := r.Txs[i].To(); to != nil {
+				addr = *to
+			}
+			c := contracts[addr]
+			if c == nil {
+				c = &contractGas{addr: addr}
+				contracts[addr] = c
+			}
+			c.gas += receipt.GasUsed
+			c.txs++
+		}
+
+		log.Info("Replayed block", "index", r.Index, "txs", len(r.Txs), "gas_used", r.GasUsed,
+			"mgasps", mgasPerSec(r.GasUsed, r.Elapsed), "t", common.PrettyDuration(r.Elapsed))
+		if r.Mismatch() {
+			mismatches++
+			log.Error("Replayed block mismatch", "index", r.Index,
+				"root", r.Root, "expected_root", r.Block.Root,
+				"gas_used", r.GasUsed, "expected_gas_used", r.Block.GasUsed,
+				"skipped_txs", r.SkippedTxs, "expected_skipped_txs", r.Block.SkippedTxs)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	hot := make([]*contractGas, 0, len(contracts))
+	for _, c := range contracts {
+		hot = append(hot, c)
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].gas != hot[j].gas {
+			return hot[i].gas > hot[j].gas
+		}
+		return bytes.Compare(hot[i].addr[:], hot[j].addr[:]) < 0
+	})
+	if limit := ctx.Int(ReplayHotContractsFlag.Name); len(hot) > limit {
+		hot = hot[:limit]
+	}
+	if len(hot) != 0 {
+		fmt.Printf("Hot contracts:\n")
+		for _, c := range hot {
+			fmt.Printf("  %s gas=%d txs=%d\n", c.addr.String(), c.gas, c.txs)
+		}
+	}
+	fmt.Printf("Replayed blocks: %d, txs: %d, gas used: %d, execution time: %v, mgas/s: %.3f, total time: %v\n",
+		blocks, txs, gasUsed, common.PrettyDuration(elapsed), mgasPerSec(gasUsed, elapsed), common.PrettyDuration(time.Since(start)))
+
+	if mismatches != 0 {
+		return fmt.Errorf("%d of %d replayed blocks mismatch", mismatches, blocks)
+	}
+	if blocks == 0 {
+		return errors.New("no blocks to replay")
+	}
+	return nil
+}
+
+func mgasPerSec(gas uint64, elapsed time.Duration) float64 {
+	if elapsed == 0 {
+		return 0
+	}
+	return float64(gas) / 1e6 / elapsed.Seconds()
+}
@@ -1,6 +1,12 @@
 package launcher
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	cli "gopkg.in/urfave/cli.v1"
 
@@ -9,6 +15,7 @@ import (
 	"github.com/Fantom-foundation/go-opera/gossip/emitter"
 	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
 	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
 )
 
 var validatorIDFlag = cli.UintFlag{
@@ -23,12 +30,84 @@ var validatorPubkeyFlag = cli.StringFlag{
 	Value: "",
 }
 
+var validatorNextPubkeyFlag = cli.StringFlag{
+	Name:  "validator.nextpubkey",
+	Usage: "Public key which replaces the validator key at the epoch when it's updated on-chain, both keys are unlocked (the password file may contain the password of the next key on the second line)",
+	Value: "",
+}
+
+var validatorExtraFlag = cli.StringFlag{
+	Name:  "validator.extra",
+	Usage: "Comma-separated list of other validators hosted by the node in the ID:pubkey[:keystore] format, e.g. 2:0xc004...,3:0xc004...:pkcs11=token3 (the validators share the emitter settings, the keystore is file, pkcs11 or pkcs11=<token label> and defaults to --validator.keystore, the passwords of their keys follow in the password file). Cannot be combined with [[Opera.ExtraEmitters]] of the config file, which configure each validator fully",
+	Value: "",
+}
+
 var validatorPasswordFlag = cli.StringFlag{
 	Name:  "validator.password",
 	Usage: "Password to unlock validator private key",
 	Value: "",
 }
 
+var validatorKeystoreFlag = cli.StringFlag{
+	Name:  "validator.keystore",
+	Usage: "Backend of the validator keys: file or pkcs11 (keys of a PKCS#11 token cannot be exported, the token PIN is used as the password)",
+	Value: valkeystore.FileBackend,
+}
+
+var validatorPKCS11LibFlag = cli.StringFlag{
+	Name:  "validator.pkcs11.lib",
+	Usage: "Path of the PKCS#11 module of the validator keystore, e.g. /usr/lib/softhsm/libsofthsm2.so",
+	Value: "",
+}
+
+var validatorPKCS11TokenFlag = cli.StringFlag{
+	Name:  "validator.pkcs11.token",
+	Usage: "Label of the PKCS#11 token which holds the validator keys",
+	Value: "",
+}
+
+var validatorSignerFlag = cli.StringFlag{
+	Name:  "validator.signer",
+	Usage: "Endpoint of a remote signer holding the validator key (unix:///path/to/socket or https://host:port), the local validator keystore isn't used",
+	Value: "",
+}
+
+var validatorSignerCertFlag = cli.StringFlag{
+	Name:  "validator.signer.tls.cert",
+	Usage: "Client TLS certificate to authenticate at a https:// remote signer",
+	Value: "",
+}
+
+var validatorSignerKeyFlag = cli.StringFlag{
+	Name:  "validator.signer.tls.key",
+	Usage: "Client TLS private key to authenticate at a https:// remote signer",
+	Value: "",
+}
+
+var validatorSignerCAFlag = cli.StringFlag{
+	Name:  "validator.signer.tls.ca",
+	Usage: "CA certificate to authenticate a https:// remote signer",
+	Value: "",
+}
+
+var validatorSlashingProtectionFlag = cli.StringFlag{
+	Name:  "validator.slashingprotection",
+	Usage: "Path of the validator signing history, which refuses to sign conflicting events, must be outside the datadir to survive its restoration (extra validators use the path with -<ID> suffix)",
+	Value: "",
+}
+
+var validatorFailoverLeaseFlag = cli.StringFlag{
+	Name:  "validator.failover.lease",
+	Usage: "Path of the lease file on a storage shared by active and standby nodes of the validator, only the lease holder emits events (keep --validator.slashingprotection on the shared storage too)",
+	Value: "",
+}
+
+var validatorFailoverTTLFlag = cli.DurationFlag{
+	Name:  "validator.failover.ttl",
+	Usage: "Duration of the validator lease, a standby node takes over after the lease of the active node expires",
+	Value: 30 * time.Second,
+}
+
 // setValidatorID retrieves the validator ID either from the directly specified
 // command line flags or from the keystore if CLI indexed.
 func setValidator(ctx *cli.Context, cfg *emitter.Config) error {
@@ -66,5 +145,107 @@ func setValidator(ctx *cli.Context, cfg *emitter.Config) error {
 
 	cfg.Validator.ID = validatorID
 	cfg.Validator.PubKey = validatorPubkey
+
+	if ctx.GlobalIsSet(validatorNextPubkeyFlag.Name) {
+		nextPubkey, err := validatorpk.FromString(ctx.GlobalString(validatorNextPubkeyFlag.Name))
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(nextPubkey.Bytes(), validatorPubkey.Bytes()) {
+			return errors.New("next validator public key is the same as the current one")
+		}
+		cfg.Validator.NextPubKey = nextPubkey
+	}
 	return nil
 }
+
+// makeExtraValidators configures the emitters of the other validators hosted by the node.
+// The emitters inherit the settings of the main emitter, but not its validator files,
+// the keystore may be selected per validator.
+func makeExtraValidators(ctx *cli.Context, main emitter.Config) ([]emitter.Config, error) {
+	if main.Validator.ID == 0 {
+		return nil, errors.New("extra validators require the main validator")
+	}
+	var extras []emitter.Config
+	for _, item := range strings.Split(ctx.GlobalString(validatorExtraFlag.Name), ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("extra validator %s isn't in the ID:pubkey[:keystore] format", item)
+		}
+		validatorID, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil || validatorID == 0 {
+			return nil, fmt.Errorf("invalid ID of extra validator %s", item)
+		}
+		validatorPubkey, err := validatorpk.FromString(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid public key of extra validator %s", item)
+		}
+
+		cfg := main
+		cfg.Validator.ID = idx.ValidatorID(validatorID)
+		cfg.Validator.PubKey = validatorPubkey
+		if len(parts) == 3 {
+			cfg.Validator.Keystore, err = parseExtraKeystore(parts[2], main.Validator.Keystore)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid keystore of extra validator %s", item)
+			}
+		}
+		cfg.Validator.NextPubKey = validatorpk.PubKey{}
+		cfg.PrevEmittedEventFile.Path = ""
+		cfg.SlashingProtection.Path = ""
+		if len(main.SlashingProtection.Path) != 0 {
+			cfg.SlashingProtection.Path = fmt.Sprintf("%s-%d", main.SlashingProtection.Path, validatorID)
+		}
+		cfg.OverridesPath = ""
+		if len(main.Failover.LeasePath) != 0 {
+			cfg.Failover.LeasePath = fmt.Sprintf("%s-%d", main.Failover.LeasePath, validatorID)
+		}
+		extras = append(extras, cfg)
+	}
+	return extras, nil
+}
+
+// parseExtraKeystore parses the keystore of an extra validator: file, pkcs11 or pkcs11=<token label>.
+// The PKCS#11 module is shared with the main validator.
+func parseExtraKeystore(spec string, main valkeystore.Config) (valkeystore.Config, error) {
+	cfg := main
+	parts := strings.SplitN(spec, "=", 2)
+	cfg.Backend = parts[0]
+	switch {
+	case cfg.Backend == valkeystore.FileBackend && len(parts) == 1:
+	case cfg.Backend == valkeystore.PKCS11Backend:
+		if len(parts) == 2 {
+			cfg.PKCS11Token = parts[1]
+		}
+	default:
+		return cfg, fmt.Errorf("keystore %s isn't file, pkcs11 or pkcs11=<token label>", spec)
+	}
+	return cfg, cfg.Validate()
+}
+
+// setValidatorKeystore selects the backend of the validator keys.
+func setValidatorKeystore(ctx *cli.Context, cfg *valkeystore.Config) {
+	if ctx.GlobalIsSet(validatorKeystoreFlag.Name) {
+		cfg.Backend = ctx.GlobalString(validatorKeystoreFlag.Name)
+	}
+	if ctx.GlobalIsSet(validatorPKCS11LibFlag.Name) {
+		cfg.PKCS11Lib = ctx.GlobalString(validatorPKCS11LibFlag.Name)
+	}
+	if ctx.GlobalIsSet(validatorPKCS11TokenFlag.Name) {
+		cfg.PKCS11Token = ctx.GlobalString(validatorPKCS11TokenFlag.Name)
+	}
+}
+
+// setValidatorFailover enables the failover mode if the lease file is specified.
+func setValidatorFailover(ctx *cli.Context, cfg *emitter.FailoverConfig) {
+	if ctx.GlobalIsSet(validatorFailoverLeaseFlag.Name) {
+		cfg.LeasePath = ctx.GlobalString(validatorFailoverLeaseFlag.Name)
+	}
+	if ctx.GlobalIsSet(validatorFailoverTTLFlag.Name) {
+		cfg.LeaseTTL = ctx.GlobalDuration(validatorFailoverTTLFlag.Name)
+	}
+}
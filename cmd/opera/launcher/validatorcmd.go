@@ -1,20 +1,31 @@
 package launcher
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"fmt"
+	"os"
 	"path"
+	"strconv"
 	"strings"
 
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 	"gopkg.in/urfave/cli.v1"
 
+	"github.com/Fantom-foundation/go-opera/gossip/emitter"
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/slashing"
 	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driver/drivercall"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driverauth"
 	"github.com/Fantom-foundation/go-opera/valkeystore"
 	"github.com/Fantom-foundation/go-opera/valkeystore/encryption"
+	"github.com/Fantom-foundation/go-opera/valkeystore/pkcs11"
 )
 
 var (
@@ -50,6 +61,9 @@ Make sure you backup your keys regularly.`,
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
+					validatorKeystoreFlag,
+					validatorPKCS11LibFlag,
+					validatorPKCS11TokenFlag,
 				},
 				Description: `
     opera validator new
@@ -58,12 +72,46 @@ Creates a new validator private key and prints the public key.
 
 The key is saved in encrypted format, you are prompted for a passphrase.
 
+With --validator.keystore=pkcs11, the key is generated by the PKCS#11 token, and cannot
+be exported from the token. You are prompted for the token PIN instead of the passphrase.
+
 You must remember this passphrase to unlock your key in the future.
 
 For non-interactive use the passphrase can be specified with the --validator.password flag:
 
 Note, this is meant to be used for testing only, it is a bad idea to save your
 password to file or expose in any other way.
+`,
+			},
+			{
+				Name:      "rotate",
+				Usage:     "Create a new validator key to replace the current one",
+				Action:    utils.MigrateFlags(validatorKeyRotate),
+				ArgsUsage: "<validator ID>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					validatorKeystoreFlag,
+					validatorPKCS11LibFlag,
+					validatorPKCS11TokenFlag,
+				},
+				Description: `
+    opera validator rotate <validator ID>
+
+Creates a new validator private key to replace the current one, and prints the call
+which updates the validator public key on-chain.
+
+The key is updated by updateValidatorPubkey(uint256,bytes) of NodeDriverAuth, which
+accepts the call only from SFC. The SFC deployed by the genesis doesn't provide a method
+for that, so the call must be made by an upgraded SFC.
+The new key becomes effective at the next epoch after the update. Before the update,
+restart the node with both keys, so the node switches to the new key exactly at the
+epoch when it takes effect:
+
+    opera --validator.id=ID --validator.pubkey=<current key> --validator.nextpubkey=<new key>
+
+Once the key is rotated, --validator.pubkey may be replaced with the new key.
 `,
 			},
 			{
@@ -81,15 +129,81 @@ password to file or expose in any other way.
 Converts an account private key to a validator private key and saves in the validator keystore.
 `,
 			},
+			{
+				Name:  "protection",
+				Usage: "Export and import the slashing protection history",
+				Subcommands: []cli.Command{
+					{
+						Name:      "export",
+						Usage:     "Export the slashing protection history into a portable file",
+						Action:    utils.MigrateFlags(exportSlashingProtection),
+						ArgsUsage: "<filename>",
+						Flags: []cli.Flag{
+							DataDirFlag,
+							validatorIDFlag,
+							validatorPubkeyFlag,
+							validatorSlashingProtectionFlag,
+						},
+						Description: `
+    opera validator protection export --validator.id=ID --validator.pubkey=0x... history.json
+
+Exports the history of events signed by the validator, so it can be imported on another
+machine together with the validator key.
+`,
+					},
+					{
+						Name:      "import",
+						Usage:     "Import the slashing protection history from a portable file",
+						Action:    utils.MigrateFlags(importSlashingProtection),
+						ArgsUsage: "<filename>",
+						Flags: []cli.Flag{
+							DataDirFlag,
+							validatorIDFlag,
+							validatorPubkeyFlag,
+							validatorSlashingProtectionFlag,
+						},
+						Description: `
+    opera validator protection import --validator.id=ID --validator.pubkey=0x... history.json
+
+Merges the exported history of events signed by the validator into the local history.
+The node will refuse to sign events which conflict with the imported history.
+The node must be stopped during the import.
+`,
+					},
+				},
+			},
 		},
 	}
 )
 
-// validatorKeyCreate creates a new validator key into the keystore defined by the CLI flags.
-func validatorKeyCreate(ctx *cli.Context) error {
+// createValidatorKey generates a new validator key into the keystore defined by the CLI flags,
+// and prints the public key.
+func createValidatorKey(ctx *cli.Context) validatorpk.PubKey {
 	cfg := makeAllConfigs(ctx)
 	utils.SetNodeConfig(ctx, &cfg.Node)
 
+	if keystoreCfg := cfg.Opera.Emitter.Validator.Keystore; keystoreCfg.IsPKCS11() {
+		// the key is generated by the token, and cannot be exported
+		ks, err := pkcs11.Open(keystoreCfg.PKCS11Lib, keystoreCfg.PKCS11Token)
+		if err != nil {
+			utils.Fatalf("Failed to open PKCS#11 token: %v", err)
+		}
+		defer ks.Close()
+		pin := getPassPhrase("Please give the PIN of the PKCS#11 token.", false, 0, utils.MakePasswordList(ctx))
+		publicKey, err := ks.Generate(pin)
+		if err != nil {
+			utils.Fatalf("Failed to create key: %v", err)
+		}
+
+		fmt.Printf("\nYour new key was generated\n\n")
+		fmt.Printf("Public key:                  %s\n", publicKey.String())
+		fmt.Printf("PKCS#11 token of the key:    %s\n\n", ks.Token())
+		fmt.Printf("- You can share your public key with anyone. Others need it to validate messages from you.\n")
+		fmt.Printf("- The secret key cannot be exported from the token, the token signs the events.\n")
+		fmt.Printf("- You must REMEMBER the token PIN! Without the PIN, it's impossible to use the key!\n\n")
+		return publicKey
+	}
+
 	password := getPassPhrase("Your new validator key is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
 	privateKeyECDSA, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
@@ -121,6 +235,34 @@ func validatorKeyCreate(ctx *cli.Context) error {
 	fmt.Printf("- You must NEVER share the secret key with anyone! The key controls access to your validator!\n")
 	fmt.Printf("- You must BACKUP your key file! Without the key, it's impossible to operate the validator!\n")
 	fmt.Printf("- You must REMEMBER your password! Without the password, it's impossible to decrypt the key!\n\n")
+	return publicKey
+}
+
+// validatorKeyCreate creates a new validator key into the keystore defined by the CLI flags.
+func validatorKeyCreate(ctx *cli.Context) error {
+	createValidatorKey(ctx)
+	return nil
+}
+
+// validatorKeyRotate creates a new validator key, and prints the call which updates the key on-chain and the steps to switch the node to it.
+func validatorKeyRotate(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	id, err := strconv.ParseUint(ctx.Args().First(), 10, 32)
+	if err != nil || id == 0 {
+		utils.Fatalf("Failed to parse the validator ID: %s", ctx.Args().First())
+	}
+	validatorID := idx.ValidatorID(id)
+	publicKey := createValidatorKey(ctx)
+
+	fmt.Printf("Call of SFC to update the key on-chain:\n")
+	fmt.Printf("To:   %s\n", driverauth.ContractAddress.String())
+	fmt.Printf("Data: %s\n\n", hexutil.Encode(drivercall.UpdateValidatorPubkey(validatorID, publicKey)))
+	fmt.Printf("- NodeDriverAuth accepts the call only from SFC, the genesis SFC doesn't provide a method to make it.\n")
+	fmt.Printf("- Restart the node with --%s=%s before the key is updated on-chain, both keys are unlocked.\n", validatorNextPubkeyFlag.Name, publicKey.String())
+	fmt.Printf("- The node switches to the new key at the epoch when it becomes effective.\n")
+	fmt.Printf("- After that, replace --%s with the new key, and remove --%s.\n\n", validatorPubkeyFlag.Name, validatorNextPubkeyFlag.Name)
 	return nil
 }
 
@@ -158,3 +300,74 @@ func validatorKeyConvert(ctx *cli.Context) error {
 	fmt.Println("\nYour key was converted and saved to " + valkeypath)
 	return nil
 }
+
+func slashingProtectionConfig(ctx *cli.Context) (string, emitter.ValidatorConfig) {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	cfg := makeAllConfigs(ctx)
+	validator := cfg.Opera.Emitter.Validator
+	if validator.ID == 0 || validator.PubKey.Empty() {
+		utils.Fatalf("Validator ID and public key must be specified with --%s and --%s", validatorIDFlag.Name, validatorPubkeyFlag.Name)
+	}
+	return cfg.Opera.Emitter.SlashingProtection.Path, validator
+}
+
+func openSlashingProtection(path string) *slashing.Store {
+	s, err := slashing.Open(path)
+	if err != nil {
+		utils.Fatalf("Failed to open slashing protection history: %v", err)
+	}
+	return s
+}
+
+// exportSlashingProtection writes the signing history of the validator into a portable file.
+func exportSlashingProtection(ctx *cli.Context) error {
+	path, validator := slashingProtectionConfig(ctx)
+	s := openSlashingProtection(path)
+	defer s.Close()
+
+	records, err := s.Records()
+	if err != nil {
+		utils.Fatalf("Failed to read slashing protection history: %v", err)
+	}
+	f, err := os.OpenFile(ctx.Args().First(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		utils.Fatalf("Failed to create file: %v", err)
+	}
+	defer f.Close()
+	err = slashing.NewInterchange(validator.ID, validator.PubKey, records).Write(f)
+	if err != nil {
+		utils.Fatalf("Failed to write file: %v", err)
+	}
+	log.Info("Exported slashing protection history", "events", len(records), "file", ctx.Args().First())
+	return nil
+}
+
+// importSlashingProtection merges a portable signing history into the history of the validator.
+func importSlashingProtection(ctx *cli.Context) error {
+	path, validator := slashingProtectionConfig(ctx)
+
+	f, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to open file: %v", err)
+	}
+	defer f.Close()
+	h, err := slashing.ReadInterchange(f)
+	if err != nil {
+		utils.Fatalf("Failed to read slashing protection history: %v", err)
+	}
+	if h.ValidatorID != validator.ID || !bytes.Equal(h.PubKey.Bytes(), validator.PubKey.Bytes()) {
+		utils.Fatalf("History of validator %d %s cannot be imported for validator %d %s",
+			h.ValidatorID, h.PubKey.String(), validator.ID, validator.PubKey.String())
+	}
+
+	s := openSlashingProtection(path)
+	defer s.Close()
+	imported, err := s.Import(h.Records())
+	if err != nil {
+		utils.Fatalf("Failed to write slashing protection history: %v", err)
+	}
+	log.Info("Imported slashing protection history", "events", len(h.Events), "new", imported, "path", s.Path())
+	return nil
+}
@@ -2,9 +2,12 @@ package launcher
 
 import (
 	"crypto/ecdsa"
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -12,10 +15,16 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 	cli "gopkg.in/urfave/cli.v1"
 
+	"github.com/Fantom-foundation/go-opera/gossip/emitter"
 	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
 	"github.com/Fantom-foundation/go-opera/valkeystore"
+	"github.com/Fantom-foundation/go-opera/valkeystore/pkcs11"
+	"github.com/Fantom-foundation/go-opera/valkeystore/remote"
 )
 
+// remoteSignerTimeout limits a signing request, a late signature delays the emitted event
+const remoteSignerTimeout = 5 * time.Second
+
 func addFakeValidatorKey(ctx *cli.Context, key *ecdsa.PrivateKey, pubkey validatorpk.PubKey, valKeystore valkeystore.RawKeystoreI) {
 	// add fake validator key
 	if key != nil && !valKeystore.Has(pubkey) {
@@ -34,6 +43,88 @@ func getValKeystoreDir(cfg node.Config) string {
 	return keydir
 }
 
+// makeValidatorKeystore opens the backend of the validator keys, and returns a signer by the keys.
+func makeValidatorKeystore(cfg node.Config, keystoreCfg valkeystore.Config) (valkeystore.KeystoreI, valkeystore.SignerI) {
+	if keystoreCfg.IsPKCS11() {
+		// keys of a PKCS#11 token cannot be exported, so the token signs
+		ks, err := pkcs11.Open(keystoreCfg.PKCS11Lib, keystoreCfg.PKCS11Token)
+		if err != nil {
+			utils.Fatalf("Failed to open PKCS#11 token: %v", err)
+		}
+		return ks, ks
+	}
+	ks := valkeystore.NewDefaultFileKeystore(path.Join(getValKeystoreDir(cfg), "validator"))
+	return ks, valkeystore.NewSigner(ks)
+}
+
+// validatorKeystores opens every backend of the validator keys once, the hosted validators may share a backend.
+type validatorKeystores struct {
+	node      node.Config
+	keystores map[valkeystore.Config]valkeystore.KeystoreI
+	signers   map[valkeystore.Config]valkeystore.SignerI
+}
+
+func newValidatorKeystores(cfg node.Config) *validatorKeystores {
+	return &validatorKeystores{
+		node:      cfg,
+		keystores: make(map[valkeystore.Config]valkeystore.KeystoreI),
+		signers:   make(map[valkeystore.Config]valkeystore.SignerI),
+	}
+}
+
+func (v *validatorKeystores) get(keystoreCfg valkeystore.Config) (valkeystore.KeystoreI, valkeystore.SignerI) {
+	if !keystoreCfg.IsPKCS11() {
+		// all the file keys are in the same directory
+		keystoreCfg = valkeystore.Config{}
+	}
+	if ks, ok := v.keystores[keystoreCfg]; ok {
+		return ks, v.signers[keystoreCfg]
+	}
+	ks, signer := makeValidatorKeystore(v.node, keystoreCfg)
+	v.keystores[keystoreCfg] = ks
+	v.signers[keystoreCfg] = signer
+	return ks, signer
+}
+
+// validatorPubKeys returns the keys of the hosted validators, during a key rotation both keys are required.
+func validatorPubKeys(emitters []emitter.Config) []validatorpk.PubKey {
+	pubKeys := make([]validatorpk.PubKey, 0, 2*len(emitters))
+	for _, emitterCfg := range emitters {
+		for _, pubKey := range []validatorpk.PubKey{emitterCfg.Validator.PubKey, emitterCfg.Validator.NextPubKey} {
+			if !pubKey.Empty() {
+				pubKeys = append(pubKeys, pubKey)
+			}
+		}
+	}
+	return pubKeys
+}
+
+// unlockValidatorKeys unlocks the keys of the hosted validators, and returns a signer by the keys.
+// The password file contains the passwords in the order of validatorPubKeys.
+func unlockValidatorKeys(ctx *cli.Context, emitters []emitter.Config, valKeystores *validatorKeystores) valkeystore.SignerI {
+	multiSigner := valkeystore.NewMultiSigner()
+	var mainSigner valkeystore.SignerI
+	passwordIdx := 0
+	for i, emitterCfg := range emitters {
+		valKeystore, valSigner := valKeystores.get(emitterCfg.Validator.Keystore)
+		if i == 0 {
+			mainSigner = valSigner
+		}
+		for _, pubKey := range validatorPubKeys([]emitter.Config{emitterCfg}) {
+			err := unlockValidatorKey(ctx, pubKey, passwordIdx, valKeystore)
+			if err != nil {
+				utils.Fatalf("Failed to unlock validator key: %v", err)
+			}
+			passwordIdx++
+			multiSigner.Add(pubKey, valSigner)
+		}
+	}
+	if len(emitters) == 1 {
+		return mainSigner
+	}
+	return multiSigner
+}
+
 // makeValidatorPasswordList reads password lines from the file specified by the global --validator.password flag.
 func makeValidatorPasswordList(ctx *cli.Context) []string {
 	if path := ctx.GlobalString(validatorPasswordFlag.Name); path != "" {
@@ -54,11 +145,11 @@ func makeValidatorPasswordList(ctx *cli.Context) []string {
 	return nil
 }
 
-func unlockValidatorKey(ctx *cli.Context, pubKey validatorpk.PubKey, valKeystore valkeystore.KeystoreI) error {
+func unlockValidatorKey(ctx *cli.Context, pubKey validatorpk.PubKey, passwordIdx int, valKeystore valkeystore.KeystoreI) error {
 	var err error
 	for trials := 0; trials < 3; trials++ {
 		prompt := fmt.Sprintf("Unlocking validator key %s | Attempt %d/%d", pubKey.String(), trials+1, 3)
-		password := getPassPhrase(prompt, false, 0, makeValidatorPasswordList(ctx))
+		password := getPassPhrase(prompt, false, passwordIdx, makeValidatorPasswordList(ctx))
 		err = valKeystore.Unlock(pubKey, password)
 		if err == nil {
 			log.Info("Unlocked validator key", "pubkey", pubKey.String())
@@ -68,3 +159,41 @@ func unlockValidatorKey(ctx *cli.Context, pubKey validatorpk.PubKey, valKeystore
 	// All trials expended to unlock account, bail out
 	return err
 }
+
+// makeRemoteSigner connects to the signer specified by the global --validator.signer flag,
+// and checks that the signer holds the validator keys.
+func makeRemoteSigner(ctx *cli.Context, pubKeys ...validatorpk.PubKey) (*remote.Signer, error) {
+	endpoint, err := remote.ParseEndpoint(ctx.GlobalString(validatorSignerFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	var tlsCfg *tls.Config
+	if endpoint.Socket == "" {
+		tlsCfg, err = remote.LoadTLSConfig(
+			ctx.GlobalString(validatorSignerCertFlag.Name),
+			ctx.GlobalString(validatorSignerKeyFlag.Name),
+			ctx.GlobalString(validatorSignerCAFlag.Name),
+			false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	signer, err := remote.NewSigner(endpoint, tlsCfg, remoteSignerTimeout)
+	if err != nil {
+		return nil, err
+	}
+	for _, pubKey := range pubKeys {
+		if pubKey.Empty() {
+			continue
+		}
+		ok, err := signer.Has(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("signer doesn't hold validator key %s", pubKey.String())
+		}
+		log.Info("Using remote signer", "endpoint", ctx.GlobalString(validatorSignerFlag.Name), "pubkey", pubKey.String())
+	}
+	return signer, nil
+}
@@ -0,0 +1,177 @@
+// valsigner is a reference signing daemon for validator keys.
+// It keeps the validator keys encrypted by valkeystore/encryption on a host without a node,
+// and signs events of the nodes started with --validator.signer.
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/mattn/go-isatty"
+	cli "gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+	"github.com/Fantom-foundation/go-opera/valkeystore/remote"
+)
+
+var (
+	keystoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Directory of the validator keystore (e.g. ~/.opera/keystore/validator)",
+	}
+	pubkeyFlag = cli.StringSliceFlag{
+		Name:  "pubkey",
+		Usage: "Public key of a validator to sign by (may be repeated)",
+	}
+	passwordFlag = cli.StringFlag{
+		Name:  "password",
+		Usage: "Password file to unlock the validator keys, one line per key",
+	}
+	listenFlag = cli.StringFlag{
+		Name:  "listen",
+		Usage: "Endpoint to serve: unix:///path/to/socket or https://host:port",
+	}
+	tlsCertFlag = cli.StringFlag{
+		Name:  "tls.cert",
+		Usage: "Server TLS certificate for a https:// endpoint",
+	}
+	tlsKeyFlag = cli.StringFlag{
+		Name:  "tls.key",
+		Usage: "Server TLS private key for a https:// endpoint",
+	}
+	tlsCAFlag = cli.StringFlag{
+		Name:  "tls.ca",
+		Usage: "CA certificate to authenticate the nodes connecting to a https:// endpoint",
+	}
+	verbosityFlag = cli.IntFlag{
+		Name:  "verbosity",
+		Usage: "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail",
+		Value: 3,
+	}
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "valsigner"
+	app.Usage = "signing daemon for validator keys"
+	app.Flags = []cli.Flag{
+		keystoreFlag,
+		pubkeyFlag,
+		passwordFlag,
+		listenFlag,
+		tlsCertFlag,
+		tlsKeyFlag,
+		tlsCAFlag,
+		verbosityFlag,
+	}
+	app.Action = serve
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(ctx *cli.Context) error {
+	usecolor := isatty.IsTerminal(os.Stderr.Fd()) && os.Getenv("TERM") != "dumb"
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(ctx.Int(verbosityFlag.Name)), log.StreamHandler(os.Stderr, log.TerminalFormat(usecolor))))
+
+	if !ctx.IsSet(keystoreFlag.Name) || !ctx.IsSet(listenFlag.Name) || len(ctx.StringSlice(pubkeyFlag.Name)) == 0 {
+		return fmt.Errorf("--%s, --%s and --%s must be specified", keystoreFlag.Name, pubkeyFlag.Name, listenFlag.Name)
+	}
+	endpoint, err := remote.ParseEndpoint(ctx.String(listenFlag.Name))
+	if err != nil {
+		return err
+	}
+	var tlsCfg *tls.Config
+	if endpoint.Socket == "" {
+		tlsCfg, err = remote.LoadTLSConfig(ctx.String(tlsCertFlag.Name), ctx.String(tlsKeyFlag.Name), ctx.String(tlsCAFlag.Name), true)
+		if err != nil {
+			return err
+		}
+	}
+
+	passwords, err := readPasswords(ctx.String(passwordFlag.Name))
+	if err != nil {
+		return err
+	}
+	keystore := valkeystore.NewDefaultFileKeystore(ctx.String(keystoreFlag.Name))
+	var pubkeys []validatorpk.PubKey
+	for i, s := range ctx.StringSlice(pubkeyFlag.Name) {
+		pubkey, err := validatorpk.FromString(s)
+		if err != nil {
+			return err
+		}
+		if !keystore.Has(pubkey) {
+			return fmt.Errorf("validator key %s isn't found in the keystore", pubkey.String())
+		}
+		password, err := getPassword(pubkey, i, passwords)
+		if err != nil {
+			return err
+		}
+		if err := keystore.Unlock(pubkey, password); err != nil {
+			return fmt.Errorf("failed to unlock validator key %s: %v", pubkey.String(), err)
+		}
+		log.Info("Unlocked validator key", "pubkey", pubkey.String())
+		pubkeys = append(pubkeys, pubkey)
+	}
+
+	l, err := remote.Listen(endpoint, tlsCfg)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:      remote.NewHandler(valkeystore.NewSigner(keystore), pubkeys),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func() {
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+		<-sigc
+		log.Info("Got interrupt, shutting down...")
+		_ = srv.Close()
+	}()
+	log.Info("Signer started", "endpoint", ctx.String(listenFlag.Name), "keys", len(pubkeys))
+	err = srv.Serve(l)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func readPasswords(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password file: %v", err)
+	}
+	lines := strings.Split(string(text), "\n")
+	// Sanitise DOS line endings.
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return lines, nil
+}
+
+func getPassword(pubkey validatorpk.PubKey, i int, passwords []string) (string, error) {
+	if len(passwords) > 0 {
+		if i < len(passwords) {
+			return passwords[i], nil
+		}
+		return passwords[len(passwords)-1], nil
+	}
+	fmt.Printf("Unlocking validator key %s\n", pubkey.String())
+	return prompt.Stdin.PromptPassword("Passphrase: ")
+}
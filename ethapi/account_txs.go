@@ -0,0 +1,138 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Fantom-foundation/lachesis-base/common/bigendian"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/Fantom-foundation/go-opera/evmcore"
+	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
+)
+
+const (
+	// defaultAccountTxsLimit is a page size of GetTransactionsByAddress if limit isn't specified.
+	defaultAccountTxsLimit = 100
+	// maxAccountTxsLimit is a max page size of GetTransactionsByAddress.
+	maxAccountTxsLimit = 1000
+
+	accountTxsCursorSize = 8 + 4
+)
+
+// AccountTransactionsPage is a page of transactions sent or received by an account.
+type AccountTransactionsPage struct {
+	Transactions []*RPCTransaction `json:"transactions"`
+	// Cursor is a position to continue from, nil if the page is the last one.
+	Cursor hexutil.Bytes `json:"cursor"`
+}
+
+// GetTransactionsByAddress returns transactions sent or received by the address (including created contracts)
+// within the blocks range, in the chain order.
+// If not all the transactions fit into the limit, the returned cursor should be passed to get the next page.
+func (s *PublicTransactionPoolAPI) GetTransactionsByAddress(ctx context.Context, address common.Address, fromBlock, toBlock *rpc.BlockNumber, cursor *hexutil.Bytes, limit *hexutil.Uint) (*AccountTransactionsPage, error) {
+	r, err := s.parseAccountTxsRange(ctx, fromBlock, toBlock, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// request one more to find out if it's the last page
+	positions, err := s.b.GetTransactionsByAddress(ctx, address, r.from, r.fromOffset, r.to, r.limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AccountTransactionsPage{
+		Transactions: make([]*RPCTransaction, 0, len(positions)),
+	}
+	positions, page.Cursor = r.paginate(positions)
+
+	var block *evmcore.EvmBlock
+	for _, p := range positions {
+		if block == nil || block.NumberU64() != uint64(p.Block) {
+			block, err = s.b.BlockByNumber(ctx, rpc.BlockNumber(p.Block))
+			if err != nil {
+				return nil, err
+			}
+			if block == nil {
+				return nil, errors.New("block not found")
+			}
+		}
+		if int(p.BlockOffset) >= len(block.Transactions) || block.Transactions[p.BlockOffset].Hash() != p.TxHash {
+			return nil, errors.New("account transactions index is corrupted")
+		}
+		page.Transactions = append(page.Transactions, newRPCTransaction(block.Transactions[p.BlockOffset], block.Hash, uint64(p.Block), uint64(p.BlockOffset)))
+	}
+	return page, nil
+}
+
+// accountTxsRange is a range of a paginated account history request.
+type accountTxsRange struct {
+	from       idx.Block
+	fromOffset uint32
+	to         idx.Block
+	limit      int
+}
+
+func (s *PublicTransactionPoolAPI) parseAccountTxsRange(ctx context.Context, fromBlock, toBlock *rpc.BlockNumber, cursor *hexutil.Bytes, limit *hexutil.Uint) (r accountTxsRange, err error) {
+	r.from, err = s.resolveBlockNumber(ctx, fromBlock, rpc.EarliestBlockNumber)
+	if err != nil {
+		return
+	}
+	r.to, err = s.resolveBlockNumber(ctx, toBlock, rpc.LatestBlockNumber)
+	if err != nil {
+		return
+	}
+	if cursor != nil {
+		if len(*cursor) != accountTxsCursorSize {
+			return r, errors.New("invalid cursor")
+		}
+		cursorBlock := idx.BytesToBlock((*cursor)[:8])
+		if cursorBlock < r.from || cursorBlock > r.to {
+			return r, errors.New("cursor is out of blocks range")
+		}
+		r.from = cursorBlock
+		r.fromOffset = bigendian.BytesToUint32((*cursor)[8:])
+	}
+
+	r.limit = defaultAccountTxsLimit
+	if limit != nil {
+		r.limit = int(*limit)
+		if r.limit < 1 || r.limit > maxAccountTxsLimit {
+			return r, errors.New("limit is out of range")
+		}
+	}
+	return r, nil
+}
+
+// paginate cuts the positions to the page size and returns the cursor of the next page.
+func (r accountTxsRange) paginate(positions []evmstore.AccountTxPosition) ([]evmstore.AccountTxPosition, hexutil.Bytes) {
+	if len(positions) <= r.limit {
+		return positions, nil
+	}
+	next := positions[r.limit]
+	return positions[:r.limit], append(next.Block.Bytes(), bigendian.Uint32ToBytes(next.BlockOffset)...)
+}
+
+func (s *PublicTransactionPoolAPI) resolveBlockNumber(ctx context.Context, number *rpc.BlockNumber, def rpc.BlockNumber) (idx.Block, error) {
+	if number == nil {
+		number = &def
+	}
+	switch *number {
+	case rpc.EarliestBlockNumber:
+		return 0, nil
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		header, err := s.b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, errors.New("block not found")
+		}
+		return idx.Block(header.Number.Uint64()), nil
+	}
+	return idx.Block(*number), nil
+}
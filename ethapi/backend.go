@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/Fantom-foundation/go-opera/evmcore"
+	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
 	"github.com/Fantom-foundation/go-opera/gossip/sfcapi"
 	"github.com/Fantom-foundation/go-opera/inter"
 )
@@ -79,6 +80,9 @@ type Backend interface {
 	// Transaction pool API
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
 	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, uint64, uint64, error)
+	GetTransactionsByAddress(ctx context.Context, addr common.Address, from idx.Block, fromOffset uint32, to idx.Block, limit int) ([]evmstore.AccountTxPosition, error)
+	GetInternalTransfers(ctx context.Context, txHash common.Hash) ([]evmcore.InternalTransfer, error)
+	GetInternalTransfersByAddress(ctx context.Context, addr common.Address, from idx.Block, fromOffset uint32, to idx.Block, limit int) ([]evmstore.AccountTxPosition, error)
 	GetPoolTransactions() (types.Transactions, error)
 	GetPoolTransaction(txHash common.Hash) *types.Transaction
 	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
@@ -0,0 +1,121 @@
+package ethapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/Fantom-foundation/go-opera/evmcore"
+)
+
+// RPCInternalTransfer represents an internal value transfer that will serialize to the RPC representation.
+type RPCInternalTransfer struct {
+	Type  string         `json:"type"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// RPCTransactionTransfers is a list of internal value transfers made by a transaction.
+type RPCTransactionTransfers struct {
+	TransactionHash  common.Hash            `json:"transactionHash"`
+	BlockHash        common.Hash            `json:"blockHash"`
+	BlockNumber      hexutil.Uint64         `json:"blockNumber"`
+	TransactionIndex hexutil.Uint64         `json:"transactionIndex"`
+	Transfers        []*RPCInternalTransfer `json:"transfers"`
+}
+
+// AccountTransfersPage is a page of internal value transfers from or to an account.
+type AccountTransfersPage struct {
+	Transactions []*RPCTransactionTransfers `json:"transactions"`
+	// Cursor is a position to continue from, nil if the page is the last one.
+	Cursor hexutil.Bytes `json:"cursor"`
+}
+
+func newRPCInternalTransfer(t evmcore.InternalTransfer) *RPCInternalTransfer {
+	var kind string
+	switch t.Kind {
+	case evmcore.TransferCall:
+		kind = "call"
+	case evmcore.TransferCreate:
+		kind = "create"
+	case evmcore.TransferSelfDestruct:
+		kind = "selfdestruct"
+	default:
+		kind = "unknown"
+	}
+	return &RPCInternalTransfer{
+		Type:  kind,
+		From:  t.From,
+		To:    t.To,
+		Value: (*hexutil.Big)(t.Value),
+	}
+}
+
+// GetInternalTransfers returns value transfers made by contracts during the transaction execution
+// (calls with value, contract creations with value and self-destructs).
+func (s *PublicTransactionPoolAPI) GetInternalTransfers(ctx context.Context, hash common.Hash) ([]*RPCInternalTransfer, error) {
+	transfers, err := s.b.GetInternalTransfers(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*RPCInternalTransfer, len(transfers))
+	for i, t := range transfers {
+		res[i] = newRPCInternalTransfer(t)
+	}
+	return res, nil
+}
+
+// GetInternalTransfersByAddress returns internal value transfers from or to the address
+// within the blocks range, grouped by transactions in the chain order.
+// If not all the transactions fit into the limit, the returned cursor should be passed to get the next page.
+func (s *PublicTransactionPoolAPI) GetInternalTransfersByAddress(ctx context.Context, address common.Address, fromBlock, toBlock *rpc.BlockNumber, cursor *hexutil.Bytes, limit *hexutil.Uint) (*AccountTransfersPage, error) {
+	r, err := s.parseAccountTxsRange(ctx, fromBlock, toBlock, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// request one more to find out if it's the last page
+	positions, err := s.b.GetInternalTransfersByAddress(ctx, address, r.from, r.fromOffset, r.to, r.limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AccountTransfersPage{
+		Transactions: make([]*RPCTransactionTransfers, 0, len(positions)),
+	}
+	positions, page.Cursor = r.paginate(positions)
+
+	var header *evmcore.EvmHeader
+	for _, p := range positions {
+		if header == nil || header.Number.Uint64() != uint64(p.Block) {
+			header, err = s.b.HeaderByNumber(ctx, rpc.BlockNumber(p.Block))
+			if err != nil {
+				return nil, err
+			}
+			if header == nil {
+				return nil, fmt.Errorf("block #%d not found", p.Block)
+			}
+		}
+		transfers, err := s.b.GetInternalTransfers(ctx, p.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		txTransfers := &RPCTransactionTransfers{
+			TransactionHash:  p.TxHash,
+			BlockHash:        header.Hash,
+			BlockNumber:      hexutil.Uint64(p.Block),
+			TransactionIndex: hexutil.Uint64(p.BlockOffset),
+		}
+		for _, t := range transfers {
+			if t.From == address || t.To == address {
+				txTransfers.Transfers = append(txTransfers.Transfers, newRPCInternalTransfer(t))
+			}
+		}
+		page.Transactions = append(page.Transactions, txTransfers)
+	}
+	return page, nil
+}
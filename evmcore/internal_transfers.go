@@ -0,0 +1,157 @@
+package evmcore
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// InternalTransferKind is a kind of the operation which made an internal transfer.
+type InternalTransferKind uint8
+
+const (
+	// TransferCall is a CALL with value
+	TransferCall InternalTransferKind = iota + 1
+	// TransferCreate is a CREATE or CREATE2 with value
+	TransferCreate
+	// TransferSelfDestruct is a SELFDESTRUCT of a contract with non-zero balance
+	TransferSelfDestruct
+)
+
+// InternalTransfer is a value transfer made by a contract during a transaction execution.
+type InternalTransfer struct {
+	Kind  InternalTransferKind
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+type transfersFrame struct {
+	depth     int
+	op        vm.OpCode
+	transfer  *InternalTransfer // own value transfer of the call, if any
+	transfers []InternalTransfer
+}
+
+// InternalTransfersTracer is a vm.Tracer which records internal value transfers
+// of every executed transaction. Transfers of reverted calls are dropped.
+type InternalTransfersTracer struct {
+	frames  []*transfersFrame // the first frame is the transaction itself
+	onTxEnd func(transfers []InternalTransfer)
+}
+
+// NewInternalTransfersTracer creates a tracer which calls onTxEnd after each transaction
+// with the non-reverted internal transfers it made.
+func NewInternalTransfersTracer(onTxEnd func(transfers []InternalTransfer)) *InternalTransfersTracer {
+	return &InternalTransfersTracer{
+		onTxEnd: onTxEnd,
+	}
+}
+
+// CaptureStart implements vm.Tracer.
+func (t *InternalTransfersTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames[:0], &transfersFrame{})
+}
+
+// CaptureState implements vm.Tracer.
+func (t *InternalTransfersTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	stack := scope.Stack
+	t.finishFrames(depth, stack)
+	if err != nil {
+		return
+	}
+
+	self := scope.Contract.Address()
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+		f := &transfersFrame{
+			depth: depth,
+			op:    op,
+		}
+		switch op {
+		case vm.CALL:
+			if value := stack.Back(2); !value.IsZero() {
+				f.transfer = &InternalTransfer{
+					Kind:  TransferCall,
+					From:  self,
+					To:    common.Address(stack.Back(1).Bytes20()),
+					Value: value.ToBig(),
+				}
+			}
+		case vm.CREATE, vm.CREATE2:
+			if value := stack.Back(0); !value.IsZero() {
+				// recipient is known after the contract is created
+				f.transfer = &InternalTransfer{
+					Kind:  TransferCreate,
+					From:  self,
+					Value: value.ToBig(),
+				}
+			}
+		}
+		t.frames = append(t.frames, f)
+	case vm.SELFDESTRUCT:
+		balance := env.StateDB.GetBalance(self)
+		if balance.Sign() > 0 {
+			top := t.frames[len(t.frames)-1]
+			top.transfers = append(top.transfers, InternalTransfer{
+				Kind:  TransferSelfDestruct,
+				From:  self,
+				To:    common.Address(stack.Back(0).Bytes20()),
+				Value: new(big.Int).Set(balance),
+			})
+		}
+	}
+}
+
+// finishFrames merges the returned calls into the callers, or drops them if the calls failed.
+func (t *InternalTransfersTracer) finishFrames(depth int, stack *vm.Stack) {
+	for len(t.frames) > 1 {
+		f := t.frames[len(t.frames)-1]
+		if f.depth < depth {
+			return
+		}
+		t.frames = t.frames[:len(t.frames)-1]
+
+		// the call result is pushed onto the caller's stack
+		if f.depth == depth && stack != nil && len(stack.Data()) > 0 {
+			result := stack.Back(0)
+			if result.IsZero() {
+				continue
+			}
+			if f.transfer != nil && f.transfer.Kind == TransferCreate {
+				f.transfer.To = common.Address(result.Bytes20())
+			}
+		}
+
+		parent := t.frames[len(t.frames)-1]
+		if f.transfer != nil {
+			parent.transfers = append(parent.transfers, *f.transfer)
+		}
+		parent.transfers = append(parent.transfers, f.transfers...)
+	}
+}
+
+// CaptureFault implements vm.Tracer.
+func (t *InternalTransfersTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnd implements vm.Tracer.
+func (t *InternalTransfersTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	var transfers []InternalTransfer
+	if err == nil {
+		t.finishFrames(0, nil)
+		transfers = t.frames[0].transfers
+	}
+	t.frames = t.frames[:0]
+	if t.onTxEnd != nil {
+		t.onTxEnd(transfers)
+	}
+}
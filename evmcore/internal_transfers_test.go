@@ -0,0 +1,109 @@
+package evmcore
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+// callCode returns a code which calls the address with the value and pops the result.
+func callCode(to common.Address, value byte) []byte {
+	code := []byte{
+		byte(vm.PUSH1), 0, // retSize
+		byte(vm.PUSH1), 0, // retOffset
+		byte(vm.PUSH1), 0, // argsSize
+		byte(vm.PUSH1), 0, // argsOffset
+		byte(vm.PUSH1), value,
+		byte(vm.PUSH20),
+	}
+	code = append(code, to.Bytes()...)
+	return append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+}
+
+func TestInternalTransfersTracer(t *testing.T) {
+	var (
+		a = common.Address{0xa}
+		b = common.Address{0xb}
+		c = common.Address{0xc}
+		d = common.Address{0xd}
+		e = common.Address{0xe}
+	)
+	selfdestructTo := func(to common.Address) []byte {
+		return append(append([]byte{byte(vm.PUSH20)}, to.Bytes()...), byte(vm.SELFDESTRUCT))
+	}
+	revert := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+
+	for name, tc := range map[string]struct {
+		code   map[common.Address][]byte
+		expect []InternalTransfer
+	}{
+		"nested": {
+			code: map[common.Address][]byte{
+				// a sends 5 to b, b self-destructs to c; a sends 7 to d, d reverts
+				a: append(callCode(b, 5), callCode(d, 7)...),
+				b: selfdestructTo(c),
+				d: revert,
+			},
+			expect: []InternalTransfer{
+				{Kind: TransferCall, From: a, To: b, Value: big.NewInt(5)},
+				{Kind: TransferSelfDestruct, From: b, To: c, Value: big.NewInt(5)},
+			},
+		},
+		"reverted caller": {
+			code: map[common.Address][]byte{
+				// a calls e without value, e sends 3 to b and reverts
+				a: callCode(e, 0),
+				e: append(callCode(b, 3), revert...),
+			},
+			expect: nil,
+		},
+		"reverted tx": {
+			code: map[common.Address][]byte{
+				a: append(callCode(b, 5), revert...),
+			},
+			expect: nil,
+		},
+		"no value": {
+			code: map[common.Address][]byte{
+				a: callCode(b, 0),
+			},
+			expect: nil,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+			require.NoError(err)
+			for addr, code := range tc.code {
+				statedb.SetCode(addr, code)
+			}
+			statedb.SetBalance(a, big.NewInt(100))
+
+			var (
+				got   []InternalTransfer
+				calls int
+			)
+			tracer := NewInternalTransfersTracer(func(transfers []InternalTransfer) {
+				got = transfers
+				calls++
+			})
+			_, _, _ = runtime.Call(a, nil, &runtime.Config{
+				State:    statedb,
+				GasLimit: 1000000,
+				EVMConfig: vm.Config{
+					Debug:  true,
+					Tracer: tracer,
+				},
+			})
+			require.Equal(1, calls)
+			require.Equal(tc.expect, got)
+		})
+	}
+}
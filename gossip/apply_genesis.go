@@ -146,7 +146,7 @@ func (s *Store) applyEpoch1Genesis(blockProc BlockProc, g opera.Genesis) (err er
 	evmProcessor := blockProc.EVMModule.Start(blockCtx, statedb, evmStateReader, func(l *types.Log) {
 		txListener.OnNewLog(l)
 		sfcapi.OnNewLog(s.sfcapi, l)
-	}, es.Rules)
+	}, nil, es.Rules)
 
 	// Execute genesis-internal transactions
 	genesisInternalTxs := blockProc.GenesisTxTransactor.PopInternalTxs(blockCtx, bs, es, sealing, statedb)
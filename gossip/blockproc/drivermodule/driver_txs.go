@@ -1,6 +1,7 @@
 package drivermodule
 
 import (
+	"errors"
 	"io"
 	"math"
 	"math/big"
@@ -203,6 +204,23 @@ func decodeDataBytes(l *types.Log) ([]byte, error) {
 	return l.Data[start+32 : start+32+size], nil
 }
 
+var errNotPubkeyUpdate = errors.New("not UpdateValidatorPubkey Driver event")
+
+// DecodeUpdateValidatorPubkey decodes the validator ID and the new key of UpdateValidatorPubkey Driver event.
+// The key becomes effective at the epoch following the epoch of the event.
+func DecodeUpdateValidatorPubkey(l *types.Log) (idx.ValidatorID, validatorpk.PubKey, error) {
+	if l.Address != driver.ContractAddress || len(l.Topics) < 2 || l.Topics[0] != driverpos.Topics.UpdateValidatorPubkey {
+		return 0, validatorpk.PubKey{}, errNotPubkeyUpdate
+	}
+	validatorID := idx.ValidatorID(new(big.Int).SetBytes(l.Topics[1][:]).Uint64())
+	pubkey, err := decodeDataBytes(l)
+	if err != nil {
+		return 0, validatorpk.PubKey{}, err
+	}
+	decoded, _ := validatorpk.FromBytes(pubkey)
+	return validatorID, decoded, nil
+}
+
 func (p *DriverTxListener) OnNewLog(l *types.Log) {
 	if l.Address != driver.ContractAddress {
 		return
@@ -228,8 +246,7 @@ func (p *DriverTxListener) OnNewLog(l *types.Log) {
 	}
 	// Track validator pubkey changes
 	if l.Topics[0] == driverpos.Topics.UpdateValidatorPubkey && len(l.Topics) > 1 {
-		validatorID := idx.ValidatorID(new(big.Int).SetBytes(l.Topics[1][:]).Uint64())
-		pubkey, err := decodeDataBytes(l)
+		validatorID, pubkey, err := DecodeUpdateValidatorPubkey(l)
 		if err != nil {
 			log.Warn("Malformed UpdatedValidatorPubkey Driver event")
 			return
@@ -240,8 +257,9 @@ func (p *DriverTxListener) OnNewLog(l *types.Log) {
 			log.Warn("Unexpected UpdatedValidatorPubkey Driver event")
 			return
 		}
-		profile.PubKey, _ = validatorpk.FromBytes(pubkey)
+		profile.PubKey = pubkey
 		p.bs.NextValidatorProfiles[validatorID] = profile
+		log.Info("Validator pubkey is updated", "validator", validatorID, "pubkey", profile.PubKey.String(), "epoch", p.es.Epoch+1)
 	}
 	// Update rules
 	if l.Topics[0] == driverpos.Topics.UpdateNetworkRules && len(l.Data) >= 64 {
@@ -22,19 +22,20 @@ func New() *EVMModule {
 	return &EVMModule{}
 }
 
-func (p *EVMModule) Start(block blockproc.BlockCtx, statedb *state.StateDB, reader evmcore.DummyChain, onNewLog func(*types.Log), net opera.Rules) blockproc.EVMProcessor {
+func (p *EVMModule) Start(block blockproc.BlockCtx, statedb *state.StateDB, reader evmcore.DummyChain, onNewLog func(*types.Log), onNewTransfers func(common.Hash, []evmcore.InternalTransfer), net opera.Rules) blockproc.EVMProcessor {
 	var prevBlockHash common.Hash
 	if block.Idx != 0 {
 		prevBlockHash = reader.GetHeader(common.Hash{}, uint64(block.Idx-1)).Hash
 	}
 	return &OperaEVMProcessor{
-		block:         block,
-		reader:        reader,
-		statedb:       statedb,
-		onNewLog:      onNewLog,
-		net:           net,
-		blockIdx:      utils.U64toBig(uint64(block.Idx)),
-		prevBlockHash: prevBlockHash,
+		block:          block,
+		reader:         reader,
+		statedb:        statedb,
+		onNewLog:       onNewLog,
+		onNewTransfers: onNewTransfers,
+		net:            net,
+		blockIdx:       utils.U64toBig(uint64(block.Idx)),
+		prevBlockHash:  prevBlockHash,
 	}
 }
 
@@ -43,7 +44,9 @@ type OperaEVMProcessor struct {
 	reader   evmcore.DummyChain
 	statedb  *state.StateDB
 	onNewLog func(*types.Log)
-	net      opera.Rules
+	// onNewTransfers is called for every tx with internal value transfers, tracing is disabled if nil
+	onNewTransfers func(common.Hash, []evmcore.InternalTransfer)
+	net            opera.Rules
 
 	blockIdx      *big.Int
 	prevBlockHash common.Hash
@@ -73,9 +76,19 @@ func (p *OperaEVMProcessor) evmBlockWith(txs types.Transactions) *evmcore.EvmBlo
 func (p *OperaEVMProcessor) Execute(txs types.Transactions, internal bool) types.Receipts {
 	evmProcessor := evmcore.NewStateProcessor(p.net.EvmChainConfig(), p.reader)
 
+	vmConfig := opera.DefaultVMConfig
+	if p.onNewTransfers != nil {
+		vmConfig.Debug = true
+		vmConfig.Tracer = evmcore.NewInternalTransfersTracer(func(transfers []evmcore.InternalTransfer) {
+			if len(transfers) != 0 {
+				p.onNewTransfers(txs[p.statedb.TxIndex()].Hash(), transfers)
+			}
+		})
+	}
+
 	// Process txs
 	evmBlock := p.evmBlockWith(txs)
-	receipts, _, skipped, err := evmProcessor.Process(evmBlock, p.statedb, opera.DefaultVMConfig, &p.gasUsed, internal, func(log *types.Log, _ *state.StateDB) {
+	receipts, _, skipped, err := evmProcessor.Process(evmBlock, p.statedb, vmConfig, &p.gasUsed, internal, func(log *types.Log, _ *state.StateDB) {
 		p.onNewLog(log)
 	})
 	if err != nil {
@@ -19,12 +19,14 @@ import (
 
 	"github.com/Fantom-foundation/go-opera/evmcore"
 	"github.com/Fantom-foundation/go-opera/gossip/blockproc"
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc/drivermodule"
 	"github.com/Fantom-foundation/go-opera/gossip/blockproc/verwatcher"
 	"github.com/Fantom-foundation/go-opera/gossip/emitter"
 	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
 	"github.com/Fantom-foundation/go-opera/gossip/sfcapi"
 	"github.com/Fantom-foundation/go-opera/inter"
 	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/utils/gsignercache"
 )
 
 type ExtendedTxPosition struct {
@@ -42,8 +44,10 @@ func (s *Service) GetConsensusCallbacks() lachesis.ConsensusCallbacks {
 			s.store,
 			s.blockProcModules,
 			s.config.TxIndex,
+			s.config.AccountTxIndex,
+			s.config.InternalTransfersIndex,
 			&s.feed,
-			s.emitter,
+			s.emitters,
 			s.verWatcher,
 			nil,
 		),
@@ -60,8 +64,10 @@ func consensusCallbackBeginBlockFn(
 	store *Store,
 	blockProc BlockProc,
 	txIndex bool,
+	accountTxIndex bool,
+	transfersIndex bool,
 	feed *ServiceFeed,
-	emitter *emitter.Emitter,
+	emitters []*emitter.Emitter,
 	verWatcher *verwatcher.VerWarcher,
 	onBlockEnd func(block *inter.Block, preInternalReceipts, internalReceipts, externalReceipts types.Receipts),
 ) lachesis.BeginBlockFn {
@@ -102,8 +108,8 @@ func consensusCallbackBeginBlockFn(
 					confirmedEvents = append(confirmedEvents, e.ID())
 				}
 				eventProcessor.ProcessConfirmedEvent(e)
-				if emitter != nil {
-					emitter.OnEventConfirmed(e)
+				for _, em := range emitters {
+					em.OnEventConfirmed(e)
 				}
 			},
 			EndBlock: func() (newValidators *pos.Validators) {
@@ -149,8 +155,24 @@ func consensusCallbackBeginBlockFn(
 						verWatcher.OnNewLog(l)
 					}
 					sfcapi.OnNewLog(store.sfcapi, l)
+					// the validator key is switched by the emitter at the epoch when the new key becomes effective
+					if validatorID, pubkey, err := drivermodule.DecodeUpdateValidatorPubkey(l); err == nil {
+						for _, em := range emitters {
+							em.OnValidatorPubkeyUpdate(validatorID, pubkey, es.Epoch+1)
+						}
+					}
+				}
+				var (
+					internalTransfers map[common.Hash][]evmcore.InternalTransfer
+					onNewTransfers    func(common.Hash, []evmcore.InternalTransfer)
+				)
+				if transfersIndex {
+					internalTransfers = make(map[common.Hash][]evmcore.InternalTransfer)
+					onNewTransfers = func(txid common.Hash, transfers []evmcore.InternalTransfer) {
+						internalTransfers[txid] = transfers
+					}
 				}
-				evmProcessor := blockProc.EVMModule.Start(blockCtx, statedb, evmStateReader, onNewLogAll, es.Rules)
+				evmProcessor := blockProc.EVMModule.Start(blockCtx, statedb, evmStateReader, onNewLogAll, onNewTransfers, es.Rules)
 
 				// Execute pre-internal transactions
 				preInternalTxs := blockProc.PreTxTransactor.PopInternalTxs(blockCtx, bs, es, sealing, statedb)
@@ -261,6 +283,13 @@ func consensusCallbackBeginBlockFn(
 							}
 						}
 					}
+					if accountTxIndex {
+						signer := gsignercache.Wrap(types.LatestSignerForChainID(es.Rules.EvmChainConfig().ChainID))
+						store.indexAccountTxs(blockCtx.Idx, block, evmBlock.Transactions, signer)
+					}
+					if transfersIndex {
+						store.indexInternalTransfers(blockCtx.Idx, evmBlock.Transactions, internalTransfers)
+					}
 					for _, tx := range append(preInternalTxs, internalTxs...) {
 						store.evm.SetTx(tx.Hash(), tx)
 					}
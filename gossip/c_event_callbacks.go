@@ -168,7 +168,9 @@ func (s *Service) processEvent(e *inter.EventPayload) error {
 		s.store.SetHighestLamport(e.Lamport())
 	}
 
-	s.emitter.OnEventConnected(e)
+	for _, em := range s.emitters {
+		em.OnEventConnected(e)
+	}
 
 	if newEpoch != oldEpoch {
 		// reset dag indexer
@@ -181,7 +183,9 @@ func (s *Service) processEvent(e *inter.EventPayload) error {
 		s.gasPowerCheckReader.Ctx.Store(NewGasPowerContext(s.store, s.store.GetValidators(), newEpoch, s.store.GetRules().Economy)) // read gaspower check data from disk
 		s.heavyCheckReader.Addrs.Store(NewEpochPubKeys(s.store, newEpoch))
 		// notify about new epoch
-		s.emitter.OnNewEpoch(s.store.GetValidators(), newEpoch)
+		for _, em := range s.emitters {
+			em.OnNewEpoch(s.store.GetValidators(), newEpoch)
+		}
 		s.feed.newEpoch.Send(newEpoch)
 	}
 
@@ -206,3 +210,8 @@ func (u *uniqueID) sample() [24]byte {
 func (s *Service) DagProcessor() *dagprocessor.Processor {
 	return s.pm.processor
 }
+
+// Store returns the gossip store of the service.
+func (s *Service) Store() *Store {
+	return s.store
+}
@@ -122,7 +122,11 @@ func (env *testEnv) GetEvmStateReader() *EvmStateReader {
 func (env *testEnv) consensusCallbackBeginBlockFn(
 	onBlockEnd func(block *inter.Block, preInternalReceipts, internalReceipts, externalReceipts types.Receipts),
 ) lachesis.BeginBlockFn {
-	const txIndex = true
+	const (
+		txIndex        = true
+		accountTxIndex = true
+		transfersIndex = true
+	)
 	callback := consensusCallbackBeginBlockFn(
 		env.blockProcTasks,
 		&env.blockProcWg,
@@ -130,6 +134,8 @@ func (env *testEnv) consensusCallbackBeginBlockFn(
 		env.store,
 		env.blockProcModules,
 		txIndex,
+		accountTxIndex,
+		transfersIndex,
 		nil,
 		nil,
 		nil,
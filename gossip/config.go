@@ -56,12 +56,19 @@ type (
 	// Config for the gossip service.
 	Config struct {
 		Emitter emitter.Config
-		TxPool  evmcore.TxPoolConfig
+		// ExtraEmitters are the emitters of the other validators hosted by the node.
+		// The emitters share the DAG and the stores, but have their own keys, slashing protection and settings.
+		ExtraEmitters []emitter.Config
+		TxPool        evmcore.TxPoolConfig
 
 		FilterAPI filters.Config
 
 		TxIndex bool // Whether to enable indexing transactions and receipts or not
 
+		AccountTxIndex bool // Whether to enable indexing transactions by sender and recipient or not
+
+		InternalTransfersIndex bool // Whether to enable recording internal value transfers of transactions or not
+
 		// Protocol options
 		Protocol ProtocolConfig
 
@@ -220,10 +227,54 @@ func (c *Config) Validate() error {
 	if c.Protocol.Processor.EventsBufferLimit.Size < protocolMaxMsgSize {
 		return fmt.Errorf("EventsBufferLimit.Size has to be at least %d", protocolMaxMsgSize)
 	}
+	if len(c.ExtraEmitters) != 0 && c.Emitter.Validator.ID == 0 {
+		return fmt.Errorf("ExtraEmitters require the main Emitter.Validator to be specified")
+	}
+	validators := make(map[idx.ValidatorID]bool)
+	files := make(map[string]bool)
+	for i, e := range c.Emitters() {
+		name := "Emitter"
+		if i != 0 {
+			name = fmt.Sprintf("ExtraEmitters[%d]", i-1)
+			if e.Validator.ID == 0 || e.Validator.PubKey.Empty() {
+				return fmt.Errorf("%s.Validator: ID and public key must be specified", name)
+			}
+		}
+		if len(e.Failover.LeasePath) != 0 && e.Failover.LeaseTTL < time.Second {
+			return fmt.Errorf("%s.Failover.LeaseTTL has to be at least %s", name, time.Second)
+		}
+		if err := e.TxPolicy.Validate(); err != nil {
+			return fmt.Errorf("%s.TxPolicy: %v", name, err)
+		}
+		if err := e.Validator.Keystore.Validate(); err != nil {
+			return fmt.Errorf("%s.Validator.Keystore: %v", name, err)
+		}
+		if e.Validator.ID != 0 {
+			if validators[e.Validator.ID] {
+				return fmt.Errorf("%s: validator %d is hosted twice", name, e.Validator.ID)
+			}
+			validators[e.Validator.ID] = true
+		}
+		// the files of a validator cannot be shared with another validator
+		for _, path := range []string{e.PrevEmittedEventFile.Path, e.SlashingProtection.Path, e.OverridesPath, e.Failover.LeasePath} {
+			if len(path) == 0 {
+				continue
+			}
+			if files[path] {
+				return fmt.Errorf("%s: file %s is used by another emitter", name, path)
+			}
+			files[path] = true
+		}
+	}
 
 	return nil
 }
 
+// Emitters returns the configs of all the emitters hosted by the node, the main emitter first.
+func (c *Config) Emitters() []emitter.Config {
+	return append([]emitter.Config{c.Emitter}, c.ExtraEmitters...)
+}
+
 // FakeConfig returns the default configurations for the gossip service in fakenet.
 func FakeConfig(num int, scale cachescale.Func) Config {
 	cfg := DefaultConfig(scale)
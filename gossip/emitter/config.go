@@ -9,6 +9,7 @@ import (
 
 	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
 	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
 )
 
 // EmitIntervals is the configuration of emit intervals.
@@ -23,6 +24,10 @@ type EmitIntervals struct {
 type ValidatorConfig struct {
 	ID     idx.ValidatorID
 	PubKey validatorpk.PubKey
+	// NextPubKey is a key which replaces PubKey at the epoch when it's set in the validator profile
+	NextPubKey validatorpk.PubKey
+	// Keystore selects the backend which holds the validator keys
+	Keystore valkeystore.Config
 }
 
 type PrevEmittedEventFile struct {
@@ -30,6 +35,23 @@ type PrevEmittedEventFile struct {
 	SyncMode bool
 }
 
+// SlashingProtection is the configuration of the signing history, which refuses to sign conflicting events.
+// The history must be kept together with the validator key, i.e. be moved to a backup machine on failover.
+type SlashingProtection struct {
+	Path string
+}
+
+// FailoverConfig is the configuration of active/passive instances of a validator.
+// Only the instance holding the lease emits events, the standby instances stay synced.
+type FailoverConfig struct {
+	// LeasePath is a path of the lease file on a storage shared by the instances, the failover is disabled if empty
+	LeasePath string
+	// LeaseHolder is a unique name of the instance
+	LeaseHolder string
+	// LeaseTTL is the duration of the lease, the standby takes over after the lease of the active instance expires
+	LeaseTTL time.Duration
+}
+
 // Config is the configuration of events emitter.
 type Config struct {
 	VersionToPublish string
@@ -40,6 +62,9 @@ type Config struct {
 
 	MaxTxsPerAddress int
 
+	// TxPolicy defines the order in which transactions are originated
+	TxPolicy TxPolicy
+
 	MaxParents idx.Event
 
 	// thresholds on GasLeft
@@ -50,6 +75,16 @@ type Config struct {
 	TxsCacheInvalidation time.Duration
 
 	PrevEmittedEventFile PrevEmittedEventFile
+	SlashingProtection   SlashingProtection
+
+	Failover FailoverConfig
+
+	// OverridesPath is a path of the file with settings changed at runtime, which take precedence over the config.
+	// The runtime changes aren't persisted if empty
+	OverridesPath string
+
+	// DecisionLogSize is the number of recent emission decisions kept for the emitter_decisions RPC
+	DecisionLogSize int
 }
 
 // DefaultConfig returns the default configurations for the events emitter.
@@ -67,6 +102,10 @@ func DefaultConfig() Config {
 
 		MaxTxsPerAddress: TxTurnNonces,
 
+		TxPolicy: TxPolicy{
+			Ordering: OrderByPrice,
+		},
+
 		MaxParents: 0,
 
 		LimitedTpsThreshold: opera.DefaultEventGas * 120,
@@ -74,6 +113,12 @@ func DefaultConfig() Config {
 		EmergencyThreshold:  opera.DefaultEventGas * 5,
 
 		TxsCacheInvalidation: 200 * time.Millisecond,
+
+		Failover: FailoverConfig{
+			LeaseTTL: 30 * time.Second,
+		},
+
+		DecisionLogSize: 512,
 	}
 }
 
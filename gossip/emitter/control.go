@@ -55,15 +55,18 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 	adjustedPassedTime := time.Duration(ancestor.Metric(passedTime/piecefunc.DecimalUnit) * metric)
 	adjustedPassedIdleTime := time.Duration(ancestor.Metric(passedTimeIdle/piecefunc.DecimalUnit) * metric)
 	passedBlocks := em.world.GetLatestBlockIndex() - em.prevEmittedAtBlock
+	em.decision.setTiming(metric, passedTime, passedBlocks, e.GasPowerLeft().Min())
 	// Forbid emitting if not enough power and power is decreasing
 	{
 		threshold := em.config.EmergencyThreshold
 		if e.GasPowerLeft().Min() <= threshold {
+			em.decision.hitThreshold(ThresholdEmergency)
 			if selfParent != nil && e.GasPowerLeft().Min() < selfParent.GasPowerLeft().Min() {
 				em.Periodic.Warn(10*time.Second, "Not enough power to emit event, waiting",
 					"power", e.GasPowerLeft().String(),
 					"selfParentPower", selfParent.GasPowerLeft().String(),
 					"stake%", 100*float64(em.validators.Get(e.Creator()))/float64(em.validators.TotalWeight()))
+				em.decision.skip(SkipEmergencyGasPower, "")
 				return false
 			}
 		}
@@ -75,9 +78,13 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 		if rules.Economy.BlockMissedSlack > maxBlocks && maxBlocks < rules.Economy.BlockMissedSlack-5 {
 			maxBlocks = rules.Economy.BlockMissedSlack - 5
 		}
-		if passedTime >= em.intervals.Max ||
-			passedBlocks >= maxBlocks*4/5 && metric >= piecefunc.DecimalUnit/2 ||
+		if passedTime >= em.intervals.Max {
+			em.decision.force(ForceMaxInterval)
+			return true
+		}
+		if passedBlocks >= maxBlocks*4/5 && metric >= piecefunc.DecimalUnit/2 ||
 			passedBlocks >= maxBlocks {
+			em.decision.force(ForceMissedBlocks)
 			return true
 		}
 	}
@@ -85,12 +92,14 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 	{
 		threshold := (em.config.NoTxsThreshold + em.config.EmergencyThreshold) / 2
 		if e.GasPowerLeft().Min() <= threshold {
+			em.decision.hitThreshold(ThresholdSlowdown)
 			// it's emitter, so no need in determinism => fine to use float
 			minT := float64(em.intervals.Min)
 			maxT := float64(em.intervals.Max)
 			factor := float64(e.GasPowerLeft().Min()) / float64(threshold)
 			adjustedEmitInterval := time.Duration(maxT - (maxT-minT)*factor)
 			if passedTime < adjustedEmitInterval {
+				em.decision.skip(SkipLowGasPower, "")
 				return false
 			}
 		}
@@ -100,21 +109,25 @@ func (em *Emitter) isAllowedToEmit(e inter.EventI, eTxs bool, metric ancestor.Me
 		if passedTime < em.intervals.Max &&
 			em.idle() &&
 			!eTxs {
+			em.decision.skip(SkipIdle, "")
 			return false
 		}
 	}
 	// Emitting is controlled by the efficiency metric
 	{
 		if passedTime < em.intervals.Min {
+			em.decision.skip(SkipMinInterval, "")
 			return false
 		}
 		if adjustedPassedTime < em.intervals.Min &&
 			!em.idle() {
+			em.decision.skip(SkipLowMetric, "")
 			return false
 		}
 		if adjustedPassedIdleTime < em.intervals.Confirming &&
 			!em.idle() &&
 			!eTxs {
+			em.decision.skip(SkipLowMetricConfirm, "")
 			return false
 		}
 	}
@@ -0,0 +1,238 @@
+package emitter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/emitter/ancestor"
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/Fantom-foundation/go-opera/utils/piecefunc"
+)
+
+// Reasons of skipped emission
+const (
+	SkipNotValidator       = "not_validator"
+	SkipNotSynced          = "not_synced"
+	SkipDoublesign         = "doublesign"
+	SkipFork               = "fork"
+	SkipNotEnoughGasPower  = "not_enough_gas_power"
+	SkipBuildFailed        = "build_failed"
+	SkipEmergencyGasPower  = "emergency_gas_power"
+	SkipLowGasPower        = "low_gas_power"
+	SkipIdle               = "idle"
+	SkipMinInterval        = "min_interval"
+	SkipLowMetric          = "low_metric"
+	SkipLowMetricConfirm   = "low_metric_confirming"
+	SkipSlashingProtection = "slashing_protection"
+	SkipSignFailed         = "sign_failed"
+	SkipIncorrectEvent     = "incorrect_event"
+	SkipProcessFailed      = "process_failed"
+)
+
+// Reasons of enforced emission
+const (
+	ForceMaxInterval  = "max_interval"
+	ForceMissedBlocks = "missed_blocks"
+)
+
+// Gas power thresholds
+const (
+	ThresholdEmergency  = "emergency"
+	ThresholdSlowdown   = "slowdown"
+	ThresholdLimitedTps = "limited_tps"
+	ThresholdNoTxs      = "no_txs"
+	ThresholdPendingGas = "pending_gas"
+)
+
+// Parent search strategies
+const (
+	ParentSelf    = "self"
+	ParentPayload = "payload"
+	ParentRandom  = "random"
+	ParentQuorum  = "quorum"
+)
+
+// decisionMetrics are the metrics of emission decisions.
+type decisionMetrics struct {
+	prefix  string
+	emitted metrics.Meter
+	skipped metrics.Meter
+	forced  metrics.Meter
+	metric  metrics.Histogram
+	passed  metrics.Timer
+	txs     metrics.Histogram
+	parents metrics.Histogram
+}
+
+func newDecisionMetrics(prefix string) *decisionMetrics {
+	return &decisionMetrics{
+		prefix:  prefix,
+		emitted: metrics.GetOrRegisterMeter(prefix+"emitted", nil),
+		skipped: metrics.GetOrRegisterMeter(prefix+"skipped", nil),
+		forced:  metrics.GetOrRegisterMeter(prefix+"forced", nil),
+		metric:  metrics.GetOrRegisterHistogram(prefix+"metric", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		passed:  metrics.GetOrRegisterTimer(prefix+"passed", nil),
+		txs:     metrics.GetOrRegisterHistogram(prefix+"txs", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		parents: metrics.GetOrRegisterHistogram(prefix+"parents", nil, metrics.NewExpDecaySample(1028, 0.015)),
+	}
+}
+
+// validatorDecisionMetrics returns the metrics of a validator hosted by the node.
+func validatorDecisionMetrics(validator idx.ValidatorID) *decisionMetrics {
+	return newDecisionMetrics(fmt.Sprintf("emitter/validator/%d/decisions/", validator))
+}
+
+// allDecisionMetrics are aggregated over all the validators hosted by the node
+var allDecisionMetrics = newDecisionMetrics("emitter/decisions/")
+
+func (m *decisionMetrics) mark(d *Decision) {
+	if m == nil {
+		return
+	}
+	if d.Emitted != nil {
+		m.emitted.Mark(1)
+		m.txs.Update(int64(d.Txs))
+		m.parents.Update(int64(len(d.Parents)))
+	} else {
+		m.skipped.Mark(1)
+		metrics.GetOrRegisterMeter(m.prefix+"skipped/"+d.Skipped, nil).Mark(1)
+	}
+	if d.Forced != "" {
+		m.forced.Mark(1)
+	}
+	if d.PassedTime != 0 {
+		m.metric.Update(int64(d.Metric * 1000))
+		m.passed.Update(d.PassedTime)
+	}
+}
+
+// Decision is a record of an emission attempt.
+type Decision struct {
+	Time time.Time
+	// Repeats is the number of the identical skipped attempts folded into the record
+	Repeats uint64
+	Epoch   idx.Epoch
+	// Emitted is the emitted event, or nil if the emission was skipped
+	Emitted *hash.Event
+	Skipped string
+	Details string
+	Forced  string
+
+	// Metric is an estimation of how much the event advances the consensus, in (0, 1]
+	Metric        float64
+	PassedTime    time.Duration
+	PassedBlocks  idx.Block
+	GasPowerLeft  uint64
+	GasThresholds []string
+	MaxGasToUse   uint64
+	Txs           int
+	Parents       hash.Events
+	ParentReasons []string
+}
+
+func (d *Decision) skip(reason, details string) {
+	if d == nil {
+		return
+	}
+	d.Skipped = reason
+	d.Details = details
+}
+
+func (d *Decision) force(reason string) {
+	if d == nil {
+		return
+	}
+	d.Forced = reason
+}
+
+func (d *Decision) hitThreshold(threshold string) {
+	if d == nil {
+		return
+	}
+	for _, t := range d.GasThresholds {
+		if t == threshold {
+			return
+		}
+	}
+	d.GasThresholds = append(d.GasThresholds, threshold)
+}
+
+func (d *Decision) setTiming(metric ancestor.Metric, passedTime time.Duration, passedBlocks idx.Block, gasPowerLeft uint64) {
+	if d == nil {
+		return
+	}
+	d.Metric = float64(metric) / piecefunc.DecimalUnit
+	d.PassedTime = passedTime
+	d.PassedBlocks = passedBlocks
+	d.GasPowerLeft = gasPowerLeft
+}
+
+func (d *Decision) foldable(prev *Decision) bool {
+	return d.Emitted == nil && prev.Emitted == nil &&
+		d.Skipped == prev.Skipped && d.Details == prev.Details && d.Epoch == prev.Epoch
+}
+
+// decisionLog is a bounded ring of the recent decisions.
+type decisionLog struct {
+	ring []Decision
+	head int
+	size int
+	mu   sync.Mutex
+}
+
+func newDecisionLog(capacity int) *decisionLog {
+	if capacity <= 0 {
+		return nil
+	}
+	return &decisionLog{
+		ring: make([]Decision, capacity),
+	}
+}
+
+func (l *decisionLog) add(d *Decision) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size != 0 {
+		last := &l.ring[(l.head+len(l.ring)-1)%len(l.ring)]
+		if d.foldable(last) {
+			repeats := last.Repeats + 1
+			*last = *d
+			last.Repeats = repeats
+			return
+		}
+	}
+	l.ring[l.head] = *d
+	l.head = (l.head + 1) % len(l.ring)
+	if l.size < len(l.ring) {
+		l.size++
+	}
+}
+
+// last returns up to n recent decisions, the latest first.
+func (l *decisionLog) last(n int) []Decision {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n <= 0 || n > l.size {
+		n = l.size
+	}
+	res := make([]Decision, n)
+	for i := 0; i < n; i++ {
+		res[i] = l.ring[(l.head+len(l.ring)-1-i)%len(l.ring)]
+	}
+	return res
+}
+
+// Decisions returns up to n recent emission decisions, the latest first.
+func (em *Emitter) Decisions(n int) []Decision {
+	return em.decisions.last(n)
+}
@@ -0,0 +1,57 @@
+package emitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionLog(t *testing.T) {
+	require := require.New(t)
+
+	require.Nil(newDecisionLog(0))
+	var nilLog *decisionLog
+	nilLog.add(&Decision{Skipped: SkipIdle})
+	require.Empty(nilLog.last(10))
+
+	l := newDecisionLog(3)
+	require.Empty(l.last(10))
+
+	start := time.Now()
+	emitted := func(i int) *Decision {
+		id := hash.Event(hash.Of([]byte{byte(i)}))
+		return &Decision{Time: start.Add(time.Duration(i)), Emitted: &id}
+	}
+	skipped := func(i int, reason string) *Decision {
+		return &Decision{Time: start.Add(time.Duration(i)), Skipped: reason}
+	}
+
+	// identical skips are folded
+	l.add(skipped(1, SkipIdle))
+	l.add(skipped(2, SkipIdle))
+	l.add(skipped(3, SkipIdle))
+	got := l.last(0)
+	require.Len(got, 1)
+	require.Equal(uint64(2), got[0].Repeats)
+	require.Equal(start.Add(3), got[0].Time)
+
+	// different skips and emitted events aren't folded
+	l.add(skipped(4, SkipMinInterval))
+	l.add(emitted(5))
+	l.add(emitted(6))
+	got = l.last(0)
+	require.Len(got, 3)
+	require.Equal(*emitted(6).Emitted, *got[0].Emitted)
+	require.Equal(*emitted(5).Emitted, *got[1].Emitted)
+	require.Equal(SkipMinInterval, got[2].Skipped)
+
+	// the oldest records are overwritten
+	l.add(skipped(7, SkipLowMetric))
+	got = l.last(2)
+	require.Len(got, 2)
+	require.Equal(SkipLowMetric, got[0].Skipped)
+	require.Equal(*emitted(6).Emitted, *got[1].Emitted)
+	require.Len(l.last(10), 3)
+}
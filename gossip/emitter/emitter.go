@@ -2,7 +2,6 @@ package emitter
 
 import (
 	"fmt"
-	"math/rand"
 	"os"
 	"strings"
 	"sync"
@@ -14,11 +13,13 @@ import (
 	"github.com/Fantom-foundation/lachesis-base/inter/pos"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/trie"
 	lru "github.com/hashicorp/golang-lru"
 
 	"github.com/Fantom-foundation/go-opera/evmcore"
 	"github.com/Fantom-foundation/go-opera/gossip/emitter/originatedtxs"
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/slashing"
 	"github.com/Fantom-foundation/go-opera/inter"
 	"github.com/Fantom-foundation/go-opera/logger"
 	"github.com/Fantom-foundation/go-opera/tracing"
@@ -66,21 +67,43 @@ type Emitter struct {
 
 	intervals EmitIntervals
 
+	// settings are protected by both the engine lock and settingsMu, and can be read under any of them
+	settings       Settings
+	configSettings Settings
+	settingsMu     sync.RWMutex
+	paused         uint32
+
+	maintenance maintenanceState
+
 	done chan struct{}
 	wg   sync.WaitGroup
 
 	maxParents idx.Event
 
+	txSelector TxSelector
+
 	cache struct {
-		sortedTxs *types.TransactionsByPriceAndNonce
+		sortedTxs TxIterator
 		poolTime  time.Time
 		poolBlock idx.Block
 		poolCount int
 	}
 
 	emittedEventFile *os.File
+	signedHistory    *slashing.Store
 	busyRate         *rate.Gauge
 
+	failover failoverState
+
+	// pubKeyUpdate is the pending validator key update, it's set during blocks processing
+	pubKeyUpdate   *pubKeyUpdate
+	pubKeyUpdateMu sync.Mutex
+
+	// decision is the record of current emission attempt
+	decision        *Decision
+	decisions       *decisionLog
+	decisionMetrics *decisionMetrics
+
 	logger.Periodic
 }
 
@@ -89,42 +112,77 @@ func NewEmitter(
 	config Config,
 	world World,
 ) *Emitter {
-	// Randomize event time to decrease chance of 2 parallel instances emitting event at the same time
-	// It increases the chance of detecting parallel instances
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	config.EmitIntervals = config.EmitIntervals.RandomizeEmitTime(r)
-
 	txTime, _ := lru.New(TxTimeBufferSize)
-	return &Emitter{
-		config:        config,
-		world:         world,
-		originatedTxs: originatedtxs.New(SenderCountBufferSize),
-		txTime:        txTime,
-		intervals:     config.EmitIntervals,
-		Periodic:      logger.Periodic{Instance: logger.MakeInstance()},
+	em := &Emitter{
+		config:         config,
+		configSettings: config.Settings(),
+		world:          world,
+		originatedTxs:  originatedtxs.New(SenderCountBufferSize),
+		txTime:         txTime,
+		decisions:      newDecisionLog(config.DecisionLogSize),
+		Periodic:       logger.Periodic{Instance: logger.MakeInstance()},
+	}
+	txSelector, err := NewTxSelector(config.TxPolicy, world.TxSigner, em.getTxTime)
+	if err != nil {
+		log.Crit("Failed to create transactions selector", "err", err)
+	}
+	em.txSelector = txSelector
+	if config.Validator.ID != 0 {
+		em.decisionMetrics = validatorDecisionMetrics(config.Validator.ID)
+	}
+
+	settings := em.configSettings
+	if len(config.OverridesPath) != 0 {
+		overrides, err := loadSettings(config.OverridesPath)
+		if err != nil {
+			log.Crit("Failed to load emitter settings overrides", "file", config.OverridesPath, "err", err)
+		}
+		if overrides != nil {
+			log.Warn("Emitter settings are overridden at runtime", "file", config.OverridesPath)
+			settings = *overrides
+		}
 	}
+	em.applySettings(settings)
+	return em
 }
 
 // init emitter without starting events emission
 func (em *Emitter) init() {
 	em.syncStatus.startup = time.Now()
-	em.syncStatus.lastConnected = time.Now()
-	em.syncStatus.p2pSynced = time.Now()
+	if em.syncStatus.lastConnected.IsZero() {
+		em.syncStatus.lastConnected = time.Now()
+		em.syncStatus.p2pSynced = time.Now()
+	}
 	validators, epoch := em.world.GetEpochValidators()
+	em.loadPubKeyUpdate(epoch)
 	em.OnNewEpoch(validators, epoch)
 
-	if len(em.config.PrevEmittedEventFile.Path) != 0 {
+	// the emission may be restarted by the failover, the file is opened once
+	if len(em.config.PrevEmittedEventFile.Path) != 0 && em.emittedEventFile == nil {
 		em.emittedEventFile = openEventFile(em.config.PrevEmittedEventFile.Path, em.config.PrevEmittedEventFile.SyncMode)
 	}
+	em.loadMaintenanceMarker()
+	if len(em.config.SlashingProtection.Path) != 0 {
+		em.signedHistory = openSlashingProtection(em.config.SlashingProtection.Path)
+	}
 	em.busyRate = rate.NewGauge()
 }
 
 // Start starts event emission.
+// In the failover mode, the emission is started only while the lease is held.
 func (em *Emitter) Start() {
 	if em.config.Validator.ID == 0 {
 		// short circuit if not a validator
 		return
 	}
+	if em.world.Lease != nil {
+		em.startFailover()
+		return
+	}
+	em.start()
+}
+
+func (em *Emitter) start() {
 	if em.done != nil {
 		return
 	}
@@ -157,6 +215,15 @@ func (em *Emitter) Start() {
 
 // Stop stops event emission.
 func (em *Emitter) Stop() {
+	em.stopMaintenance()
+	if em.world.Lease != nil {
+		em.stopFailover()
+		return
+	}
+	em.stop()
+}
+
+func (em *Emitter) stop() {
 	if em.done == nil {
 		return
 	}
@@ -165,18 +232,14 @@ func (em *Emitter) Stop() {
 	em.done = nil
 	em.wg.Wait()
 	em.busyRate.Stop()
+	if em.signedHistory != nil {
+		_ = em.signedHistory.Close()
+		em.signedHistory = nil
+	}
 }
 
 func (em *Emitter) tick() {
-	// track synced time
-	if em.world.PeersNum() == 0 {
-		// connected time ~= last time when it's true that "not connected yet"
-		em.syncStatus.lastConnected = time.Now()
-	}
-	if !em.world.IsSynced() {
-		// synced time ~= last time when it's true that "not synced yet"
-		em.syncStatus.p2pSynced = time.Now()
-	}
+	em.trackSyncStatus()
 	if em.idle() {
 		em.busyRate.Mark(0)
 	} else {
@@ -188,12 +251,15 @@ func (em *Emitter) tick() {
 
 	em.recheckChallenges()
 	em.recheckIdleTime()
-	if time.Since(em.prevEmittedAtTime) >= em.intervals.Min {
+	em.settingsMu.RLock()
+	minInterval := em.intervals.Min
+	em.settingsMu.RUnlock()
+	if time.Since(em.prevEmittedAtTime) >= minInterval {
 		_ = em.EmitEvent()
 	}
 }
 
-func (em *Emitter) getSortedTxs() *types.TransactionsByPriceAndNonce {
+func (em *Emitter) getSortedTxs() TxIterator {
 	// Short circuit if pool wasn't updated since the cache was built
 	poolCount := em.world.TxPool.Count()
 	if em.cache.sortedTxs != nil &&
@@ -208,13 +274,14 @@ func (em *Emitter) getSortedTxs() *types.TransactionsByPriceAndNonce {
 		em.Log.Error("Tx pool transactions fetching error", "err", err)
 		return nil
 	}
+	maxTxsPerAddress := em.Settings().MaxTxsPerAddress
 	for from, txs := range pendingTxs {
 		// Filter the excessive transactions from each sender
-		if len(txs) > em.config.MaxTxsPerAddress {
-			pendingTxs[from] = txs[:em.config.MaxTxsPerAddress]
+		if len(txs) > maxTxsPerAddress {
+			pendingTxs[from] = txs[:maxTxsPerAddress]
 		}
 	}
-	sortedTxs := types.NewTransactionsByPriceAndNonce(em.world.TxSigner, pendingTxs)
+	sortedTxs := em.txSelector.Select(pendingTxs)
 	em.cache.sortedTxs = sortedTxs
 	em.cache.poolCount = poolCount
 	em.cache.poolBlock = em.world.GetLatestBlockIndex()
@@ -227,6 +294,13 @@ func (em *Emitter) EmitEvent() *inter.EventPayload {
 		// short circuit if not a validator
 		return nil
 	}
+	if !em.leaseValid() {
+		// standby instance, or the lease may be expired
+		return nil
+	}
+	if em.Paused() || em.inMaintenance() {
+		return nil
+	}
 	sortedTxs := em.getSortedTxs()
 
 	if em.world.IsBusy() {
@@ -237,6 +311,17 @@ func (em *Emitter) EmitEvent() *inter.EventPayload {
 
 	start := time.Now()
 
+	em.decision = &Decision{
+		Time:  start,
+		Epoch: em.epoch,
+	}
+	defer func() {
+		allDecisionMetrics.mark(em.decision)
+		em.decisionMetrics.mark(em.decision)
+		em.decisions.add(em.decision)
+		em.decision = nil
+	}()
+
 	e := em.createEvent(sortedTxs)
 	if e == nil {
 		return nil
@@ -246,8 +331,11 @@ func (em *Emitter) EmitEvent() *inter.EventPayload {
 	err := em.world.Process(e)
 	if err != nil {
 		em.Log.Error("Self-event connection failed", "err", err.Error())
+		em.decision.skip(SkipProcessFailed, err.Error())
 		return nil
 	}
+	id := e.ID()
+	em.decision.Emitted = &id
 	// write event ID to avoid doublesigning in future after a crash
 	em.writeLastEmittedEventID(e.ID())
 	// broadcast the event
@@ -282,13 +370,20 @@ func (em *Emitter) loadPrevEmitTime() time.Time {
 }
 
 // createEvent is not safe for concurrent use.
-func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) *inter.EventPayload {
+func (em *Emitter) createEvent(sortedTxs TxIterator) *inter.EventPayload {
 	if !em.isValidator() {
+		em.decision.skip(SkipNotValidator, "")
 		return nil
 	}
 
-	if synced := em.logSyncStatus(em.isSyncedToEmit()); !synced {
+	if wait, syncErr := em.isSyncedToEmit(); !em.logSyncStatus(wait, syncErr) {
 		// I'm reindexing my old events, so don't create events until connect all the existing self-events
+		em.decision.skip(SkipNotSynced, syncErr.Error())
+		return nil
+	}
+	if err := em.checkResumed(); err != nil {
+		em.Periodic.Info(7*time.Second, "Emitting is paused", "reason", err)
+		em.decision.skip(SkipNotSynced, err.Error())
 		return nil
 	}
 
@@ -300,10 +395,15 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) *in
 	)
 
 	// Find parents
-	selfParent, parents, ok := em.chooseParents(em.epoch, em.config.Validator.ID)
+	selfParent, parents, reasons, ok := em.chooseParents(em.epoch, em.config.Validator.ID)
 	if !ok {
+		em.decision.skip(SkipDoublesign, "")
 		return nil
 	}
+	if em.decision != nil {
+		em.decision.Parents = parents
+		em.decision.ParentReasons = reasons
+	}
 
 	// Set parent-dependent fields
 	parentHeaders := make(inter.Events, len(parents))
@@ -316,6 +416,7 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) *in
 		if parentHeaders[i].Creator() == em.config.Validator.ID && i != 0 {
 			// there're 2 heads from me, i.e. due to a fork, chooseParents could have found multiple self-parents
 			em.Periodic.Error(5*time.Second, "I've created a fork, events emitting isn't allowed", "creator", em.config.Validator.ID)
+			em.decision.skip(SkipFork, "")
 			return nil
 		}
 		maxLamport = idx.MaxLamport(maxLamport, parent.Lamport())
@@ -350,8 +451,10 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) *in
 		if err == ErrNotEnoughGasPower {
 			em.Periodic.Warn(time.Second, "Not enough gas power to emit event. Too small stake?",
 				"stake%", 100*float64(em.validators.Get(em.config.Validator.ID))/float64(em.validators.TotalWeight()))
+			em.decision.skip(SkipNotEnoughGasPower, "")
 		} else {
 			em.Log.Warn("Dropped event while emitting", "err", err)
+			em.decision.skip(SkipBuildFailed, err.Error())
 		}
 		return nil
 	}
@@ -364,6 +467,9 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) *in
 
 	// Add txs
 	em.addTxs(mutEvent, sortedTxs)
+	if em.decision != nil {
+		em.decision.Txs = mutEvent.Txs().Len()
+	}
 
 	// Check if event should be emitted
 	// Check only if no txs were added, since check in a case with added txs was performed above
@@ -376,10 +482,16 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) *in
 	// calc Merkle root
 	mutEvent.SetTxHash(hash.Hash(types.DeriveSha(mutEvent.Txs(), new(trie.Trie))))
 
+	// check the event against the signing history before signing
+	if !em.checkSignedHistory(mutEvent.Build()) {
+		return nil
+	}
+
 	// sign
 	bSig, err := em.world.Signer.Sign(em.config.Validator.PubKey, mutEvent.HashToSign().Bytes())
 	if err != nil {
 		em.Periodic.Error(time.Second, "Failed to sign event", "err", err)
+		em.decision.skip(SkipSignFailed, err.Error())
 		return nil
 	}
 	var sig inter.Signature
@@ -392,6 +504,12 @@ func (em *Emitter) createEvent(sortedTxs *types.TransactionsByPriceAndNonce) *in
 	// check
 	if err := em.world.Check(event, parentHeaders); err != nil {
 		em.Periodic.Error(time.Second, "Emitted incorrect event", "err", err)
+		em.decision.skip(SkipIncorrectEvent, err.Error())
+		return nil
+	}
+
+	// record the signed event before it's published
+	if !em.recordSigned(event) {
 		return nil
 	}
 
@@ -406,7 +524,8 @@ func (em *Emitter) idle() bool {
 }
 
 func (em *Emitter) isValidator() bool {
-	return em.config.Validator.ID != 0 && em.validators.Get(em.config.Validator.ID) != 0
+	// validators are unknown until the emitter is initialized, events may be processed before it
+	return em.config.Validator.ID != 0 && em.validators != nil && em.validators.Get(em.config.Validator.ID) != 0
 }
 
 func (em *Emitter) nameEventForDebug(e *inter.EventPayload) {
@@ -0,0 +1,119 @@
+package emitter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type failoverState struct {
+	// active is 1 while the emission is started
+	active uint32
+	// validUntil is the local time (unix nanoseconds) when the emission must be stopped if the lease isn't renewed
+	validUntil int64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// startFailover starts the lease renewal loop. The emission is started while the lease is held,
+// and stopped before the lease expires if it cannot be renewed.
+func (em *Emitter) startFailover() {
+	if em.failover.done != nil {
+		return
+	}
+	// track the sync status and the epoch while standby
+	em.world.Lock()
+	em.syncStatus.lastConnected = time.Now()
+	em.syncStatus.p2pSynced = time.Now()
+	validators, epoch := em.world.GetEpochValidators()
+	em.OnNewEpoch(validators, epoch)
+	em.world.Unlock()
+
+	em.failover.done = make(chan struct{})
+	done := em.failover.done
+	ttl := em.config.Failover.LeaseTTL
+	em.Log.Info("Validator failover is enabled, waiting for the lease", "holder", em.world.Lease.Holder(), "ttl", ttl)
+	em.failover.wg.Add(1)
+	go func() {
+		defer em.failover.wg.Done()
+		ticker := time.NewTicker(ttl / 4)
+		defer ticker.Stop()
+		for {
+			em.renewLease()
+			select {
+			case <-ticker.C:
+			case <-done:
+				em.deactivate("node is stopping")
+				if err := em.world.Lease.Release(); err != nil {
+					em.Log.Warn("Failed to release validator lease", "err", err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+func (em *Emitter) stopFailover() {
+	if em.failover.done == nil {
+		return
+	}
+	close(em.failover.done)
+	em.failover.wg.Wait()
+	em.failover.done = nil
+}
+
+func (em *Emitter) renewLease() {
+	ttl := em.config.Failover.LeaseTTL
+	start := time.Now()
+	held, err := em.world.Lease.Acquire(ttl)
+	switch {
+	case err == nil && held:
+		// stop before the expiration, so the instances never emit simultaneously despite a small clocks drift
+		atomic.StoreInt64(&em.failover.validUntil, start.Add(ttl-ttl/4).UnixNano())
+		if !em.isActive() {
+			em.activate()
+		}
+	case err == nil && !held:
+		em.deactivate("lease is held by another instance")
+	default:
+		em.Periodic.Warn(time.Second, "Failed to renew validator lease", "err", err)
+		if !em.leaseValid() {
+			em.deactivate("lease isn't renewed")
+		}
+	}
+	if !em.isActive() {
+		// the sync status is read by the events processing
+		em.world.Lock()
+		em.trackSyncStatus()
+		em.world.Unlock()
+	}
+}
+
+func (em *Emitter) activate() {
+	em.Log.Info("Validator lease is acquired, starting events emission", "holder", em.world.Lease.Holder())
+	em.world.Lock()
+	em.start()
+	em.world.Unlock()
+	atomic.StoreUint32(&em.failover.active, 1)
+}
+
+func (em *Emitter) deactivate(reason string) {
+	atomic.StoreInt64(&em.failover.validUntil, 0)
+	if !em.isActive() {
+		return
+	}
+	em.Log.Warn("Stopping events emission", "reason", reason)
+	atomic.StoreUint32(&em.failover.active, 0)
+	em.stop()
+}
+
+// isActive returns false for a standby instance.
+func (em *Emitter) isActive() bool {
+	return em.world.Lease == nil || atomic.LoadUint32(&em.failover.active) != 0
+}
+
+// leaseValid returns false if the lease may be expired.
+func (em *Emitter) leaseValid() bool {
+	return em.world.Lease == nil || time.Now().UnixNano() < atomic.LoadInt64(&em.failover.validUntil)
+}
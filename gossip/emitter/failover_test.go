@@ -0,0 +1,111 @@
+package emitter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/inter/pos"
+	notify "github.com/ethereum/go-ethereum/event"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/lease"
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/mock"
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/vecmt"
+)
+
+func TestEmitterFailover(t *testing.T) {
+	require := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Failover.LeaseTTL = 200 * time.Millisecond
+	gValidators := makegenesis.GetFakeValidators(3)
+	vv := pos.NewBuilder()
+	for _, v := range gValidators {
+		vv.Set(v.ID, pos.Weight(1))
+	}
+	validators := vv.Build()
+	cfg.Validator.ID = gValidators[0].ID
+
+	ctrl := gomock.NewController(t)
+	external := mock.NewMockExternal(ctrl)
+	txPool := mock.NewMockTxPool(ctrl)
+	external.EXPECT().Lock().AnyTimes()
+	external.EXPECT().Unlock().AnyTimes()
+	external.EXPECT().DagIndex().Return((*vecmt.Index)(nil)).AnyTimes()
+	external.EXPECT().IsSynced().Return(true).AnyTimes()
+	external.EXPECT().IsBusy().Return(true).AnyTimes()
+	external.EXPECT().PeersNum().Return(int(3)).AnyTimes()
+	external.EXPECT().GetRules().Return(opera.FakeNetRules()).AnyTimes()
+	external.EXPECT().GetEpochValidators().Return(validators, idx.Epoch(1)).AnyTimes()
+	external.EXPECT().GetLastEvent(idx.Epoch(1), cfg.Validator.ID).Return((*hash.Event)(nil)).AnyTimes()
+	external.EXPECT().GetGenesisTime().Return(inter.Timestamp(uint64(time.Now().UnixNano()))).AnyTimes()
+	txPool.EXPECT().SubscribeNewTxsNotify(gomock.Any()).Return(notify.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})).AnyTimes()
+
+	dir, err := ioutil.TempDir("", "emitter-failover")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	shared := lease.NewMemLease()
+	newEmitter := func(holder string) *Emitter {
+		cfg := cfg
+		cfg.PrevEmittedEventFile.Path = filepath.Join(dir, holder)
+		return NewEmitter(cfg, World{
+			External: external,
+			TxPool:   txPool,
+			Lease:    shared.For(holder),
+		})
+	}
+	a, b := newEmitter("a"), newEmitter("b")
+	ttl := cfg.Failover.LeaseTTL
+	active := func(em *Emitter) func() bool {
+		return func() bool {
+			return em.isActive() && em.leaseValid()
+		}
+	}
+
+	a.Start()
+	defer a.Stop()
+	require.Eventually(active(a), ttl, ttl/20)
+	b.Start()
+	defer b.Stop()
+	require.Never(active(b), 2*ttl, ttl/20)
+	// a standby doesn't emit
+	require.Nil(b.EmitEvent())
+
+	// the standby takes over after the active instance stops
+	a.Stop()
+	require.False(a.isActive())
+	require.Eventually(active(b), ttl, ttl/20)
+	emittedEventFile := b.emittedEventFile
+	require.NotNil(emittedEventFile)
+	a.Start()
+	require.Never(active(a), 2*ttl, ttl/20)
+
+	// the lease is taken by another instance, e.g. due to a network partition
+	b.Stop()
+	ok, err := shared.For("c").Acquire(time.Hour)
+	require.NoError(err)
+	require.True(ok)
+	b.Start()
+	require.Never(func() bool {
+		return a.isActive() || b.isActive()
+	}, 2*ttl, ttl/20)
+
+	// the file of the prev emitted event isn't reopened when the emission is restarted
+	a.Stop()
+	require.NoError(shared.For("c").Release())
+	require.Eventually(active(b), ttl, ttl/20)
+	require.Equal(emittedEventFile, b.emittedEventFile)
+}
@@ -14,20 +14,14 @@ import (
 
 // OnNewEpoch should be called after each epoch change, and on startup
 func (em *Emitter) OnNewEpoch(newValidators *pos.Validators, newEpoch idx.Epoch) {
-	em.maxParents = em.config.MaxParents
-	rules := em.world.GetRules()
-	if em.maxParents == 0 {
-		em.maxParents = rules.Dag.MaxParents
-	}
-	if em.maxParents > rules.Dag.MaxParents {
-		em.maxParents = rules.Dag.MaxParents
-	}
+	em.recountMaxParents()
 
 	em.validators, em.epoch = newValidators, newEpoch
 
 	if !em.isValidator() {
 		return
 	}
+	em.rotatePubKey(newEpoch)
 	// update myValidatorID
 	em.prevEmittedAtTime = em.loadPrevEmitTime()
 
@@ -48,6 +42,17 @@ func (em *Emitter) OnNewEpoch(newValidators *pos.Validators, newEpoch idx.Epoch)
 	em.payloadIndexer = ancestor.NewPayloadIndexer(PayloadIndexerSize)
 }
 
+func (em *Emitter) recountMaxParents() {
+	em.maxParents = em.config.MaxParents
+	rules := em.world.GetRules()
+	if em.maxParents == 0 {
+		em.maxParents = rules.Dag.MaxParents
+	}
+	if em.maxParents > rules.Dag.MaxParents {
+		em.maxParents = rules.Dag.MaxParents
+	}
+}
+
 // OnEventConnected tracks new events
 func (em *Emitter) OnEventConnected(e inter.EventPayloadI) {
 	if !em.isValidator() {
@@ -0,0 +1,117 @@
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// staleLockAge is the age of a lock file left by a crashed instance
+const staleLockAge = 10 * time.Second
+
+var errLocked = errors.New("lease file is locked")
+
+// FileLease is a lease file on a storage shared by the instances, e.g. NFS.
+// The file is changed under a lock file created exclusively, and is replaced atomically.
+// The clocks of the instances must be synchronized with a precision much better than the lease TTL.
+type FileLease struct {
+	path   string
+	holder string
+}
+
+type fileLeaseRecord struct {
+	Holder  string `json:"holder"`
+	Expires int64  `json:"expires"`
+}
+
+// NewFileLease creates a lease stored at the path, held under the holder name.
+func NewFileLease(path, holder string) *FileLease {
+	return &FileLease{
+		path:   path,
+		holder: holder,
+	}
+}
+
+// Acquire implements Lease.
+func (l *FileLease) Acquire(ttl time.Duration) (bool, error) {
+	acquired := false
+	err := l.locked(func(rec fileLeaseRecord) (*fileLeaseRecord, error) {
+		now := time.Now()
+		if rec.Holder != l.holder && now.UnixNano() < rec.Expires {
+			return nil, nil
+		}
+		acquired = true
+		return &fileLeaseRecord{
+			Holder:  l.holder,
+			Expires: now.Add(ttl).UnixNano(),
+		}, nil
+	})
+	return acquired, err
+}
+
+// Release implements Lease.
+func (l *FileLease) Release() error {
+	return l.locked(func(rec fileLeaseRecord) (*fileLeaseRecord, error) {
+		if rec.Holder != l.holder {
+			return nil, nil
+		}
+		return &fileLeaseRecord{}, nil
+	})
+}
+
+// Holder implements Lease.
+func (l *FileLease) Holder() string {
+	return l.holder
+}
+
+// locked calls fn under the lock file, and writes the record returned by fn.
+func (l *FileLease) locked(fn func(rec fileLeaseRecord) (*fileLeaseRecord, error)) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return err
+	}
+	lockPath := l.path + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		// remove the lock left by a crashed instance
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			lock, err = os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		}
+		if os.IsExist(err) {
+			return errLocked
+		}
+	}
+	if err != nil {
+		return err
+	}
+	_ = lock.Close()
+	defer os.Remove(lockPath)
+
+	var rec fileLeaseRecord
+	data, err := ioutil.ReadFile(l.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) != 0 {
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("malformed lease file %s: %v", l.path, err)
+		}
+	}
+	upd, err := fn(rec)
+	if err != nil || upd == nil {
+		return err
+	}
+	data, err = json.Marshal(upd)
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
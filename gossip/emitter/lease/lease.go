@@ -0,0 +1,69 @@
+// Package lease implements the leases which allow only one of the instances of a validator to emit events.
+package lease
+
+import (
+	"sync"
+	"time"
+)
+
+// Lease is held by at most one instance at a time.
+type Lease interface {
+	// Acquire takes the lease if it's free or expired, or extends the lease held by this instance.
+	// The lease is held until now+ttl. Returns false if the lease is held by another instance.
+	Acquire(ttl time.Duration) (bool, error)
+	// Release frees the lease if it's held by this instance.
+	Release() error
+	// Holder returns the name of this instance.
+	Holder() string
+}
+
+// MemLease is a lease shared by instances within a process, a stand-in for tests and single-host setups.
+type MemLease struct {
+	holder  string
+	expires time.Time
+	mu      sync.Mutex
+}
+
+// NewMemLease creates a free lease.
+func NewMemLease() *MemLease {
+	return &MemLease{}
+}
+
+type memLeaseHandle struct {
+	lease  *MemLease
+	holder string
+}
+
+// For returns the lease as seen by the instance.
+func (m *MemLease) For(holder string) Lease {
+	return &memLeaseHandle{
+		lease:  m,
+		holder: holder,
+	}
+}
+
+func (h *memLeaseHandle) Acquire(ttl time.Duration) (bool, error) {
+	h.lease.mu.Lock()
+	defer h.lease.mu.Unlock()
+	now := time.Now()
+	if h.lease.holder != h.holder && now.Before(h.lease.expires) {
+		return false, nil
+	}
+	h.lease.holder = h.holder
+	h.lease.expires = now.Add(ttl)
+	return true, nil
+}
+
+func (h *memLeaseHandle) Release() error {
+	h.lease.mu.Lock()
+	defer h.lease.mu.Unlock()
+	if h.lease.holder == h.holder {
+		h.lease.holder = ""
+		h.lease.expires = time.Time{}
+	}
+	return nil
+}
+
+func (h *memLeaseHandle) Holder() string {
+	return h.holder
+}
@@ -0,0 +1,72 @@
+package lease
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testLease(t *testing.T, a, b Lease) {
+	require := require.New(t)
+	const ttl = 50 * time.Millisecond
+
+	ok, err := a.Acquire(ttl)
+	require.NoError(err)
+	require.True(ok)
+	ok, err = b.Acquire(ttl)
+	require.NoError(err)
+	require.False(ok)
+	// renewal
+	ok, err = a.Acquire(ttl)
+	require.NoError(err)
+	require.True(ok)
+	// releasing of a lease held by another instance is a no-op
+	require.NoError(b.Release())
+	ok, err = b.Acquire(ttl)
+	require.NoError(err)
+	require.False(ok)
+
+	// expiration
+	time.Sleep(ttl)
+	ok, err = b.Acquire(ttl)
+	require.NoError(err)
+	require.True(ok)
+	ok, err = a.Acquire(ttl)
+	require.NoError(err)
+	require.False(ok)
+
+	require.NoError(b.Release())
+	ok, err = a.Acquire(ttl)
+	require.NoError(err)
+	require.True(ok)
+}
+
+func TestMemLease(t *testing.T) {
+	l := NewMemLease()
+	testLease(t, l.For("a"), l.For("b"))
+}
+
+func TestFileLease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lease")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "shared", "validator-1.lease")
+
+	a, b := NewFileLease(path, "a"), NewFileLease(path, "b")
+	testLease(t, a, b)
+
+	// the lease is locked by another instance
+	require.NoError(t, ioutil.WriteFile(path+".lock", nil, 0600))
+	_, err = b.Acquire(time.Second)
+	require.Equal(t, errLocked, err)
+	// a stale lock is removed
+	old := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(path+".lock", old, old))
+	ok, err := a.Acquire(time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
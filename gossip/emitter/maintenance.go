@@ -0,0 +1,234 @@
+package emitter
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const maintenanceCheckPeriod = time.Second
+
+// MaintenanceStatus describes the progress of a graceful validator shutdown.
+type MaintenanceStatus struct {
+	// Active is true if events emission is stopped for maintenance
+	Active bool
+	Since  time.Time
+	// LastEvent is the latest self-event of the current epoch, nil if none
+	LastEvent *hash.Event
+	// LastConfirmedEvent is the latest confirmed self-event, nil if none
+	LastConfirmedEvent *hash.Event
+	// Confirmed is true if the latest self-event is confirmed, or won't be confirmed because the epoch is sealed
+	Confirmed bool
+	// Flushed is true if the emitted event file and the stores were flushed after the confirmation
+	Flushed bool
+	// SafeToShutdown is true if the node may be stopped without missing blocks
+	SafeToShutdown bool
+	// Resuming is true if the node was shut down for maintenance, and the emission is waiting for the node to sync
+	Resuming bool
+}
+
+type maintenanceState struct {
+	mu      sync.Mutex
+	active  uint32
+	since   time.Time
+	flushed bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+	// resuming is set on startup after a shutdown for maintenance
+	resuming uint32
+}
+
+// maintenanceMarkerPath returns a path of the file which indicates a shutdown for maintenance
+func (em *Emitter) maintenanceMarkerPath() string {
+	if len(em.config.PrevEmittedEventFile.Path) == 0 {
+		return ""
+	}
+	return em.config.PrevEmittedEventFile.Path + ".maintenance"
+}
+
+func (em *Emitter) loadMaintenanceMarker() {
+	path := em.maintenanceMarkerPath()
+	if len(path) == 0 {
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		atomic.StoreUint32(&em.maintenance.resuming, 1)
+		em.Log.Warn("Validator was shut down for maintenance, events emission resumes after the node is synced")
+	}
+}
+
+func (em *Emitter) removeMaintenanceMarker() {
+	path := em.maintenanceMarkerPath()
+	if len(path) == 0 {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		em.Log.Error("Failed to remove maintenance marker", "file", path, "err", err)
+	}
+}
+
+// checkResumed returns an error if the emission shouldn't resume after a shutdown for maintenance yet.
+func (em *Emitter) checkResumed() error {
+	if atomic.LoadUint32(&em.maintenance.resuming) == 0 {
+		return nil
+	}
+	if !em.world.IsSynced() {
+		return errors.New("waiting for the node to sync after maintenance")
+	}
+	prevEmitted := em.readLastEmittedEventID()
+	if prevEmitted != nil && em.epoch <= prevEmitted.Epoch() && em.world.GetEvent(*prevEmitted) == nil {
+		return fmt.Errorf("waiting for the last self-event %s after maintenance", prevEmitted.String())
+	}
+	em.removeMaintenanceMarker()
+	atomic.StoreUint32(&em.maintenance.resuming, 0)
+	em.Log.Info("Events emission is resumed after maintenance")
+	return nil
+}
+
+func (em *Emitter) inMaintenance() bool {
+	return atomic.LoadUint32(&em.maintenance.active) != 0
+}
+
+// EnterMaintenance stops events emission, and flushes the state once the last self-event is confirmed.
+func (em *Emitter) EnterMaintenance() {
+	m := &em.maintenance
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done != nil {
+		return
+	}
+	atomic.StoreUint32(&m.active, 1)
+	m.since = time.Now()
+	m.flushed = false
+	m.done = make(chan struct{})
+	em.Log.Warn("Validator enters maintenance, events emission is stopped")
+
+	done := m.done
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(maintenanceCheckPeriod)
+		defer ticker.Stop()
+		for {
+			if em.maintain() {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// ExitMaintenance resumes events emission.
+func (em *Emitter) ExitMaintenance() {
+	if !em.stopMaintenance() {
+		return
+	}
+	em.removeMaintenanceMarker()
+	em.Log.Info("Validator exits maintenance, events emission is resumed")
+}
+
+func (em *Emitter) stopMaintenance() bool {
+	m := &em.maintenance
+	m.mu.Lock()
+	done := m.done
+	m.done = nil
+	m.mu.Unlock()
+	if done == nil {
+		return false
+	}
+	close(done)
+	m.wg.Wait()
+	atomic.StoreUint32(&m.active, 0)
+	return true
+}
+
+// MaintenanceStatus returns the progress of the maintenance.
+func (em *Emitter) MaintenanceStatus() MaintenanceStatus {
+	em.world.Lock()
+	defer em.world.Unlock()
+	return em.maintenanceStatus()
+}
+
+// maintenanceStatus should be called under the engine lock
+func (em *Emitter) maintenanceStatus() MaintenanceStatus {
+	m := &em.maintenance
+	m.mu.Lock()
+	s := MaintenanceStatus{
+		Active:   em.inMaintenance(),
+		Since:    m.since,
+		Flushed:  m.flushed,
+		Resuming: atomic.LoadUint32(&m.resuming) != 0,
+	}
+	m.mu.Unlock()
+	if !s.Active {
+		return s
+	}
+
+	s.LastEvent = em.world.GetLastEvent(em.epoch, em.config.Validator.ID)
+	s.LastConfirmedEvent = em.world.GetLastConfirmedEvent(em.config.Validator.ID)
+	switch {
+	case s.LastEvent == nil:
+		// no events to confirm in the current epoch
+		s.Confirmed = true
+	case s.LastEvent.Epoch() < em.epoch:
+		// epoch is sealed
+		s.Confirmed = true
+	case s.LastConfirmedEvent != nil:
+		s.Confirmed = s.LastConfirmedEvent.Epoch() > s.LastEvent.Epoch() ||
+			s.LastConfirmedEvent.Epoch() == s.LastEvent.Epoch() && s.LastConfirmedEvent.Lamport() >= s.LastEvent.Lamport()
+	}
+	s.SafeToShutdown = s.Confirmed && s.Flushed
+	return s
+}
+
+// maintain flushes the state if the last self-event is confirmed, returns true if it's safe to shut down
+func (em *Emitter) maintain() bool {
+	em.world.Lock()
+	defer em.world.Unlock()
+
+	s := em.maintenanceStatus()
+	if !s.Active || s.SafeToShutdown {
+		return true
+	}
+	if !s.Confirmed {
+		em.Periodic.Info(10*time.Second, "Waiting for the last self-event to be confirmed", "event", s.LastEvent)
+		return false
+	}
+	if err := em.flushMaintenance(); err != nil {
+		em.Periodic.Error(10*time.Second, "Failed to flush the state for maintenance", "err", err)
+		return false
+	}
+	em.maintenance.mu.Lock()
+	em.maintenance.flushed = true
+	em.maintenance.mu.Unlock()
+	em.Log.Warn("Validator is safe to shut down", "lastEvent", s.LastEvent, "maintenance", common.PrettyDuration(time.Since(s.Since)))
+	return true
+}
+
+func (em *Emitter) flushMaintenance() error {
+	if em.emittedEventFile != nil {
+		if err := em.emittedEventFile.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := em.world.Flush(); err != nil {
+		return err
+	}
+	if path := em.maintenanceMarkerPath(); len(path) != 0 {
+		if err := ioutil.WriteFile(path, []byte(time.Now().UTC().Format(time.RFC3339)), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package emitter
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/mock"
+	"github.com/Fantom-foundation/go-opera/inter"
+)
+
+func fakeEventID(epoch idx.Epoch, lamport idx.Lamport) hash.Event {
+	me := &inter.MutableEventPayload{}
+	me.SetEpoch(epoch)
+	me.SetLamport(lamport)
+	me.SetCreator(1)
+	return me.Build().ID()
+}
+
+func TestEmitterMaintenance(t *testing.T) {
+	require := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Validator.ID = 1
+	cfg.PrevEmittedEventFile.Path = filepath.Join(t.TempDir(), "last-1")
+	marker := cfg.PrevEmittedEventFile.Path + ".maintenance"
+
+	ctrl := gomock.NewController(t)
+	external := mock.NewMockExternal(ctrl)
+	external.EXPECT().Lock().
+		AnyTimes()
+	external.EXPECT().Unlock().
+		AnyTimes()
+	newEmitter := func() *Emitter {
+		em := NewEmitter(cfg, World{
+			External: external,
+			TxPool:   mock.NewMockTxPool(ctrl),
+			Signer:   mock.NewMockSigner(ctrl),
+			TxSigner: mock.NewMockTxSigner(ctrl),
+		})
+		em.epoch = 2
+		em.emittedEventFile = openEventFile(cfg.PrevEmittedEventFile.Path, false)
+		em.loadMaintenanceMarker()
+		return em
+	}
+
+	lastEvent := fakeEventID(2, 5)
+	var (
+		lastConfirmed   *hash.Event
+		lastConfirmedMu sync.Mutex
+	)
+	setLastConfirmed := func(id *hash.Event) {
+		lastConfirmedMu.Lock()
+		defer lastConfirmedMu.Unlock()
+		lastConfirmed = id
+	}
+	external.EXPECT().GetLastEvent(idx.Epoch(2), idx.ValidatorID(1)).
+		Return(&lastEvent).
+		AnyTimes()
+	external.EXPECT().GetLastConfirmedEvent(idx.ValidatorID(1)).
+		DoAndReturn(func(idx.ValidatorID) *hash.Event {
+			lastConfirmedMu.Lock()
+			defer lastConfirmedMu.Unlock()
+			return lastConfirmed
+		}).
+		AnyTimes()
+
+	em := newEmitter()
+	em.writeLastEmittedEventID(lastEvent)
+	require.False(em.MaintenanceStatus().Active)
+
+	em.EnterMaintenance()
+	defer em.Stop()
+	// the emission is stopped, so txpool isn't requested
+	require.Nil(em.EmitEvent())
+
+	s := em.MaintenanceStatus()
+	require.True(s.Active)
+	require.Equal(&lastEvent, s.LastEvent)
+	require.False(s.Confirmed)
+	require.False(s.SafeToShutdown)
+
+	// an earlier self-event is confirmed
+	prevConfirmed := fakeEventID(2, 3)
+	setLastConfirmed(&prevConfirmed)
+	require.False(em.maintain())
+	require.False(em.MaintenanceStatus().Confirmed)
+
+	// the last self-event is confirmed
+	external.EXPECT().Flush().
+		Return(nil).
+		Times(1)
+	setLastConfirmed(&lastEvent)
+	require.Eventually(func() bool {
+		return em.MaintenanceStatus().SafeToShutdown
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err := os.Stat(marker)
+	require.NoError(err)
+
+	// emission resumes after restart only when the node is synced and the last self-event is known
+	em.Stop()
+	em = newEmitter()
+	require.True(em.MaintenanceStatus().Resuming)
+
+	external.EXPECT().IsSynced().
+		Return(false).
+		Times(1)
+	require.Error(em.checkResumed())
+
+	external.EXPECT().IsSynced().
+		Return(true).
+		AnyTimes()
+	external.EXPECT().GetEvent(lastEvent).
+		Return(nil).
+		Times(1)
+	require.Error(em.checkResumed())
+	_, err = os.Stat(marker)
+	require.NoError(err)
+
+	external.EXPECT().GetEvent(lastEvent).
+		Return(&inter.Event{}).
+		Times(1)
+	require.NoError(em.checkResumed())
+	require.False(em.MaintenanceStatus().Resuming)
+	_, err = os.Stat(marker)
+	require.True(os.IsNotExist(err))
+
+	// exit without shutdown
+	external.EXPECT().Flush().
+		Return(nil).
+		AnyTimes()
+	em.EnterMaintenance()
+	require.True(em.MaintenanceStatus().Active)
+	em.ExitMaintenance()
+	require.False(em.MaintenanceStatus().Active)
+}
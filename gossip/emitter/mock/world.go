@@ -98,7 +98,50 @@ func (mr *MockExternalMockRecorder) DagIndex() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DagIndex", reflect.TypeOf((*MockExternal)(nil).DagIndex))
 }
 
-// GetEpochValidators mocks base method
+// Flush mocks base method
+func (m *MockExternal) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush
+func (mr *MockExternalMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockExternal)(nil).Flush))
+}
+
+// GetEpochPubKey mocks base method
+func (m *MockExternal) GetEpochPubKey(arg0 idx.ValidatorID) (validatorpk.PubKey, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEpochPubKey", arg0)
+	ret0, _ := ret[0].(validatorpk.PubKey)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetEpochPubKey indicates an expected call of GetEpochPubKey
+func (mr *MockExternalMockRecorder) GetEpochPubKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpochPubKey", reflect.TypeOf((*MockExternal)(nil).GetEpochPubKey), arg0)
+}
+
+// GetNextPubKey mocks base method
+func (m *MockExternal) GetNextPubKey(arg0 idx.ValidatorID) (validatorpk.PubKey, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextPubKey", arg0)
+	ret0, _ := ret[0].(validatorpk.PubKey)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetNextPubKey indicates an expected call of GetNextPubKey
+func (mr *MockExternalMockRecorder) GetNextPubKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextPubKey", reflect.TypeOf((*MockExternal)(nil).GetNextPubKey), arg0)
+}
+
 func (m *MockExternal) GetEpochValidators() (*pos.Validators, idx.Epoch) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetEpochValidators")
@@ -169,6 +212,20 @@ func (mr *MockExternalMockRecorder) GetHeads(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHeads", reflect.TypeOf((*MockExternal)(nil).GetHeads), arg0)
 }
 
+// GetLastConfirmedEvent mocks base method
+func (m *MockExternal) GetLastConfirmedEvent(arg0 idx.ValidatorID) *hash.Event {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastConfirmedEvent", arg0)
+	ret0, _ := ret[0].(*hash.Event)
+	return ret0
+}
+
+// GetLastConfirmedEvent indicates an expected call of GetLastConfirmedEvent
+func (mr *MockExternalMockRecorder) GetLastConfirmedEvent(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastConfirmedEvent", reflect.TypeOf((*MockExternal)(nil).GetLastConfirmedEvent), arg0)
+}
+
 // GetLastEvent mocks base method
 func (m *MockExternal) GetLastEvent(arg0 idx.Epoch, arg1 idx.ValidatorID) *hash.Event {
 	m.ctrl.T.Helper()
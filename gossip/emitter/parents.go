@@ -8,41 +8,51 @@ import (
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 )
 
-// buildSearchStrategies returns a strategy for each parent search
-func (em *Emitter) buildSearchStrategies(maxParents idx.Event) []ancestor.SearchStrategy {
+// buildSearchStrategies returns a strategy for each parent search, and the names of strategies
+func (em *Emitter) buildSearchStrategies(maxParents idx.Event) ([]ancestor.SearchStrategy, []string) {
 	strategies := make([]ancestor.SearchStrategy, 0, maxParents)
+	names := make([]string, 0, maxParents)
 	if maxParents == 0 {
-		return strategies
+		return strategies, names
 	}
 	payloadStrategy := em.payloadIndexer.SearchStrategy()
 	for idx.Event(len(strategies)) < 1 {
 		strategies = append(strategies, payloadStrategy)
+		names = append(names, ParentPayload)
 	}
 	randStrategy := ancestor.NewRandomStrategy(nil)
 	for idx.Event(len(strategies)) < maxParents/2 {
 		strategies = append(strategies, randStrategy)
+		names = append(names, ParentRandom)
 	}
 	quorumStrategy := em.quorumIndexer.SearchStrategy()
 	for idx.Event(len(strategies)) < maxParents {
 		strategies = append(strategies, quorumStrategy)
+		names = append(names, ParentQuorum)
 	}
-	return strategies
+	return strategies, names
 }
 
-// chooseParents selects an "optimal" parents set for the validator
-func (em *Emitter) chooseParents(epoch idx.Epoch, myValidatorID idx.ValidatorID) (*hash.Event, hash.Events, bool) {
+// chooseParents selects an "optimal" parents set for the validator.
+// Returns the parents with the names of strategies which have chosen them.
+func (em *Emitter) chooseParents(epoch idx.Epoch, myValidatorID idx.ValidatorID) (*hash.Event, hash.Events, []string, bool) {
 	selfParent := em.world.GetLastEvent(epoch, myValidatorID)
 	heads := em.world.GetHeads(epoch) // events with no descendants
 
 	if selfParent != nil && len(em.world.DagIndex().NoCheaters(selfParent, hash.Events{*selfParent})) == 0 {
 		em.Periodic.Error(time.Second, "Events emitting isn't allowed due to the doublesign", "validator", myValidatorID)
-		return nil, nil, false
+		return nil, nil, nil, false
 	}
 
 	var parents hash.Events
+	var reasons []string
 	if selfParent != nil {
 		parents = hash.Events{*selfParent}
+		reasons = []string{ParentSelf}
 	}
-	parents = ancestor.ChooseParents(parents, heads, em.buildSearchStrategies(em.maxParents-idx.Event(len(parents))))
-	return selfParent, parents, true
+	strategies, names := em.buildSearchStrategies(em.maxParents - idx.Event(len(parents)))
+	parents = ancestor.ChooseParents(parents, heads, strategies)
+	// each strategy chooses one parent, until the heads are exhausted
+	reasons = append(reasons, names[:len(parents)-len(reasons)]...)
+	return selfParent, parents, reasons, true
 }
@@ -0,0 +1,73 @@
+package emitter
+
+import (
+	"bytes"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+)
+
+// pubKeyUpdate is a validator key update logged by the driver
+type pubKeyUpdate struct {
+	pubkey validatorpk.PubKey
+	epoch  idx.Epoch
+}
+
+func samePubKey(a, b validatorpk.PubKey) bool {
+	return bytes.Equal(a.Bytes(), b.Bytes())
+}
+
+// OnValidatorPubkeyUpdate should be called on each UpdateValidatorPubkey driver log.
+// The key is switched by OnNewEpoch at the epoch when the update becomes effective.
+// Safe for concurrent use, the logs may be emitted while a block is processed asynchronously.
+func (em *Emitter) OnValidatorPubkeyUpdate(validatorID idx.ValidatorID, pubkey validatorpk.PubKey, epoch idx.Epoch) {
+	if em.config.Validator.ID == 0 || validatorID != em.config.Validator.ID {
+		return
+	}
+	em.pubKeyUpdateMu.Lock()
+	defer em.pubKeyUpdateMu.Unlock()
+	em.pubKeyUpdate = &pubKeyUpdate{
+		pubkey: pubkey,
+		epoch:  epoch,
+	}
+}
+
+// loadPubKeyUpdate restores the key update which was logged before the emitter start
+func (em *Emitter) loadPubKeyUpdate(epoch idx.Epoch) {
+	if em.config.Validator.NextPubKey.Empty() {
+		return
+	}
+	if pubkey, ok := em.world.GetEpochPubKey(em.config.Validator.ID); ok {
+		em.OnValidatorPubkeyUpdate(em.config.Validator.ID, pubkey, epoch)
+		em.rotatePubKey(epoch)
+	}
+	if pubkey, ok := em.world.GetNextPubKey(em.config.Validator.ID); ok {
+		em.OnValidatorPubkeyUpdate(em.config.Validator.ID, pubkey, epoch+1)
+	}
+}
+
+// rotatePubKey switches to the next validator key at the epoch when the key becomes effective.
+// Should be called under the engine lock.
+func (em *Emitter) rotatePubKey(epoch idx.Epoch) {
+	em.pubKeyUpdateMu.Lock()
+	update := em.pubKeyUpdate
+	if update == nil || update.epoch > epoch {
+		em.pubKeyUpdateMu.Unlock()
+		return
+	}
+	em.pubKeyUpdate = nil
+	em.pubKeyUpdateMu.Unlock()
+
+	if samePubKey(update.pubkey, em.config.Validator.PubKey) {
+		return
+	}
+	next := em.config.Validator.NextPubKey
+	if !next.Empty() && samePubKey(update.pubkey, next) {
+		em.Log.Warn("Validator key is rotated", "epoch", epoch, "prev", em.config.Validator.PubKey.String(), "pubkey", next.String())
+		em.config.Validator.PubKey = next
+		return
+	}
+	em.Log.Error("Validator key of the epoch is unknown", "epoch", epoch, "pubkey", update.pubkey.String(),
+		"current", em.config.Validator.PubKey.String(), "next", next.String())
+}
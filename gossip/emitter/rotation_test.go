@@ -0,0 +1,89 @@
+package emitter
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/mock"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+)
+
+func TestEmitterKeyRotation(t *testing.T) {
+	require := require.New(t)
+
+	prev := validatorpk.PubKey{Type: validatorpk.Types.Secp256k1, Raw: []byte{1}}
+	next := validatorpk.PubKey{Type: validatorpk.Types.Secp256k1, Raw: []byte{2}}
+	unknown := validatorpk.PubKey{Type: validatorpk.Types.Secp256k1, Raw: []byte{3}}
+
+	cfg := DefaultConfig()
+	cfg.Validator.ID = 1
+	cfg.Validator.PubKey = prev
+
+	ctrl := gomock.NewController(t)
+	external := mock.NewMockExternal(ctrl)
+	newEmitter := func() *Emitter {
+		return NewEmitter(cfg, World{
+			External: external,
+			TxPool:   mock.NewMockTxPool(ctrl),
+			Signer:   mock.NewMockSigner(ctrl),
+			TxSigner: mock.NewMockTxSigner(ctrl),
+		})
+	}
+
+	// no rotation is configured
+	em := newEmitter()
+	em.rotatePubKey(1)
+	require.Equal(prev, em.config.Validator.PubKey)
+
+	cfg.Validator.NextPubKey = next
+	em = newEmitter()
+
+	// the new key isn't effective yet
+	em.OnValidatorPubkeyUpdate(1, next, 3)
+	em.rotatePubKey(2)
+	require.Equal(prev, em.config.Validator.PubKey)
+
+	// keys of other validators are ignored
+	em.OnValidatorPubkeyUpdate(2, unknown, 3)
+
+	// the new key is effective
+	em.rotatePubKey(3)
+	require.Equal(next, em.config.Validator.PubKey)
+	em.rotatePubKey(4)
+	require.Equal(next, em.config.Validator.PubKey)
+
+	// unexpected key is ignored
+	em = newEmitter()
+	em.OnValidatorPubkeyUpdate(1, unknown, 3)
+	em.rotatePubKey(3)
+	require.Equal(prev, em.config.Validator.PubKey)
+
+	// the update logged before a restart is restored
+	em = newEmitter()
+	external.EXPECT().GetEpochPubKey(idx.ValidatorID(1)).
+		Return(prev, true).
+		Times(1)
+	external.EXPECT().GetNextPubKey(idx.ValidatorID(1)).
+		Return(next, true).
+		Times(1)
+	em.loadPubKeyUpdate(5)
+	require.Equal(prev, em.config.Validator.PubKey)
+	em.rotatePubKey(6)
+	require.Equal(next, em.config.Validator.PubKey)
+
+	// the update which became effective before a restart is restored
+	em = newEmitter()
+	external.EXPECT().GetEpochPubKey(idx.ValidatorID(1)).
+		Return(next, true).
+		Times(1)
+	external.EXPECT().GetNextPubKey(idx.ValidatorID(1)).
+		Return(next, true).
+		Times(1)
+	em.loadPubKeyUpdate(5)
+	require.Equal(next, em.config.Validator.PubKey)
+	em.rotatePubKey(6)
+	require.Equal(next, em.config.Validator.PubKey)
+}
@@ -0,0 +1,187 @@
+package emitter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+)
+
+// Settings are the emitter parameters which are adjustable at runtime.
+type Settings struct {
+	EmitIntervals EmitIntervals
+
+	MaxTxsPerAddress int
+
+	MaxParents idx.Event
+
+	LimitedTpsThreshold uint64
+	NoTxsThreshold      uint64
+	EmergencyThreshold  uint64
+}
+
+// Settings returns the runtime-adjustable part of the config.
+func (c Config) Settings() Settings {
+	return Settings{
+		EmitIntervals:       c.EmitIntervals,
+		MaxTxsPerAddress:    c.MaxTxsPerAddress,
+		MaxParents:          c.MaxParents,
+		LimitedTpsThreshold: c.LimitedTpsThreshold,
+		NoTxsThreshold:      c.NoTxsThreshold,
+		EmergencyThreshold:  c.EmergencyThreshold,
+	}
+}
+
+func (s Settings) applyTo(c *Config) {
+	c.EmitIntervals = s.EmitIntervals
+	c.MaxTxsPerAddress = s.MaxTxsPerAddress
+	c.MaxParents = s.MaxParents
+	c.LimitedTpsThreshold = s.LimitedTpsThreshold
+	c.NoTxsThreshold = s.NoTxsThreshold
+	c.EmergencyThreshold = s.EmergencyThreshold
+}
+
+// Validate the settings.
+func (s Settings) Validate() error {
+	if s.EmitIntervals.Min <= 0 {
+		return errors.New("min emit interval has to be positive")
+	}
+	if s.EmitIntervals.Max < s.EmitIntervals.Min {
+		return fmt.Errorf("max emit interval %s is less than min emit interval %s", s.EmitIntervals.Max, s.EmitIntervals.Min)
+	}
+	if s.EmitIntervals.Confirming < 0 || s.EmitIntervals.ParallelInstanceProtection < 0 || s.EmitIntervals.DoublesignProtection < 0 {
+		return errors.New("emit intervals cannot be negative")
+	}
+	if s.MaxTxsPerAddress <= 0 {
+		return errors.New("max txs per address has to be positive")
+	}
+	if s.EmergencyThreshold > s.NoTxsThreshold {
+		return fmt.Errorf("emergency threshold %d is greater than no-txs threshold %d", s.EmergencyThreshold, s.NoTxsThreshold)
+	}
+	return nil
+}
+
+// loadSettings reads the settings overrides, returns nil if there are no overrides.
+func loadSettings(path string) (*Settings, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// saveSettings replaces the settings overrides atomically.
+func saveSettings(path string, s Settings) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Settings returns the current runtime settings.
+func (em *Emitter) Settings() Settings {
+	em.settingsMu.RLock()
+	defer em.settingsMu.RUnlock()
+	return em.settings
+}
+
+// UpdateSettings atomically changes the runtime settings, and persists them as overrides of the config.
+func (em *Emitter) UpdateSettings(update func(*Settings)) (Settings, error) {
+	em.world.Lock()
+	defer em.world.Unlock()
+
+	s := em.Settings()
+	update(&s)
+	if err := s.Validate(); err != nil {
+		return em.Settings(), err
+	}
+	if len(em.config.OverridesPath) != 0 {
+		if err := saveSettings(em.config.OverridesPath, s); err != nil {
+			return em.Settings(), err
+		}
+	}
+	em.applySettings(s)
+	em.Log.Info("Emitter settings are updated", "settings", fmt.Sprintf("%+v", s))
+	return s, nil
+}
+
+// ResetSettings drops the overrides and restores the settings from the config.
+func (em *Emitter) ResetSettings() (Settings, error) {
+	em.world.Lock()
+	defer em.world.Unlock()
+
+	if len(em.config.OverridesPath) != 0 {
+		if err := os.Remove(em.config.OverridesPath); err != nil && !os.IsNotExist(err) {
+			return em.Settings(), err
+		}
+	}
+	em.applySettings(em.configSettings)
+	em.Log.Info("Emitter settings are reset to the config")
+	return em.configSettings, nil
+}
+
+// applySettings should be called under the engine lock
+func (em *Emitter) applySettings(s Settings) {
+	em.settingsMu.Lock()
+	defer em.settingsMu.Unlock()
+
+	em.settings = s
+	s.applyTo(&em.config)
+	// Randomize event time to decrease chance of 2 parallel instances emitting event at the same time
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	em.config.EmitIntervals = em.config.EmitIntervals.RandomizeEmitTime(r)
+	em.intervals = em.config.EmitIntervals
+
+	if em.validators == nil {
+		// not initialized yet
+		return
+	}
+	em.recountMaxParents()
+	if em.isValidator() {
+		em.recountValidators(em.validators)
+	}
+}
+
+// Pause events emission until Resume is called. The pause isn't persisted.
+func (em *Emitter) Pause() {
+	if atomic.CompareAndSwapUint32(&em.paused, 0, 1) {
+		em.Log.Warn("Events emission is paused")
+	}
+}
+
+// Resume events emission.
+func (em *Emitter) Resume() {
+	if atomic.CompareAndSwapUint32(&em.paused, 1, 0) {
+		em.Log.Info("Events emission is resumed")
+	}
+}
+
+// Paused returns true if events emission is paused.
+func (em *Emitter) Paused() bool {
+	return atomic.LoadUint32(&em.paused) != 0
+}
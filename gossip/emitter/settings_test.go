@@ -0,0 +1,76 @@
+package emitter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/mock"
+)
+
+func TestEmitterSettings(t *testing.T) {
+	require := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Validator.ID = 1
+	cfg.OverridesPath = filepath.Join(t.TempDir(), "emitter", "overrides.json")
+
+	ctrl := gomock.NewController(t)
+	external := mock.NewMockExternal(ctrl)
+	external.EXPECT().Lock().
+		AnyTimes()
+	external.EXPECT().Unlock().
+		AnyTimes()
+	newEmitter := func() *Emitter {
+		return NewEmitter(cfg, World{
+			External: external,
+			TxPool:   mock.NewMockTxPool(ctrl),
+			Signer:   mock.NewMockSigner(ctrl),
+			TxSigner: mock.NewMockTxSigner(ctrl),
+		})
+	}
+
+	em := newEmitter()
+	require.Equal(cfg.Settings(), em.Settings())
+
+	// invalid settings are refused
+	_, err := em.UpdateSettings(func(s *Settings) {
+		s.EmitIntervals.Max = s.EmitIntervals.Min - 1
+	})
+	require.Error(err)
+	require.Equal(cfg.Settings(), em.Settings())
+	_, err = os.Stat(cfg.OverridesPath)
+	require.True(os.IsNotExist(err))
+
+	// the changes are applied and persisted
+	s, err := em.UpdateSettings(func(s *Settings) {
+		s.EmitIntervals.Min = time.Second
+		s.MaxTxsPerAddress = 3
+	})
+	require.NoError(err)
+	require.Equal(time.Second, s.EmitIntervals.Min)
+	require.Equal(time.Second, em.intervals.Min)
+	require.Equal(3, em.config.MaxTxsPerAddress)
+	require.Equal(s, em.Settings())
+	require.Equal(s, newEmitter().Settings())
+
+	// reset to the config
+	s, err = em.ResetSettings()
+	require.NoError(err)
+	require.Equal(cfg.Settings(), s)
+	require.Equal(cfg.Settings(), em.Settings())
+	require.Equal(cfg.Settings(), newEmitter().Settings())
+
+	// pause isn't persisted
+	require.False(em.Paused())
+	em.Pause()
+	require.True(em.Paused())
+	require.Nil(em.EmitEvent())
+	require.False(newEmitter().Paused())
+	em.Resume()
+	require.False(em.Paused())
+}
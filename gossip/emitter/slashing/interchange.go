@@ -0,0 +1,96 @@
+package slashing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+)
+
+// InterchangeVersion is the version of the portable history format.
+const InterchangeVersion = 1
+
+// Interchange is the portable format of the signing history of a validator.
+type Interchange struct {
+	Version     uint64             `json:"version"`
+	ValidatorID idx.ValidatorID    `json:"validatorID"`
+	PubKey      validatorpk.PubKey `json:"pubkey"`
+	Events      []InterchangeEvent `json:"signedEvents"`
+}
+
+// InterchangeEvent is a signed event in the portable format.
+type InterchangeEvent struct {
+	Epoch   idx.Epoch   `json:"epoch"`
+	Seq     idx.Event   `json:"seq"`
+	Lamport idx.Lamport `json:"lamport"`
+	ID      hash.Hash   `json:"id"`
+}
+
+// NewInterchange makes the portable history of the records, ordered by epoch and seq.
+func NewInterchange(validatorID idx.ValidatorID, pubkey validatorpk.PubKey, records []Record) *Interchange {
+	sorted := append([]Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Epoch != sorted[j].Epoch {
+			return sorted[i].Epoch < sorted[j].Epoch
+		}
+		return sorted[i].Seq < sorted[j].Seq
+	})
+	events := make([]InterchangeEvent, len(sorted))
+	for i, r := range sorted {
+		events[i] = InterchangeEvent{
+			Epoch:   r.Epoch,
+			Seq:     r.Seq,
+			Lamport: r.Lamport,
+			ID:      hash.Hash(r.ID),
+		}
+	}
+	return &Interchange{
+		Version:     InterchangeVersion,
+		ValidatorID: validatorID,
+		PubKey:      pubkey,
+		Events:      events,
+	}
+}
+
+// Records returns the signed events of the portable history.
+func (h *Interchange) Records() []Record {
+	records := make([]Record, len(h.Events))
+	for i, e := range h.Events {
+		records[i] = Record{
+			Epoch:   e.Epoch,
+			Seq:     e.Seq,
+			Lamport: e.Lamport,
+			ID:      hash.Event(e.ID),
+		}
+	}
+	return records
+}
+
+// ReadInterchange decodes and validates the portable history.
+func ReadInterchange(r io.Reader) (*Interchange, error) {
+	h := &Interchange{}
+	if err := json.NewDecoder(r).Decode(h); err != nil {
+		return nil, err
+	}
+	if h.Version != InterchangeVersion {
+		return nil, fmt.Errorf("unsupported slashing protection history version %d", h.Version)
+	}
+	for _, r := range h.Records() {
+		if r.ID.Epoch() != r.Epoch || r.ID.Lamport() != r.Lamport {
+			return nil, fmt.Errorf("malformed event %s: epoch=%d lamport=%d", r.ID.String(), r.Epoch, r.Lamport)
+		}
+	}
+	return h, nil
+}
+
+// Write encodes the portable history.
+func (h *Interchange) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(h)
+}
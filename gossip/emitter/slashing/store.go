@@ -0,0 +1,244 @@
+// Package slashing keeps the history of events signed by a validator,
+// and refuses to sign events which conflict with the history.
+package slashing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/dag"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+)
+
+var (
+	// ErrConflict is returned if the event conflicts with the signing history.
+	ErrConflict = errors.New("conflicts with the slashing protection history")
+
+	fileMagic = []byte("opera-slashing-protection-v1\n")
+)
+
+const recordSize = 4 + 4 + 4 + 32
+
+// Record is a signed event.
+type Record struct {
+	Epoch   idx.Epoch
+	Seq     idx.Event
+	Lamport idx.Lamport
+	ID      hash.Event
+}
+
+// RecordOf returns the record of the event.
+func RecordOf(e dag.Event) Record {
+	return Record{
+		Epoch:   e.Epoch(),
+		Seq:     e.Seq(),
+		Lamport: e.Lamport(),
+		ID:      e.ID(),
+	}
+}
+
+func (r Record) bytes() []byte {
+	b := make([]byte, 0, recordSize)
+	b = append(b, r.Epoch.Bytes()...)
+	b = append(b, r.Seq.Bytes()...)
+	b = append(b, r.Lamport.Bytes()...)
+	return append(b, r.ID.Bytes()...)
+}
+
+func recordFromBytes(b []byte) Record {
+	return Record{
+		Epoch:   idx.BytesToEpoch(b[0:4]),
+		Seq:     idx.BytesToEvent(b[4:8]),
+		Lamport: idx.BytesToLamport(b[8:12]),
+		ID:      hash.BytesToEvent(b[12:44]),
+	}
+}
+
+// Store is an append-only file of signed events.
+// Every record is synced to disk before the signed event is published.
+type Store struct {
+	path string
+	f    *os.File
+
+	// highest is the record with the highest seq in each epoch
+	highest   map[idx.Epoch]Record
+	lastEpoch idx.Epoch
+
+	mu sync.Mutex
+}
+
+// Open opens or creates the history file.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_SYNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		path:    path,
+		f:       f,
+		highest: make(map[idx.Epoch]Record),
+	}
+	err = s.load()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("slashing protection history %s: %v", path, err)
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		_, err = s.f.Write(fileMagic)
+		return err
+	}
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(s.f, magic); err != nil || !bytes.Equal(magic, fileMagic) {
+		return errors.New("not a slashing protection history file")
+	}
+	size := info.Size() - int64(len(fileMagic))
+	if torn := size % recordSize; torn != 0 {
+		// the record wasn't synced, so the event wasn't published
+		size -= torn
+		if err := s.f.Truncate(int64(len(fileMagic)) + size); err != nil {
+			return err
+		}
+	}
+	return s.forEach(func(r Record) {
+		s.add(r)
+	})
+}
+
+func (s *Store) forEach(fn func(r Record)) error {
+	if _, err := s.f.Seek(int64(len(fileMagic)), io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, recordSize)
+	for {
+		_, err := io.ReadFull(s.f, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fn(recordFromBytes(buf))
+	}
+	_, err := s.f.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (s *Store) add(r Record) {
+	if prev, ok := s.highest[r.Epoch]; !ok || r.Seq > prev.Seq || r.Seq == prev.Seq && r.Lamport > prev.Lamport {
+		s.highest[r.Epoch] = r
+	}
+	if r.Epoch > s.lastEpoch {
+		s.lastEpoch = r.Epoch
+	}
+}
+
+// Check returns ErrConflict if signing of the event may cause a doublesign:
+//   - the history has a later epoch;
+//   - the history has an event with the same or higher seq in the epoch, or with the same or higher lamport;
+//   - the previous seq of the epoch is in the history, but it isn't the self-parent.
+//
+// Signing of the events which are already in the history is allowed.
+func (s *Store) Check(e dag.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.Epoch() < s.lastEpoch {
+		return fmt.Errorf("event of epoch %d %w, last signed epoch is %d", e.Epoch(), ErrConflict, s.lastEpoch)
+	}
+	prev, ok := s.highest[e.Epoch()]
+	if !ok {
+		return nil
+	}
+	if prev.ID == e.ID() {
+		return nil
+	}
+	if e.Seq() <= prev.Seq || e.Lamport() <= prev.Lamport {
+		return fmt.Errorf("event seq=%d lamport=%d %w, last signed event %s has seq=%d lamport=%d",
+			e.Seq(), e.Lamport(), ErrConflict, prev.ID.String(), prev.Seq, prev.Lamport)
+	}
+	if e.Seq() == prev.Seq+1 && (e.SelfParent() == nil || *e.SelfParent() != prev.ID) {
+		return fmt.Errorf("event seq=%d %w, self-parent isn't the last signed event %s", e.Seq(), ErrConflict, prev.ID.String())
+	}
+	return nil
+}
+
+// Add writes the signed event into the history.
+func (s *Store) Add(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.f.Write(r.bytes()); err != nil {
+		return err
+	}
+	s.add(r)
+	return nil
+}
+
+// Import merges the records into the history, returns the number of new records.
+func (s *Store) Import(records []Record) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	known := make(map[Record]bool)
+	if err := s.forEach(func(r Record) {
+		known[r] = true
+	}); err != nil {
+		return 0, err
+	}
+	buf := bytes.Buffer{}
+	imported := 0
+	for _, r := range records {
+		if known[r] {
+			continue
+		}
+		known[r] = true
+		buf.Write(r.bytes())
+		imported++
+	}
+	if _, err := s.f.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	for _, r := range records {
+		s.add(r)
+	}
+	return imported, nil
+}
+
+// Records returns all the records of the history.
+func (s *Store) Records() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []Record
+	err := s.forEach(func(r Record) {
+		records = append(records, r)
+	})
+	return records, err
+}
+
+// Path returns the path of the history file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Close closes the history file.
+func (s *Store) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,135 @@
+package slashing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+)
+
+func fakeEvent(epoch idx.Epoch, seq idx.Event, lamport idx.Lamport, selfParent *inter.EventPayload, extra string) *inter.EventPayload {
+	me := &inter.MutableEventPayload{}
+	me.SetEpoch(epoch)
+	me.SetSeq(seq)
+	me.SetLamport(lamport)
+	me.SetCreator(1)
+	me.SetExtra([]byte(extra))
+	if selfParent != nil {
+		me.SetParents(hash.Events{selfParent.ID()})
+	}
+	return me.Build()
+}
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "slashing")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "emitter", "slashing-1")
+
+	s, err := Open(path)
+	require.NoError(err)
+
+	e1 := fakeEvent(2, 1, 1, nil, "")
+	require.NoError(s.Check(e1))
+	require.NoError(s.Add(RecordOf(e1)))
+	e2 := fakeEvent(2, 2, 5, e1, "")
+	require.NoError(s.Check(e2))
+	require.NoError(s.Add(RecordOf(e2)))
+
+	// the same event may be signed again
+	require.NoError(s.Check(e2))
+	// forks
+	require.ErrorIs(s.Check(fakeEvent(2, 2, 5, e1, "fork")), ErrConflict)
+	require.ErrorIs(s.Check(fakeEvent(2, 1, 1, nil, "fork")), ErrConflict)
+	// lamport isn't above the last event
+	require.ErrorIs(s.Check(fakeEvent(2, 3, 5, e2, "")), ErrConflict)
+	// self-parent isn't the last signed event
+	require.ErrorIs(s.Check(fakeEvent(2, 3, 6, e1, "")), ErrConflict)
+	// previous epoch
+	require.ErrorIs(s.Check(fakeEvent(1, 10, 10, nil, "")), ErrConflict)
+	// events created by another instance
+	require.NoError(s.Check(fakeEvent(2, 4, 7, fakeEvent(2, 3, 6, e2, ""), "")))
+	// next epoch
+	e3 := fakeEvent(3, 1, 1, nil, "")
+	require.NoError(s.Check(e3))
+	require.NoError(s.Add(RecordOf(e3)))
+	require.ErrorIs(s.Check(fakeEvent(2, 3, 6, e2, "")), ErrConflict)
+	require.NoError(s.Close())
+
+	// the history is restored after restart, a torn record is dropped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(err)
+	_, err = f.Write([]byte{1, 2, 3})
+	require.NoError(err)
+	require.NoError(f.Close())
+	s, err = Open(path)
+	require.NoError(err)
+	records, err := s.Records()
+	require.NoError(err)
+	require.Equal([]Record{RecordOf(e1), RecordOf(e2), RecordOf(e3)}, records)
+	require.ErrorIs(s.Check(fakeEvent(3, 1, 1, nil, "fork")), ErrConflict)
+	require.NoError(s.Close())
+
+	require.NoError(ioutil.WriteFile(path+"x", []byte("garbage"), 0600))
+	_, err = Open(path + "x")
+	require.Error(err)
+}
+
+func TestInterchange(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "slashing")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	e1 := fakeEvent(2, 1, 1, nil, "")
+	e2 := fakeEvent(2, 2, 5, e1, "")
+	e3 := fakeEvent(3, 1, 1, nil, "")
+
+	src, err := Open(filepath.Join(dir, "src"))
+	require.NoError(err)
+	defer src.Close()
+	for _, e := range []*inter.EventPayload{e3, e1, e2} {
+		require.NoError(src.Add(RecordOf(e)))
+	}
+	records, err := src.Records()
+	require.NoError(err)
+
+	pubkey, _ := validatorpk.FromString("0xc0045ea4ce3ab0748574f0290dadcb45545aff82d8baa72e5b4c84a19d2e1f16fb3dc487430b4189ded650a94148e57a60ca8cbf4da414dbfd3b072f0a5b9a746235")
+	buf := bytes.Buffer{}
+	require.NoError(NewInterchange(1, pubkey, records).Write(&buf))
+	h, err := ReadInterchange(&buf)
+	require.NoError(err)
+	require.Equal(idx.ValidatorID(1), h.ValidatorID)
+	require.Equal(pubkey.String(), h.PubKey.String())
+	require.Equal([]Record{RecordOf(e1), RecordOf(e2), RecordOf(e3)}, h.Records())
+
+	// import into the history of a backup machine
+	dst, err := Open(filepath.Join(dir, "dst"))
+	require.NoError(err)
+	defer dst.Close()
+	require.NoError(dst.Add(RecordOf(e1)))
+	n, err := dst.Import(h.Records())
+	require.NoError(err)
+	require.Equal(2, n)
+	require.ErrorIs(dst.Check(fakeEvent(2, 2, 5, e1, "fork")), ErrConflict)
+	require.ErrorIs(dst.Check(fakeEvent(3, 1, 1, nil, "fork")), ErrConflict)
+	n, err = dst.Import(h.Records())
+	require.NoError(err)
+	require.Equal(0, n)
+
+	// malformed event ID
+	h.Events[0].Epoch++
+	buf.Reset()
+	require.NoError(h.Write(&buf))
+	_, err = ReadInterchange(&buf)
+	require.Error(err)
+}
@@ -0,0 +1,44 @@
+package emitter
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/slashing"
+	"github.com/Fantom-foundation/go-opera/inter"
+)
+
+func openSlashingProtection(path string) *slashing.Store {
+	s, err := slashing.Open(path)
+	if err != nil {
+		log.Crit("Failed to open slashing protection history", "file", path, "err", err)
+	}
+	return s
+}
+
+// checkSignedHistory returns false if signing of the event may cause a doublesign.
+func (em *Emitter) checkSignedHistory(e *inter.EventPayload) bool {
+	if em.signedHistory == nil {
+		return true
+	}
+	if err := em.signedHistory.Check(e); err != nil {
+		em.Periodic.Error(5*time.Second, "Slashing protection refused to sign event", "err", err)
+		em.decision.skip(SkipSlashingProtection, err.Error())
+		return false
+	}
+	return true
+}
+
+// recordSigned writes the event into the signing history before it's published.
+func (em *Emitter) recordSigned(e *inter.EventPayload) bool {
+	if em.signedHistory == nil {
+		return true
+	}
+	if err := em.signedHistory.Add(slashing.RecordOf(e)); err != nil {
+		em.Log.Error("Failed to write slashing protection history", "file", em.signedHistory.Path(), "err", err)
+		em.decision.skip(SkipSlashingProtection, err.Error())
+		return false
+	}
+	return true
+}
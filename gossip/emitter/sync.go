@@ -21,9 +21,24 @@ type syncStatus struct {
 	becameValidator           time.Time
 }
 
+func (em *Emitter) trackSyncStatus() {
+	if em.world.PeersNum() == 0 {
+		// connected time ~= last time when it's true that "not connected yet"
+		em.syncStatus.lastConnected = time.Now()
+	}
+	if !em.world.IsSynced() {
+		// synced time ~= last time when it's true that "not synced yet"
+		em.syncStatus.p2pSynced = time.Now()
+	}
+}
+
 func (em *Emitter) onNewExternalEvent(e inter.EventPayloadI) {
 	em.syncStatus.externalSelfEventDetected = time.Now()
 	em.syncStatus.externalSelfEventCreated = e.CreationTime().Time()
+	if !em.isActive() {
+		// a standby instance receives the events of the active instance
+		return
+	}
 	status := em.currentSyncStatus()
 	if doublesign.DetectParallelInstance(status, em.config.EmitIntervals.ParallelInstanceProtection) {
 		passedSinceEvent := status.Since(status.ExternalSelfEventCreated)
@@ -59,10 +74,15 @@ func (em *Emitter) currentSyncStatus() doublesign.SyncStatus {
 }
 
 func (em *Emitter) isSyncedToEmit() (time.Duration, error) {
-	if em.intervals.DoublesignProtection == 0 {
+	threshold := em.intervals.DoublesignProtection
+	if em.world.Lease != nil {
+		// the lease guarantees a single active instance, so wait only for the events of the previous active instance
+		threshold = em.config.Failover.LeaseTTL
+	}
+	if threshold == 0 {
 		return 0, nil // protection disabled
 	}
-	return doublesign.SyncedToEmit(em.currentSyncStatus(), em.intervals.DoublesignProtection)
+	return doublesign.SyncedToEmit(em.currentSyncStatus(), threshold)
 }
 
 func (em *Emitter) logSyncStatus(wait time.Duration, syncErr error) bool {
@@ -53,6 +53,7 @@ func (em *Emitter) maxGasPowerToUse(e *inter.MutableEventPayload) uint64 {
 
 		gasPowerLeft := e.GasPowerLeft().Min() + estimatedAlloc
 		if gasPowerLeft < downThreshold {
+			em.decision.hitThreshold(ThresholdLimitedTps)
 			return 0
 		}
 		newGasPowerLeft := uint64(0)
@@ -78,6 +79,7 @@ func (em *Emitter) maxGasPowerToUse(e *inter.MutableEventPayload) uint64 {
 
 		smoothGasToUse := healthyPart + trespassingPart/2
 		if maxGasToUse > smoothGasToUse {
+			em.decision.hitThreshold(ThresholdLimitedTps)
 			maxGasToUse = smoothGasToUse
 		}
 	}
@@ -85,9 +87,11 @@ func (em *Emitter) maxGasPowerToUse(e *inter.MutableEventPayload) uint64 {
 	{
 		maxPendingGas := max64(rules.Blocks.MaxBlockGas*3/5, rules.Economy.Gas.MaxEventGas+rules.Economy.Gas.EventGas*uint64(em.validators.Len()))
 		if maxPendingGas <= em.pendingGas {
+			em.decision.hitThreshold(ThresholdPendingGas)
 			return 0
 		}
 		if maxPendingGas < em.pendingGas+maxGasToUse {
+			em.decision.hitThreshold(ThresholdPendingGas)
 			maxGasToUse = maxPendingGas - em.pendingGas
 		}
 	}
@@ -95,8 +99,10 @@ func (em *Emitter) maxGasPowerToUse(e *inter.MutableEventPayload) uint64 {
 	{
 		threshold := em.config.NoTxsThreshold
 		if e.GasPowerLeft().Min() <= threshold {
+			em.decision.hitThreshold(ThresholdNoTxs)
 			return 0
 		} else if e.GasPowerLeft().Min() < threshold+maxGasToUse {
+			em.decision.hitThreshold(ThresholdNoTxs)
 			maxGasToUse = e.GasPowerLeft().Min() - threshold
 		}
 	}
@@ -150,13 +156,16 @@ func (em *Emitter) isMyTxTurn(txHash common.Hash, sender common.Address, account
 	return validators.GetID(idx.Validator(rounds[roundIndex])) == me
 }
 
-func (em *Emitter) addTxs(e *inter.MutableEventPayload, sorted *types.TransactionsByPriceAndNonce) {
+func (em *Emitter) addTxs(e *inter.MutableEventPayload, sorted TxIterator) {
 	maxGasUsed := em.maxGasPowerToUse(e)
+	if em.decision != nil {
+		em.decision.MaxGasToUse = maxGasUsed
+	}
 	if maxGasUsed <= e.GasPowerUsed() {
 		return
 	}
 
-	// sort transactions by price and nonce
+	// transactions are sorted by the TxSelector
 	rules := em.world.GetRules()
 	softGasPriceLimit := em.world.GetRecommendedGasPrice()
 	for tx := sorted.Peek(); tx != nil; tx = sorted.Peek() {
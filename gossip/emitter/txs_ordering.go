@@ -0,0 +1,274 @@
+package emitter
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Names of the transactions orderings
+const (
+	// OrderByPrice originates the most expensive transactions first
+	OrderByPrice = "price"
+	// OrderByArrival originates the transactions in the order they were seen, with a quota for each sender
+	OrderByArrival = "arrival"
+)
+
+// TxIterator iterates over the pending transactions in the order of origination.
+// Transactions of a sender are always returned in the order of nonces.
+type TxIterator interface {
+	// Peek returns the next transaction, or nil if there are no transactions left
+	Peek() *types.Transaction
+	// Shift replaces the current transaction with the next transaction of the same sender
+	Shift()
+	// Pop removes the current transaction along with the rest transactions of the same sender
+	Pop()
+	// Copy returns an independent copy of the iterator
+	Copy() TxIterator
+}
+
+// TxSelector decides the order in which the pending transactions get originated.
+type TxSelector interface {
+	// Select returns an iterator over the pending transactions, which are sorted by nonce for each sender
+	Select(pending map[common.Address]types.Transactions) TxIterator
+}
+
+// TxPolicy is the configuration of transactions ordering.
+type TxPolicy struct {
+	// Ordering is either OrderByPrice or OrderByArrival
+	Ordering string
+	// SenderQuota is the max number of transactions of a sender in an event for OrderByArrival, unlimited if 0
+	SenderQuota int
+	// PrioritySenders are the senders whose transactions are originated before any others
+	PrioritySenders []common.Address
+}
+
+// Validate the policy.
+func (p TxPolicy) Validate() error {
+	switch p.Ordering {
+	case "", OrderByPrice, OrderByArrival:
+	default:
+		return fmt.Errorf("unknown transactions ordering %q", p.Ordering)
+	}
+	if p.SenderQuota < 0 {
+		return fmt.Errorf("negative sender quota %d", p.SenderQuota)
+	}
+	return nil
+}
+
+// NewTxSelector creates the selector of the policy.
+// arrivalTime returns the time when a transaction was seen for the first time.
+func NewTxSelector(p TxPolicy, signer types.Signer, arrivalTime func(common.Hash) time.Time) (TxSelector, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	var selector TxSelector
+	switch p.Ordering {
+	case OrderByArrival:
+		selector = &ArrivalTxSelector{
+			ArrivalTime: arrivalTime,
+			SenderQuota: p.SenderQuota,
+		}
+	default:
+		selector = &PriceTxSelector{
+			Signer: signer,
+		}
+	}
+	if len(p.PrioritySenders) != 0 {
+		selector = NewPriorityTxSelector(selector, p.PrioritySenders)
+	}
+	return selector, nil
+}
+
+// PriceTxSelector orders transactions by gas price and nonce.
+type PriceTxSelector struct {
+	Signer types.Signer
+}
+
+type priceTxIterator struct {
+	*types.TransactionsByPriceAndNonce
+}
+
+func (it priceTxIterator) Copy() TxIterator {
+	return priceTxIterator{it.TransactionsByPriceAndNonce.Copy()}
+}
+
+// Select implements TxSelector.
+func (s *PriceTxSelector) Select(pending map[common.Address]types.Transactions) TxIterator {
+	return priceTxIterator{types.NewTransactionsByPriceAndNonce(s.Signer, pending)}
+}
+
+// ArrivalTxSelector orders transactions by the time of arrival (FIFO), and limits the number
+// of transactions of a sender, so a single sender cannot fill an event.
+type ArrivalTxSelector struct {
+	ArrivalTime func(common.Hash) time.Time
+	SenderQuota int
+}
+
+type arrivalHead struct {
+	txs     types.Transactions
+	arrival time.Time
+	taken   int
+}
+
+// arrivalTxIterator is a heap of the senders' next transactions, ordered by time of arrival
+type arrivalTxIterator struct {
+	heads       []*arrivalHead
+	arrivalTime func(common.Hash) time.Time
+	quota       int
+}
+
+func (it *arrivalTxIterator) Len() int { return len(it.heads) }
+
+func (it *arrivalTxIterator) Less(i, j int) bool {
+	a, b := it.heads[i], it.heads[j]
+	if !a.arrival.Equal(b.arrival) {
+		return a.arrival.Before(b.arrival)
+	}
+	ha, hb := a.txs[0].Hash(), b.txs[0].Hash()
+	return bytes.Compare(ha[:], hb[:]) < 0
+}
+
+func (it *arrivalTxIterator) Swap(i, j int) { it.heads[i], it.heads[j] = it.heads[j], it.heads[i] }
+
+func (it *arrivalTxIterator) Push(x interface{}) { it.heads = append(it.heads, x.(*arrivalHead)) }
+
+func (it *arrivalTxIterator) Pop() interface{} {
+	old := it.heads
+	n := len(old)
+	x := old[n-1]
+	it.heads = old[:n-1]
+	return x
+}
+
+// Select implements TxSelector.
+func (s *ArrivalTxSelector) Select(pending map[common.Address]types.Transactions) TxIterator {
+	it := &arrivalTxIterator{
+		heads:       make([]*arrivalHead, 0, len(pending)),
+		arrivalTime: s.ArrivalTime,
+		quota:       s.SenderQuota,
+	}
+	for _, txs := range pending {
+		if len(txs) == 0 {
+			continue
+		}
+		it.heads = append(it.heads, &arrivalHead{
+			txs:     txs,
+			arrival: s.ArrivalTime(txs[0].Hash()),
+		})
+	}
+	heap.Init(it)
+	return arrivalTxs{it}
+}
+
+// arrivalTxs hides the heap.Interface methods behind TxIterator
+type arrivalTxs struct {
+	it *arrivalTxIterator
+}
+
+func (t arrivalTxs) Peek() *types.Transaction {
+	if len(t.it.heads) == 0 {
+		return nil
+	}
+	return t.it.heads[0].txs[0]
+}
+
+func (t arrivalTxs) Shift() {
+	head := t.it.heads[0]
+	head.taken++
+	if len(head.txs) > 1 && (t.it.quota == 0 || head.taken < t.it.quota) {
+		head.txs = head.txs[1:]
+		head.arrival = t.it.arrivalTime(head.txs[0].Hash())
+		heap.Fix(t.it, 0)
+		return
+	}
+	heap.Pop(t.it)
+}
+
+func (t arrivalTxs) Pop() {
+	heap.Pop(t.it)
+}
+
+func (t arrivalTxs) Copy() TxIterator {
+	cp := &arrivalTxIterator{
+		heads:       make([]*arrivalHead, len(t.it.heads)),
+		arrivalTime: t.it.arrivalTime,
+		quota:       t.it.quota,
+	}
+	for i, head := range t.it.heads {
+		h := *head
+		cp.heads[i] = &h
+	}
+	return arrivalTxs{cp}
+}
+
+// PriorityTxSelector originates transactions of the priority senders before any others.
+// Transactions of each group are ordered by the underlying selector.
+type PriorityTxSelector struct {
+	base     TxSelector
+	priority map[common.Address]struct{}
+}
+
+// NewPriorityTxSelector wraps the selector to prioritize the senders.
+func NewPriorityTxSelector(base TxSelector, senders []common.Address) *PriorityTxSelector {
+	priority := make(map[common.Address]struct{}, len(senders))
+	for _, addr := range senders {
+		priority[addr] = struct{}{}
+	}
+	return &PriorityTxSelector{
+		base:     base,
+		priority: priority,
+	}
+}
+
+// Select implements TxSelector.
+func (s *PriorityTxSelector) Select(pending map[common.Address]types.Transactions) TxIterator {
+	prioritized := make(map[common.Address]types.Transactions)
+	others := make(map[common.Address]types.Transactions, len(pending))
+	for from, txs := range pending {
+		if _, ok := s.priority[from]; ok {
+			prioritized[from] = txs
+		} else {
+			others[from] = txs
+		}
+	}
+	return &chainedTxs{
+		first:  s.base.Select(prioritized),
+		second: s.base.Select(others),
+	}
+}
+
+// chainedTxs iterates over the first iterator, and then over the second one
+type chainedTxs struct {
+	first, second TxIterator
+}
+
+func (t *chainedTxs) current() TxIterator {
+	if t.first.Peek() != nil {
+		return t.first
+	}
+	return t.second
+}
+
+func (t *chainedTxs) Peek() *types.Transaction {
+	return t.current().Peek()
+}
+
+func (t *chainedTxs) Shift() {
+	t.current().Shift()
+}
+
+func (t *chainedTxs) Pop() {
+	t.current().Pop()
+}
+
+func (t *chainedTxs) Copy() TxIterator {
+	return &chainedTxs{
+		first:  t.first.Copy(),
+		second: t.second.Copy(),
+	}
+}
@@ -0,0 +1,123 @@
+package emitter
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type testSender struct {
+	key  *ecdsa.PrivateKey
+	addr common.Address
+}
+
+func newTestSenders(t *testing.T, n int) []testSender {
+	senders := make([]testSender, n)
+	for i := range senders {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		senders[i] = testSender{key, crypto.PubkeyToAddress(key.PublicKey)}
+	}
+	return senders
+}
+
+func signTestTx(t *testing.T, signer types.Signer, from testSender, nonce uint64, gasPrice int64) *types.Transaction {
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 21000, big.NewInt(gasPrice), nil), signer, from.key)
+	require.NoError(t, err)
+	return tx
+}
+
+func drainTxs(it TxIterator) types.Transactions {
+	var res types.Transactions
+	for tx := it.Peek(); tx != nil; tx = it.Peek() {
+		res = append(res, tx)
+		it.Shift()
+	}
+	return res
+}
+
+func TestTxSelectors(t *testing.T) {
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	senders := newTestSenders(t, 3)
+	a, b, c := senders[0], senders[1], senders[2]
+
+	// sender "a" pays the most, but sent the transactions last
+	a0, a1, a2 := signTestTx(t, signer, a, 0, 30), signTestTx(t, signer, a, 1, 30), signTestTx(t, signer, a, 2, 30)
+	b0, b1 := signTestTx(t, signer, b, 0, 20), signTestTx(t, signer, b, 1, 20)
+	c0 := signTestTx(t, signer, c, 0, 10)
+
+	start := time.Now()
+	arrival := map[common.Hash]time.Time{
+		c0.Hash(): start,
+		b0.Hash(): start.Add(1 * time.Second),
+		a0.Hash(): start.Add(2 * time.Second),
+		b1.Hash(): start.Add(3 * time.Second),
+		a1.Hash(): start.Add(4 * time.Second),
+		a2.Hash(): start.Add(5 * time.Second),
+	}
+	arrivalTime := func(h common.Hash) time.Time {
+		return arrival[h]
+	}
+	pending := func() map[common.Address]types.Transactions {
+		return map[common.Address]types.Transactions{
+			a.addr: {a0, a1, a2},
+			b.addr: {b0, b1},
+			c.addr: {c0},
+		}
+	}
+	selectTxs := func(p TxPolicy) TxIterator {
+		s, err := NewTxSelector(p, signer, arrivalTime)
+		require.NoError(t, err)
+		return s.Select(pending())
+	}
+
+	t.Run("price", func(t *testing.T) {
+		require.Equal(t, types.Transactions{a0, a1, a2, b0, b1, c0}, drainTxs(selectTxs(TxPolicy{})))
+	})
+
+	t.Run("arrival", func(t *testing.T) {
+		require.Equal(t, types.Transactions{c0, b0, a0, b1, a1, a2}, drainTxs(selectTxs(TxPolicy{Ordering: OrderByArrival})))
+	})
+
+	t.Run("arrival with sender quota", func(t *testing.T) {
+		require.Equal(t, types.Transactions{c0, b0, a0, b1, a1}, drainTxs(selectTxs(TxPolicy{Ordering: OrderByArrival, SenderQuota: 2})))
+		require.Equal(t, types.Transactions{c0, b0, a0}, drainTxs(selectTxs(TxPolicy{Ordering: OrderByArrival, SenderQuota: 1})))
+	})
+
+	t.Run("arrival pop", func(t *testing.T) {
+		it := selectTxs(TxPolicy{Ordering: OrderByArrival})
+		require.Equal(t, c0, it.Peek())
+		it.Shift()
+		require.Equal(t, b0, it.Peek())
+		it.Pop()
+		require.Equal(t, types.Transactions{a0, a1, a2}, drainTxs(it))
+	})
+
+	t.Run("priority senders", func(t *testing.T) {
+		require.Equal(t, types.Transactions{c0, a0, a1, a2, b0, b1}, drainTxs(selectTxs(TxPolicy{PrioritySenders: []common.Address{c.addr}})))
+		require.Equal(t, types.Transactions{b0, b1, c0, a0, a1, a2}, drainTxs(selectTxs(TxPolicy{Ordering: OrderByArrival, PrioritySenders: []common.Address{b.addr}})))
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		for _, p := range []TxPolicy{{}, {Ordering: OrderByArrival}, {PrioritySenders: []common.Address{b.addr}}} {
+			it := selectTxs(p)
+			cp := it.Copy()
+			expected := drainTxs(it)
+			require.Nil(t, it.Peek())
+			require.Equal(t, expected, drainTxs(cp))
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := NewTxSelector(TxPolicy{Ordering: "random"}, signer, arrivalTime)
+		require.Error(t, err)
+		_, err = NewTxSelector(TxPolicy{Ordering: OrderByArrival, SenderQuota: -1}, signer, arrivalTime)
+		require.Error(t, err)
+	})
+}
@@ -13,7 +13,9 @@ import (
 	notify "github.com/ethereum/go-ethereum/event"
 
 	"github.com/Fantom-foundation/go-opera/evmcore"
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/lease"
 	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
 	"github.com/Fantom-foundation/go-opera/opera"
 	"github.com/Fantom-foundation/go-opera/valkeystore"
 	"github.com/Fantom-foundation/go-opera/vecmt"
@@ -34,6 +36,14 @@ type (
 		Broadcast(*inter.EventPayload)
 		Build(*inter.MutableEventPayload, func()) error
 		DagIndex() *vecmt.Index
+		// GetEpochPubKey returns the validator key of the current epoch
+		GetEpochPubKey(idx.ValidatorID) (validatorpk.PubKey, bool)
+		// GetNextPubKey returns the validator key of the next epoch
+		GetNextPubKey(idx.ValidatorID) (validatorpk.PubKey, bool)
+		// GetLastConfirmedEvent returns the latest confirmed event of the validator
+		GetLastConfirmedEvent(idx.ValidatorID) *hash.Event
+		// Flush writes the stores on disk
+		Flush() error
 
 		IsBusy() bool
 		IsSynced() bool
@@ -50,6 +60,8 @@ type (
 		TxPool   TxPool
 		Signer   valkeystore.SignerI
 		TxSigner types.Signer
+		// Lease enables the failover mode if not nil
+		Lease lease.Lease
 	}
 )
 
@@ -0,0 +1,326 @@
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/Fantom-foundation/go-opera/gossip/emitter"
+)
+
+const defaultDecisionsLimit = 32
+
+// PrivateEmitterAPI provides an API to inspect the events emitter of the validator.
+type PrivateEmitterAPI struct {
+	s *Service
+}
+
+// NewPrivateEmitterAPI creates a new emitter API.
+func NewPrivateEmitterAPI(s *Service) *PrivateEmitterAPI {
+	return &PrivateEmitterAPI{s}
+}
+
+// RPCDecision is a JSON representation of an emission decision.
+type RPCDecision struct {
+	Time          hexutil.Uint64  `json:"time"`
+	Repeats       hexutil.Uint64  `json:"repeats,omitempty"`
+	Epoch         hexutil.Uint64  `json:"epoch"`
+	Emitted       hexutil.Bytes   `json:"emitted,omitempty"`
+	Skipped       string          `json:"skipped,omitempty"`
+	Details       string          `json:"details,omitempty"`
+	Forced        string          `json:"forced,omitempty"`
+	Metric        float64         `json:"metric"`
+	PassedTime    string          `json:"passedTime"`
+	PassedBlocks  hexutil.Uint64  `json:"passedBlocks"`
+	GasPowerLeft  hexutil.Uint64  `json:"gasPowerLeft"`
+	GasThresholds []string        `json:"gasThresholds,omitempty"`
+	MaxGasToUse   hexutil.Uint64  `json:"maxGasToUse"`
+	Txs           hexutil.Uint64  `json:"txs"`
+	Parents       []hexutil.Bytes `json:"parents,omitempty"`
+	ParentReasons []string        `json:"parentReasons,omitempty"`
+}
+
+func rpcMarshalDecision(d emitter.Decision) RPCDecision {
+	var emitted hexutil.Bytes
+	if d.Emitted != nil {
+		emitted = d.Emitted.Bytes()
+	}
+	parents := make([]hexutil.Bytes, len(d.Parents))
+	for i, id := range d.Parents {
+		parents[i] = id.Bytes()
+	}
+	return RPCDecision{
+		Time:          hexutil.Uint64(d.Time.UnixNano()),
+		Repeats:       hexutil.Uint64(d.Repeats),
+		Epoch:         hexutil.Uint64(d.Epoch),
+		Emitted:       emitted,
+		Skipped:       d.Skipped,
+		Details:       d.Details,
+		Forced:        d.Forced,
+		Metric:        d.Metric,
+		PassedTime:    d.PassedTime.String(),
+		PassedBlocks:  hexutil.Uint64(d.PassedBlocks),
+		GasPowerLeft:  hexutil.Uint64(d.GasPowerLeft),
+		GasThresholds: d.GasThresholds,
+		MaxGasToUse:   hexutil.Uint64(d.MaxGasToUse),
+		Txs:           hexutil.Uint64(d.Txs),
+		Parents:       parents,
+		ParentReasons: d.ParentReasons,
+	}
+}
+
+// getEmitter returns the emitter of the specified validator, or the emitter of the main validator if it's omitted.
+func (api *PrivateEmitterAPI) getEmitter(validatorID *hexutil.Uint64) (*emitter.Emitter, error) {
+	if validatorID == nil {
+		if api.s.config.Emitter.Validator.ID == 0 {
+			return nil, errors.New("node isn't a validator")
+		}
+		return api.s.emitters[0], nil
+	}
+	em := api.s.getEmitter(idx.ValidatorID(*validatorID))
+	if em == nil || *validatorID == 0 {
+		return nil, fmt.Errorf("validator %d isn't hosted by the node", *validatorID)
+	}
+	return em, nil
+}
+
+// Validators returns IDs of the validators hosted by the node.
+func (api *PrivateEmitterAPI) Validators() []hexutil.Uint64 {
+	res := make([]hexutil.Uint64, 0, len(api.s.emitters))
+	for _, cfg := range api.s.config.Emitters() {
+		if cfg.Validator.ID != 0 {
+			res = append(res, hexutil.Uint64(cfg.Validator.ID))
+		}
+	}
+	return res
+}
+
+// Decisions returns the recent emission decisions, the latest first.
+func (api *PrivateEmitterAPI) Decisions(limit *hexutil.Uint64, validatorID *hexutil.Uint64) ([]RPCDecision, error) {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return nil, err
+	}
+	n := defaultDecisionsLimit
+	if limit != nil {
+		n = int(*limit)
+	}
+	decisions := em.Decisions(n)
+	res := make([]RPCDecision, len(decisions))
+	for i, d := range decisions {
+		res[i] = rpcMarshalDecision(d)
+	}
+	return res, nil
+}
+
+// RPCEmitterSettings is a JSON representation of the emitter runtime settings.
+type RPCEmitterSettings struct {
+	MinEmitInterval            string         `json:"minEmitInterval"`
+	MaxEmitInterval            string         `json:"maxEmitInterval"`
+	ConfirmingEmitInterval     string         `json:"confirmingEmitInterval"`
+	ParallelInstanceProtection string         `json:"parallelInstanceProtection"`
+	DoublesignProtection       string         `json:"doublesignProtection"`
+	MaxTxsPerAddress           hexutil.Uint64 `json:"maxTxsPerAddress"`
+	MaxParents                 hexutil.Uint64 `json:"maxParents"`
+	LimitedTpsThreshold        hexutil.Uint64 `json:"limitedTpsThreshold"`
+	NoTxsThreshold             hexutil.Uint64 `json:"noTxsThreshold"`
+	EmergencyThreshold         hexutil.Uint64 `json:"emergencyThreshold"`
+	Paused                     bool           `json:"paused"`
+}
+
+// RPCEmitterSettingsUpdate contains the emitter settings to change, the omitted settings remain unchanged.
+// Durations are in the time.ParseDuration format, e.g. "150ms".
+type RPCEmitterSettingsUpdate struct {
+	MinEmitInterval            *string         `json:"minEmitInterval"`
+	MaxEmitInterval            *string         `json:"maxEmitInterval"`
+	ConfirmingEmitInterval     *string         `json:"confirmingEmitInterval"`
+	ParallelInstanceProtection *string         `json:"parallelInstanceProtection"`
+	DoublesignProtection       *string         `json:"doublesignProtection"`
+	MaxTxsPerAddress           *hexutil.Uint64 `json:"maxTxsPerAddress"`
+	MaxParents                 *hexutil.Uint64 `json:"maxParents"`
+	LimitedTpsThreshold        *hexutil.Uint64 `json:"limitedTpsThreshold"`
+	NoTxsThreshold             *hexutil.Uint64 `json:"noTxsThreshold"`
+	EmergencyThreshold         *hexutil.Uint64 `json:"emergencyThreshold"`
+}
+
+func rpcMarshalSettings(em *emitter.Emitter, s emitter.Settings) RPCEmitterSettings {
+	return RPCEmitterSettings{
+		MinEmitInterval:            s.EmitIntervals.Min.String(),
+		MaxEmitInterval:            s.EmitIntervals.Max.String(),
+		ConfirmingEmitInterval:     s.EmitIntervals.Confirming.String(),
+		ParallelInstanceProtection: s.EmitIntervals.ParallelInstanceProtection.String(),
+		DoublesignProtection:       s.EmitIntervals.DoublesignProtection.String(),
+		MaxTxsPerAddress:           hexutil.Uint64(s.MaxTxsPerAddress),
+		MaxParents:                 hexutil.Uint64(s.MaxParents),
+		LimitedTpsThreshold:        hexutil.Uint64(s.LimitedTpsThreshold),
+		NoTxsThreshold:             hexutil.Uint64(s.NoTxsThreshold),
+		EmergencyThreshold:         hexutil.Uint64(s.EmergencyThreshold),
+		Paused:                     em.Paused(),
+	}
+}
+
+// Settings returns the current emitter settings.
+func (api *PrivateEmitterAPI) Settings(validatorID *hexutil.Uint64) (RPCEmitterSettings, error) {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return RPCEmitterSettings{}, err
+	}
+	return rpcMarshalSettings(em, em.Settings()), nil
+}
+
+// UpdateSettings atomically changes the emitter settings.
+// The changes are persisted, and take precedence over the config until ResetSettings is called.
+func (api *PrivateEmitterAPI) UpdateSettings(update RPCEmitterSettingsUpdate, validatorID *hexutil.Uint64) (RPCEmitterSettings, error) {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return RPCEmitterSettings{}, err
+	}
+	durations := []struct {
+		name  string
+		arg   *string
+		value time.Duration
+	}{
+		{"minEmitInterval", update.MinEmitInterval, 0},
+		{"maxEmitInterval", update.MaxEmitInterval, 0},
+		{"confirmingEmitInterval", update.ConfirmingEmitInterval, 0},
+		{"parallelInstanceProtection", update.ParallelInstanceProtection, 0},
+		{"doublesignProtection", update.DoublesignProtection, 0},
+	}
+	for i, d := range durations {
+		if d.arg == nil {
+			continue
+		}
+		v, err := time.ParseDuration(*d.arg)
+		if err != nil {
+			return RPCEmitterSettings{}, fmt.Errorf("%s: %v", d.name, err)
+		}
+		durations[i].value = v
+	}
+
+	s, err := em.UpdateSettings(func(s *emitter.Settings) {
+		for i, field := range []*time.Duration{
+			&s.EmitIntervals.Min,
+			&s.EmitIntervals.Max,
+			&s.EmitIntervals.Confirming,
+			&s.EmitIntervals.ParallelInstanceProtection,
+			&s.EmitIntervals.DoublesignProtection,
+		} {
+			if durations[i].arg != nil {
+				*field = durations[i].value
+			}
+		}
+		if update.MaxTxsPerAddress != nil {
+			s.MaxTxsPerAddress = int(*update.MaxTxsPerAddress)
+		}
+		if update.MaxParents != nil {
+			s.MaxParents = idx.Event(*update.MaxParents)
+		}
+		if update.LimitedTpsThreshold != nil {
+			s.LimitedTpsThreshold = uint64(*update.LimitedTpsThreshold)
+		}
+		if update.NoTxsThreshold != nil {
+			s.NoTxsThreshold = uint64(*update.NoTxsThreshold)
+		}
+		if update.EmergencyThreshold != nil {
+			s.EmergencyThreshold = uint64(*update.EmergencyThreshold)
+		}
+	})
+	return rpcMarshalSettings(em, s), err
+}
+
+// ResetSettings drops the changes made by UpdateSettings, and restores the settings from the config.
+func (api *PrivateEmitterAPI) ResetSettings(validatorID *hexutil.Uint64) (RPCEmitterSettings, error) {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return RPCEmitterSettings{}, err
+	}
+	s, err := em.ResetSettings()
+	return rpcMarshalSettings(em, s), err
+}
+
+// Pause stops events emission, e.g. before a shutdown for maintenance.
+// The pause isn't persisted, i.e. the emission is resumed after a restart.
+func (api *PrivateEmitterAPI) Pause(validatorID *hexutil.Uint64) error {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return err
+	}
+	em.Pause()
+	return nil
+}
+
+// Resume events emission.
+func (api *PrivateEmitterAPI) Resume(validatorID *hexutil.Uint64) error {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return err
+	}
+	em.Resume()
+	return nil
+}
+
+// RPCMaintenanceStatus is a JSON representation of the maintenance progress.
+type RPCMaintenanceStatus struct {
+	Active             bool           `json:"active"`
+	Since              hexutil.Uint64 `json:"since,omitempty"`
+	LastEvent          hexutil.Bytes  `json:"lastEvent,omitempty"`
+	LastConfirmedEvent hexutil.Bytes  `json:"lastConfirmedEvent,omitempty"`
+	Confirmed          bool           `json:"confirmed"`
+	Flushed            bool           `json:"flushed"`
+	SafeToShutdown     bool           `json:"safeToShutdown"`
+	Resuming           bool           `json:"resuming"`
+}
+
+func rpcMarshalMaintenanceStatus(s emitter.MaintenanceStatus) RPCMaintenanceStatus {
+	res := RPCMaintenanceStatus{
+		Active:         s.Active,
+		Confirmed:      s.Confirmed,
+		Flushed:        s.Flushed,
+		SafeToShutdown: s.SafeToShutdown,
+		Resuming:       s.Resuming,
+	}
+	if s.Active {
+		res.Since = hexutil.Uint64(s.Since.Unix())
+	}
+	if s.LastEvent != nil {
+		res.LastEvent = s.LastEvent.Bytes()
+	}
+	if s.LastConfirmedEvent != nil {
+		res.LastConfirmedEvent = s.LastConfirmedEvent.Bytes()
+	}
+	return res
+}
+
+// EnterMaintenance stops events emission before a shutdown.
+// The node waits for its last event to be confirmed and flushes the state,
+// MaintenanceStatus reports when it's safe to shut down.
+func (api *PrivateEmitterAPI) EnterMaintenance(validatorID *hexutil.Uint64) (RPCMaintenanceStatus, error) {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return RPCMaintenanceStatus{}, err
+	}
+	em.EnterMaintenance()
+	return rpcMarshalMaintenanceStatus(em.MaintenanceStatus()), nil
+}
+
+// ExitMaintenance resumes events emission without a shutdown.
+func (api *PrivateEmitterAPI) ExitMaintenance(validatorID *hexutil.Uint64) error {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return err
+	}
+	em.ExitMaintenance()
+	return nil
+}
+
+// MaintenanceStatus returns the progress of the maintenance.
+func (api *PrivateEmitterAPI) MaintenanceStatus(validatorID *hexutil.Uint64) (RPCMaintenanceStatus, error) {
+	em, err := api.getEmitter(validatorID)
+	if err != nil {
+		return RPCMaintenanceStatus{}, err
+	}
+	return rpcMarshalMaintenanceStatus(em.MaintenanceStatus()), nil
+}
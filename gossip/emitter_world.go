@@ -10,6 +10,7 @@ import (
 
 	"github.com/Fantom-foundation/go-opera/evmcore"
 	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
 	"github.com/Fantom-foundation/go-opera/utils/wgmutex"
 	"github.com/Fantom-foundation/go-opera/valkeystore"
 	"github.com/Fantom-foundation/go-opera/vecmt"
@@ -67,6 +68,35 @@ func (ew *emitterWorld) GetHeads(epoch idx.Epoch) hash.Events {
 func (ew *emitterWorld) GetLastEvent(epoch idx.Epoch, from idx.ValidatorID) *hash.Event {
 	return ew.Store.GetLastEvent(epoch, from)
 }
+
+func (ew *emitterWorld) GetEpochPubKey(id idx.ValidatorID) (validatorpk.PubKey, bool) {
+	profile, ok := ew.Store.GetEpochState().ValidatorProfiles[id]
+	return profile.PubKey, ok
+}
+
+func (ew *emitterWorld) GetNextPubKey(id idx.ValidatorID) (validatorpk.PubKey, bool) {
+	profile, ok := ew.Store.GetBlockState().NextValidatorProfiles[id]
+	return profile.PubKey, ok
+}
+
+func (ew *emitterWorld) GetLastConfirmedEvent(from idx.ValidatorID) *hash.Event {
+	bs, es := ew.Store.GetBlockEpochState()
+	if !es.Validators.Exists(from) {
+		return nil
+	}
+	id := bs.GetValidatorState(from, es.Validators).LastEvent
+	if id == (hash.Event{}) {
+		return nil
+	}
+	return &id
+}
+
+// Flush should be called under the engine lock
+func (ew *emitterWorld) Flush() error {
+	ew.s.blockProcWg.Wait()
+	return ew.s.store.Commit()
+}
+
 func (ew *emitterWorld) GetRecommendedGasPrice() *big.Int {
 	return ew.s.GetEvmStateReader().RecommendedMinGasPrice()
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/Fantom-foundation/go-opera/ethapi"
 	"github.com/Fantom-foundation/go-opera/evmcore"
 	"github.com/Fantom-foundation/go-opera/gossip/blockproc"
+	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
 	"github.com/Fantom-foundation/go-opera/gossip/sfcapi"
 	"github.com/Fantom-foundation/go-opera/inter"
 	"github.com/Fantom-foundation/go-opera/inter/drivertype"
@@ -376,6 +377,50 @@ func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	return tx, uint64(position.Block), uint64(position.BlockOffset), nil
 }
 
+// GetTransactionsByAddress returns positions of transactions sent or received by the address
+// within the blocks range, starting from the given position.
+func (b *EthAPIBackend) GetTransactionsByAddress(ctx context.Context, addr common.Address, from idx.Block, fromOffset uint32, to idx.Block, limit int) ([]evmstore.AccountTxPosition, error) {
+	if !b.svc.config.AccountTxIndex {
+		return nil, errors.New("account transactions index is disabled (enable AccountTxIndex and run 'opera index accounts')")
+	}
+
+	var positions []evmstore.AccountTxPosition
+	b.svc.store.evm.ForEachAccountTx(addr, from, fromOffset, func(p evmstore.AccountTxPosition) bool {
+		if p.Block > to || ctx.Err() != nil {
+			return false
+		}
+		positions = append(positions, p)
+		return len(positions) < limit
+	})
+	return positions, ctx.Err()
+}
+
+// GetInternalTransfers returns internal value transfers made by the transaction.
+func (b *EthAPIBackend) GetInternalTransfers(ctx context.Context, txHash common.Hash) ([]evmcore.InternalTransfer, error) {
+	if !b.svc.config.InternalTransfersIndex {
+		return nil, errors.New("internal transfers index is disabled (enable InternalTransfersIndex and run 'opera index transfers')")
+	}
+	return b.svc.store.evm.GetInternalTransfers(txHash), nil
+}
+
+// GetInternalTransfersByAddress returns positions of transactions with internal value transfers from or to the address
+// within the blocks range, starting from the given position.
+func (b *EthAPIBackend) GetInternalTransfersByAddress(ctx context.Context, addr common.Address, from idx.Block, fromOffset uint32, to idx.Block, limit int) ([]evmstore.AccountTxPosition, error) {
+	if !b.svc.config.InternalTransfersIndex {
+		return nil, errors.New("internal transfers index is disabled (enable InternalTransfersIndex and run 'opera index transfers')")
+	}
+
+	var positions []evmstore.AccountTxPosition
+	b.svc.store.evm.ForEachAccountTransferTx(addr, from, fromOffset, func(p evmstore.AccountTxPosition) bool {
+		if p.Block > to || ctx.Err() != nil {
+			return false
+		}
+		positions = append(positions, p)
+		return len(positions) < limit
+	})
+	return positions, ctx.Err()
+}
+
 func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return b.svc.txpool.Nonce(addr), nil
 }
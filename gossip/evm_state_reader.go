@@ -139,7 +139,10 @@ func (r *EvmStateReader) getBlock(h hash.Event, n idx.Block, readTxs bool) *evmc
 
 	var prev hash.Event
 	if n != 0 {
-		prev = r.store.GetBlock(n - 1).Atropos
+		// the previous block is absent if the chain is started from an exported genesis
+		if prevBlock := r.store.GetBlock(n - 1); prevBlock != nil {
+			prev = prevBlock.Atropos
+		}
 	}
 	evmHeader := evmcore.ToEvmHeader(block, n, prev)
 
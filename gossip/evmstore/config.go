@@ -3,6 +3,8 @@ package evmstore
 import (
 	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+
+	"github.com/Fantom-foundation/go-opera/topicsdb"
 )
 
 type (
@@ -29,6 +31,8 @@ type (
 		EnableSnapshots bool
 		// Enables tracking of SHA3 preimages in the VM
 		EnablePreimageRecording bool
+		// Logs index config
+		LogsIndex topicsdb.Config
 	}
 )
 
@@ -46,6 +50,7 @@ func DefaultStoreConfig(scale cachescale.Func) StoreConfig {
 		},
 		EnableSnapshots:         true,
 		EnablePreimageRecording: true,
+		LogsIndex:               topicsdb.DefaultConfig(),
 	}
 }
 
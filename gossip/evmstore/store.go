@@ -35,6 +35,10 @@ type Store struct {
 		Receipts    kvdb.Store `table:"r"`
 		TxPositions kvdb.Store `table:"x"`
 		Txs         kvdb.Store `table:"X"`
+		AccountTxs  kvdb.Store `table:"a"`
+
+		InternalTransfers kvdb.Store `table:"T"`
+		AccountTransfers  kvdb.Store `table:"t"`
 
 		Evm      ethdb.Database
 		EvmState state.Database
@@ -76,7 +80,7 @@ func NewStore(mainDB kvdb.Store, cfg StoreConfig) *Store {
 		Cache:     cfg.Cache.EvmDatabase / opt.MiB,
 		Preimages: cfg.EnablePreimageRecording,
 	})
-	s.table.EvmLogs = topicsdb.New(table.New(s.mainDB, []byte("L")))
+	s.table.EvmLogs = topicsdb.NewWithConfig(table.New(s.mainDB, []byte("L")), cfg.LogsIndex)
 
 	s.initCache()
 
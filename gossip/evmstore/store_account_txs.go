@@ -0,0 +1,62 @@
+package evmstore
+
+import (
+	"github.com/Fantom-foundation/lachesis-base/common/bigendian"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const accountTxKeySize = common.AddressLength + 8 + 4
+
+// AccountTxPosition is a position of a transaction in the account's history.
+type AccountTxPosition struct {
+	Block       idx.Block
+	BlockOffset uint32
+	TxHash      common.Hash
+}
+
+func accountTxKey(addr common.Address, block idx.Block, offset uint32) []byte {
+	key := make([]byte, 0, accountTxKeySize)
+	key = append(key, addr.Bytes()...)
+	key = append(key, block.Bytes()...)
+	key = append(key, bigendian.Uint32ToBytes(offset)...)
+	return key
+}
+
+// IndexAccountTx adds the transaction into the account's history.
+func (s *Store) IndexAccountTx(addr common.Address, block idx.Block, offset uint32, txid common.Hash) {
+	err := s.table.AccountTxs.Put(accountTxKey(addr, block, offset), txid.Bytes())
+	if err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+}
+
+// ForEachAccountTx iterates over the account's transactions in the chain order,
+// starting from the given block and offset.
+func (s *Store) ForEachAccountTx(addr common.Address, from idx.Block, fromOffset uint32, onTx func(AccountTxPosition) bool) {
+	s.forEachAccountTx(s.table.AccountTxs, addr, from, fromOffset, onTx)
+}
+
+func (s *Store) forEachAccountTx(t kvdb.Store, addr common.Address, from idx.Block, fromOffset uint32, onTx func(AccountTxPosition) bool) {
+	start := accountTxKey(addr, from, fromOffset)[common.AddressLength:]
+	it := t.NewIterator(addr.Bytes(), start)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		if len(key) != accountTxKeySize {
+			s.Log.Crit("Account tx index is corrupted", "key", common.Bytes2Hex(key))
+		}
+		pos := AccountTxPosition{
+			Block:       idx.BytesToBlock(key[common.AddressLength : common.AddressLength+8]),
+			BlockOffset: bigendian.BytesToUint32(key[common.AddressLength+8:]),
+			TxHash:      common.BytesToHash(it.Value()),
+		}
+		if !onTx(pos) {
+			break
+		}
+	}
+	if it.Error() != nil {
+		s.Log.Crit("Failed to iterate keys", "err", it.Error())
+	}
+}
@@ -0,0 +1,48 @@
+package evmstore
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/logger"
+)
+
+func TestStoreAccountTxs(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := nonCachedStore()
+	a := common.Address{1}
+	b := common.Address{2}
+
+	expect := []AccountTxPosition{
+		{Block: 1, BlockOffset: 0, TxHash: common.Hash{1}},
+		{Block: 1, BlockOffset: 3, TxHash: common.Hash{2}},
+		{Block: 2, BlockOffset: 1, TxHash: common.Hash{3}},
+		{Block: 256, BlockOffset: 0, TxHash: common.Hash{4}},
+	}
+	for i := len(expect) - 1; i >= 0; i-- {
+		p := expect[i]
+		store.IndexAccountTx(a, p.Block, p.BlockOffset, p.TxHash)
+	}
+	store.IndexAccountTx(b, 1, 1, common.Hash{5})
+
+	collect := func(addr common.Address, from idx.Block, fromOffset uint32, limit int) []AccountTxPosition {
+		var got []AccountTxPosition
+		store.ForEachAccountTx(addr, from, fromOffset, func(p AccountTxPosition) bool {
+			got = append(got, p)
+			return len(got) < limit
+		})
+		return got
+	}
+
+	require.Equal(expect, collect(a, 0, 0, 100))
+	require.Equal(expect[1:], collect(a, 1, 1, 100))
+	require.Equal(expect[2:3], collect(a, 2, 0, 1))
+	require.Equal(expect[3:], collect(a, 3, 0, 100))
+	require.Equal([]AccountTxPosition{{Block: 1, BlockOffset: 1, TxHash: common.Hash{5}}}, collect(b, 0, 0, 100))
+	require.Empty(collect(common.Address{3}, 0, 0, 100))
+}
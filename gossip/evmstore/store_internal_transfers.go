@@ -0,0 +1,45 @@
+package evmstore
+
+import (
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/go-opera/evmcore"
+)
+
+// SetInternalTransfers stores internal value transfers of the transaction
+// and adds the transaction into the transfers histories of the participants.
+func (s *Store) SetInternalTransfers(block idx.Block, offset uint32, txid common.Hash, transfers []evmcore.InternalTransfer) {
+	s.rlp.Set(s.table.InternalTransfers, txid.Bytes(), transfers)
+
+	indexed := make(map[common.Address]bool, 2*len(transfers))
+	index := func(addr common.Address) {
+		if indexed[addr] {
+			return
+		}
+		indexed[addr] = true
+		err := s.table.AccountTransfers.Put(accountTxKey(addr, block, offset), txid.Bytes())
+		if err != nil {
+			s.Log.Crit("Failed to put key-value", "err", err)
+		}
+	}
+	for _, t := range transfers {
+		index(t.From)
+		index(t.To)
+	}
+}
+
+// GetInternalTransfers returns stored internal value transfers of the transaction.
+func (s *Store) GetInternalTransfers(txid common.Hash) []evmcore.InternalTransfer {
+	transfers, _ := s.rlp.Get(s.table.InternalTransfers, txid.Bytes(), &[]evmcore.InternalTransfer{}).(*[]evmcore.InternalTransfer)
+	if transfers == nil {
+		return nil
+	}
+	return *transfers
+}
+
+// ForEachAccountTransferTx iterates over the transactions with internal transfers from or to the account,
+// in the chain order, starting from the given block and offset.
+func (s *Store) ForEachAccountTransferTx(addr common.Address, from idx.Block, fromOffset uint32, onTx func(AccountTxPosition) bool) {
+	s.forEachAccountTx(s.table.AccountTransfers, addr, from, fromOffset, onTx)
+}
@@ -0,0 +1,43 @@
+package evmstore
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/evmcore"
+	"github.com/Fantom-foundation/go-opera/logger"
+)
+
+func TestStoreInternalTransfers(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := nonCachedStore()
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	txid := common.Hash{1}
+
+	require.Nil(store.GetInternalTransfers(txid))
+
+	transfers := []evmcore.InternalTransfer{
+		{Kind: evmcore.TransferCall, From: a, To: b, Value: big.NewInt(5)},
+		{Kind: evmcore.TransferSelfDestruct, From: b, To: c, Value: big.NewInt(5)},
+	}
+	store.SetInternalTransfers(7, 2, txid, transfers)
+	require.Equal(transfers, store.GetInternalTransfers(txid))
+
+	for _, addr := range []common.Address{a, b, c} {
+		var got []AccountTxPosition
+		store.ForEachAccountTransferTx(addr, 0, 0, func(p AccountTxPosition) bool {
+			got = append(got, p)
+			return true
+		})
+		require.Equal([]AccountTxPosition{{Block: 7, BlockOffset: 2, TxHash: txid}}, got, addr.String())
+	}
+	store.ForEachAccountTransferTx(common.Address{4}, 0, 0, func(p AccountTxPosition) bool {
+		require.Fail("unexpected transfer", p.TxHash.String())
+		return true
+	})
+}
@@ -32,6 +32,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/Fantom-foundation/go-opera/topicsdb"
 )
 
 var (
@@ -55,12 +57,24 @@ type Config struct {
 	IndexedLogsBlockRangeLimit idx.Block
 	// Block range limit for logs search (unindexed).
 	UnindexedLogsBlockRangeLimit idx.Block
+	// Results limit for a page of logs search (indexed). Zero means no limit.
+	IndexedLogsResultsLimit int
+	// Scanned index keys limit for a page of logs search (indexed). Zero means no limit.
+	IndexedLogsScannedKeysLimit int
+	// Results limit for logs search without paging (indexed). Zero means no limit.
+	UnpagedLogsResultsLimit int
+	// Scanned index keys limit for logs search without paging (indexed). Zero means no limit.
+	UnpagedLogsScannedKeysLimit int
 }
 
 func DefaultConfig() Config {
 	return Config{
 		IndexedLogsBlockRangeLimit:   999999999999999999,
 		UnindexedLogsBlockRangeLimit: 100,
+		IndexedLogsResultsLimit:      10000,
+		IndexedLogsScannedKeysLimit:  10000000,
+		UnpagedLogsResultsLimit:      100000,
+		UnpagedLogsScannedKeysLimit:  100000000,
 	}
 }
 
@@ -335,34 +349,88 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 }
 
 // GetLogs returns logs matching the given argument that are stored within the state.
+// If the result doesn't fit into the configured limits, LogsLimitError is returned,
+// the logs may be fetched with GetLogsPage.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
 func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
-	var filter *Filter
-	if crit.BlockHash != nil {
-		// Block filter requested, construct a single-shot filter
-		filter = NewBlockFilter(api.backend, api.config, *crit.BlockHash, crit.Addresses, crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if crit.FromBlock != nil {
-			begin = crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if crit.ToBlock != nil {
-			end = crit.ToBlock.Int64()
-		}
-		// Construct the range filter
-		filter = NewRangeFilter(api.backend, api.config, begin, end, crit.Addresses, crit.Topics)
-	}
+	filter := api.newFilter(crit)
 	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if filter.Cursor() != nil {
+		return nil, &LogsLimitError{}
+	}
 	return returnLogs(logs), err
 }
 
+// LogsPage is a part of logs search result.
+type LogsPage struct {
+	Logs []*types.Log `json:"logs"`
+	// Cursor to pass into the next GetLogsPage call, nil if there are no more logs.
+	Cursor hexutil.Bytes `json:"cursor"`
+}
+
+// GetLogsPage returns logs matching the given argument, starting from the cursor (if not nil).
+// Result is limited by configured limits and contains a cursor to continue the search.
+func (api *PublicFilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria, cursor *hexutil.Bytes) (*LogsPage, error) {
+	filter := api.newFilter(crit)
+	var from *topicsdb.ID
+	if cursor != nil {
+		id, err := topicsdb.BytesToID(*cursor)
+		if err != nil {
+			return nil, err
+		}
+		from = &id
+	}
+	if err := filter.SetPage(from); err != nil {
+		return nil, err
+	}
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogsPage{
+		Logs: returnLogs(logs),
+	}
+	if next := filter.Cursor(); next != nil {
+		page.Cursor = next.Bytes()
+	}
+	return page, nil
+}
+
+func (api *PublicFilterAPI) newFilter(crit FilterCriteria) *Filter {
+	if crit.BlockHash != nil {
+		// Block filter requested, construct a single-shot filter
+		return NewBlockFilter(api.backend, api.config, *crit.BlockHash, crit.Addresses, crit.Topics)
+	}
+	// Convert the RPC block numbers into internal representations
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	// Construct the range filter
+	return NewRangeFilter(api.backend, api.config, begin, end, crit.Addresses, crit.Topics)
+}
+
+// LogsLimitError is returned if logs search without paging exceeds the limits.
+type LogsLimitError struct{}
+
+func (e *LogsLimitError) Error() string {
+	return "query exceeds logs search limits, use eth_getLogsPage to fetch the logs by pages"
+}
+
+// ErrorCode returns the JSON-RPC error code.
+func (e *LogsLimitError) ErrorCode() int {
+	return -32005
+}
+
 // UninstallFilter removes the filter with the given filter id.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_uninstallfilter
@@ -393,28 +461,15 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 		return nil, fmt.Errorf("filter not found")
 	}
 
-	var filter *Filter
-	if f.crit.BlockHash != nil {
-		// Block filter requested, construct a single-shot filter
-		filter = NewBlockFilter(api.backend, api.config, *f.crit.BlockHash, f.crit.Addresses, f.crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if f.crit.FromBlock != nil {
-			begin = f.crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if f.crit.ToBlock != nil {
-			end = f.crit.ToBlock.Int64()
-		}
-		// Construct the range filter
-		filter = NewRangeFilter(api.backend, api.config, begin, end, f.crit.Addresses, f.crit.Topics)
-	}
+	filter := api.newFilter(f.crit)
 	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if filter.Cursor() != nil {
+		return nil, &LogsLimitError{}
+	}
 	return returnLogs(logs), nil
 }
 
@@ -60,6 +60,10 @@ type Filter struct {
 
 	block      common.Hash // Block hash if filtering a single block
 	begin, end int64       // Range interval if filtering multiple blocks
+
+	paged  bool         // Whether the indexed search is limited by the limits of a page
+	cursor *topicsdb.ID // Log record to continue the indexed search from
+	next   *topicsdb.ID // Log record the indexed search was stopped at by limits
 }
 
 // NewRangeFilter creates a new filter which inspects the blocks to
@@ -97,6 +101,24 @@ func newFilter(backend Backend, cfg Config, addresses []common.Address, topics [
 	}
 }
 
+// SetPage limits the indexed search by the configured limits, and makes the filter
+// continue the search from the log record returned by Cursor() of a previous search (if not nil).
+// Only range filters support paging.
+func (f *Filter) SetPage(cursor *topicsdb.ID) error {
+	if f.block != common.Hash(hash.Zero) {
+		return errors.New("paging isn't supported for block hash filters")
+	}
+	f.paged = true
+	f.cursor = cursor
+	return nil
+}
+
+// Cursor returns the log record to continue the search from if the last Logs() result
+// was truncated by limits, or nil if the result is complete.
+func (f *Filter) Cursor() *topicsdb.ID {
+	return f.next
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
@@ -131,6 +153,9 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 
 	if isEmpty(f.topics) && len(f.addresses) == 0 {
+		if f.cursor != nil {
+			return nil, errors.New("cursor is supported only for search by addresses or topics")
+		}
 		return f.unindexedLogs(ctx, begin, end)
 	} else {
 		return f.indexedLogs(ctx, begin, end)
@@ -152,7 +177,34 @@ func (f *Filter) indexedLogs(ctx context.Context, begin, end idx.Block) ([]*type
 	pattern[0] = addresses
 	pattern = append(pattern, f.topics...)
 
-	logs, err := f.backend.EvmLogIndex().FindInBlocks(ctx, begin, end, pattern)
+	var (
+		logs  []*types.Log
+		stats topicsdb.Stats
+		err   error
+	)
+	budget := topicsdb.Budget{
+		MaxResults:     f.config.UnpagedLogsResultsLimit,
+		MaxScannedKeys: f.config.UnpagedLogsScannedKeysLimit,
+	}
+	if f.paged {
+		budget = topicsdb.Budget{
+			MaxResults:     f.config.IndexedLogsResultsLimit,
+			MaxScannedKeys: f.config.IndexedLogsScannedKeysLimit,
+		}
+	}
+	onLog := func(l *types.Log) bool {
+		logs = append(logs, l)
+		return true
+	}
+	if f.cursor != nil {
+		if idx.Block(f.cursor.BlockNumber()) < begin {
+			return nil, errors.New("cursor is out of blocks range")
+		}
+		stats, err = f.backend.EvmLogIndex().ContinueInBlocks(ctx, *f.cursor, end, pattern, budget, onLog)
+	} else {
+		stats, err = f.backend.EvmLogIndex().ForEachInBlocks(ctx, begin, end, pattern, budget, onLog)
+	}
+	f.next = stats.Next
 
 	return logs, err
 }
@@ -264,4 +264,75 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 
+	// the page limits apply only to paged search
+	limitedConfig := testConfig()
+	limitedConfig.IndexedLogsResultsLimit = 1
+	filter = NewRangeFilter(backend, limitedConfig, 0, -1, []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3, hash4}})
+	logs, err = filter.Logs(context.Background())
+	if err != nil {
+		t.Error(err)
+	}
+	if len(logs) != 4 {
+		t.Error("expected 4 log, got", len(logs))
+	}
+	if filter.Cursor() != nil {
+		t.Error("expected no cursor")
+	}
+
+	var cursor *topicsdb.ID
+	for page := 1; ; page++ {
+		filter = NewRangeFilter(backend, limitedConfig, 0, -1, []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3, hash4}})
+		if err = filter.SetPage(cursor); err != nil {
+			t.Fatal(err)
+		}
+		logs, err = filter.Logs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		cursor = filter.Cursor()
+		if cursor == nil {
+			if page < 4 {
+				t.Error("expected at least 4 pages, got", page)
+			}
+			break
+		}
+		if len(logs) != 1 {
+			t.Error("expected 1 log, got", len(logs))
+		}
+	}
+
+	filter = NewBlockFilter(backend, limitedConfig, chain[1].Hash(), []common.Address{addr}, nil)
+	if err = filter.SetPage(nil); err == nil {
+		t.Error("expected paging of block filter to fail")
+	}
+
+	// search without paging is limited by its own limits
+	limitedConfig = testConfig()
+	limitedConfig.UnpagedLogsResultsLimit = 3
+	crit := FilterCriteria{
+		FromBlock: big.NewInt(0),
+		Addresses: []common.Address{addr},
+	}
+	api := NewPublicFilterAPI(backend, limitedConfig)
+	_, err = api.GetLogs(context.Background(), crit)
+	if _, ok := err.(*LogsLimitError); !ok {
+		t.Error("expected logs limit error, got", err)
+	}
+	page, err := api.GetLogsPage(context.Background(), crit, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Logs) != 4 {
+		t.Error("expected 4 log, got", len(page.Logs))
+	}
+
+	limitedConfig.UnpagedLogsResultsLimit = 4
+	api = NewPublicFilterAPI(backend, limitedConfig)
+	logs, err = api.GetLogs(context.Background(), crit)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(logs) != 4 {
+		t.Error("expected 4 log, got", len(logs))
+	}
 }
@@ -0,0 +1,469 @@
+package gossip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/go-opera/evmcore"
+	"github.com/Fantom-foundation/go-opera/gossip/contract/driverauth100"
+	"github.com/Fantom-foundation/go-opera/gossip/contract/sfc100"
+	"github.com/Fantom-foundation/go-opera/gossip/sfcapi"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/opera/genesis"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driver"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driverauth"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/gpos"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/netinit"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/sfc"
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+	"github.com/Fantom-foundation/go-opera/topicsdb"
+)
+
+const exportReportPeriod = 8 * time.Second
+
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// ExportGenesis writes the state of the sealed epoch into the genesis store.
+// The EVM state of the last epoch block is exported as raw EVM items, and the system contracts
+// are re-deployed with an empty storage, so the genesis transactions restore the SFC
+// from the exported validators and delegations. SFC parameters changed by the owner and
+// pending withdrawal requests aren't restored. Rules of the current epoch are exported.
+func (s *Store) ExportGenesis(epoch idx.Epoch, out *genesisstore.Store) error {
+	if epoch >= s.GetEpoch() {
+		return fmt.Errorf("epoch %d isn't sealed, current epoch is %d", epoch, s.GetEpoch())
+	}
+	n, block := s.findEpochLastBlock(epoch)
+	if block == nil {
+		return fmt.Errorf("last block of epoch %d not found", epoch)
+	}
+	statedb, err := s.evm.StateDB(block.Root)
+	if err != nil {
+		return fmt.Errorf("state of block %d isn't available: %v", n, err)
+	}
+	caller := &stateCaller{
+		reader:  &EvmStateReader{store: s},
+		header:  (&EvmStateReader{store: s}).GetHeader(common.Hash{}, uint64(n)),
+		statedb: statedb,
+		rules:   s.GetRules(),
+	}
+	sfcc, err := sfc100.NewContractCaller(sfc.ContractAddress, caller)
+	if err != nil {
+		return err
+	}
+	authc, err := driverauth100.NewContractCaller(driverauth.ContractAddress, caller)
+	if err != nil {
+		return err
+	}
+
+	s.Log.Info("Exporting validators", "epoch", epoch, "block", n)
+	validators, err := exportValidators(sfcc)
+	if err != nil {
+		return err
+	}
+	s.Log.Info("Exporting delegations", "validators", len(validators))
+	if err := s.checkLogsIndexed(n); err != nil {
+		return err
+	}
+	totalStake, err := s.exportDelegations(sfcc, validators, n, out)
+	if err != nil {
+		return err
+	}
+	snapshot, err := sfcc.GetEpochSnapshot(nil, big.NewInt(int64(epoch)))
+	if err != nil {
+		return err
+	}
+	driverOwner, err := authc.Owner(nil)
+	if err != nil {
+		return err
+	}
+
+	s.Log.Info("Exporting EVM state", "root", block.Root)
+	err = s.ForEachEvmStateItem(block.Root, func(key, value []byte) error {
+		out.SetRawEvmItem(key, value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// stakes are minted again by the genesis delegations
+	sfcBalance := new(big.Int).Sub(statedb.GetBalance(sfc.ContractAddress), totalStake)
+	if sfcBalance.Sign() < 0 {
+		return errors.New("total stake exceeds SFC balance")
+	}
+	redeploy := func(addr common.Address, code []byte, balance *big.Int) {
+		out.SetEvmAccount(addr, genesis.Account{
+			Code:         code,
+			Balance:      balance,
+			Nonce:        statedb.GetNonce(addr),
+			SelfDestruct: true,
+		})
+	}
+	redeploy(netinit.ContractAddress, netinit.GetContractBin(), statedb.GetBalance(netinit.ContractAddress))
+	redeploy(sfc.ContractAddress, statedb.GetCode(sfc.ContractAddress), sfcBalance)
+	redeploy(driver.ContractAddress, statedb.GetCode(driver.ContractAddress), statedb.GetBalance(driver.ContractAddress))
+	redeploy(driverauth.ContractAddress, statedb.GetCode(driverauth.ContractAddress), statedb.GetBalance(driverauth.ContractAddress))
+
+	genesisBlock, err := s.exportBlock(n, block)
+	if err != nil {
+		return err
+	}
+	out.SetBlock(n, genesisBlock)
+
+	var extra []byte
+	if h := s.GetGenesisHash(); h != nil {
+		extra = append(extra, h.Bytes()...)
+	}
+	out.SetRules(s.GetRules())
+	out.SetMetadata(genesisstore.Metadata{
+		Validators:    validators,
+		FirstEpoch:    epoch + 2,
+		Time:          block.Time + 1,
+		PrevEpochTime: block.Time,
+		ExtraData:     append(extra, epoch.Bytes()...),
+		DriverOwner:   driverOwner,
+		TotalSupply:   snapshot.TotalSupply,
+	})
+	return nil
+}
+
+// findEpochLastBlock returns the block which sealed the epoch.
+func (s *Store) findEpochLastBlock(epoch idx.Epoch) (idx.Block, *inter.Block) {
+	for n := s.GetLatestBlockIndex(); ; n-- {
+		block := s.GetBlock(n)
+		if block == nil || block.Atropos.Epoch() < epoch {
+			return 0, nil
+		}
+		if block.Atropos.Epoch() == epoch {
+			return n, block
+		}
+		if n == 0 {
+			return 0, nil
+		}
+	}
+}
+
+func exportValidators(sfcc *sfc100.ContractCaller) (gpos.Validators, error) {
+	lastID, err := sfcc.LastValidatorID(nil)
+	if err != nil {
+		return nil, err
+	}
+	validators := make(gpos.Validators, 0, lastID.Uint64())
+	for id := uint64(1); id <= lastID.Uint64(); id++ {
+		v, err := sfcc.GetValidator(nil, new(big.Int).SetUint64(id))
+		if err != nil {
+			return nil, err
+		}
+		if v.CreatedTime.Sign() == 0 {
+			// validator doesn't exist
+			continue
+		}
+		pkBytes, err := sfcc.GetValidatorPubkey(nil, new(big.Int).SetUint64(id))
+		if err != nil {
+			return nil, err
+		}
+		pk, err := validatorpk.FromBytes(pkBytes)
+		if err != nil {
+			return nil, fmt.Errorf("validator %d pubkey: %v", id, err)
+		}
+		validators = append(validators, gpos.Validator{
+			ID:               idx.ValidatorID(id),
+			Address:          v.Auth,
+			PubKey:           pk,
+			CreationTime:     inter.FromUnix(v.CreatedTime.Int64()),
+			CreationEpoch:    idx.Epoch(v.CreatedEpoch.Uint64()),
+			DeactivatedTime:  inter.FromUnix(v.DeactivatedTime.Int64()),
+			DeactivatedEpoch: idx.Epoch(v.DeactivatedEpoch.Uint64()),
+			Status:           v.Status.Uint64(),
+		})
+	}
+	return validators, nil
+}
+
+// checkLogsIndexed ensures that the logs index isn't disabled or behind the block,
+// i.e. all the logs of the latest block with logs are indexed.
+func (s *Store) checkLogsIndexed(to idx.Block) error {
+	lowest := idx.Block(0)
+	if genesis := s.GetGenesisBlockIndex(); genesis != nil {
+		lowest = *genesis
+	}
+	for n := to; n >= lowest; n-- {
+		var addresses []common.Hash
+		seen := make(map[common.Address]bool)
+		logs := 0
+		for _, r := range s.evm.GetReceipts(n) {
+			for _, l := range r.Logs {
+				if !seen[l.Address] {
+					seen[l.Address] = true
+					addresses = append(addresses, l.Address.Hash())
+				}
+				logs++
+			}
+		}
+		if logs != 0 {
+			indexed := 0
+			_, err := s.evm.EvmLogs().ForEachInBlocks(context.Background(), n, n, [][]common.Hash{addresses}, topicsdb.Budget{},
+				func(*types.Log) bool {
+					indexed++
+					return true
+				})
+			if err != nil {
+				return err
+			}
+			if indexed != logs {
+				return fmt.Errorf("logs index doesn't cover block %d (%d of %d logs are indexed), delegations cannot be exported", n, indexed, logs)
+			}
+			return nil
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// exportDelegations writes non-empty delegations found by Delegated SFC logs and returns their total stake.
+// Stakes of the found delegations must sum up to the stakes received by the validators,
+// otherwise some delegations are missing in the logs index.
+func (s *Store) exportDelegations(sfcc *sfc100.ContractCaller, validators gpos.Validators, to idx.Block, out *genesisstore.Store) (*big.Int, error) {
+	type delegationID struct {
+		addr common.Address
+		to   idx.ValidatorID
+	}
+	var ids []delegationID
+	seen := make(map[delegationID]bool)
+	_, err := s.evm.EvmLogs().ForEachInBlocks(context.Background(), 0, to,
+		[][]common.Hash{{sfc.ContractAddress.Hash()}, {sfcapi.Topics.Delegated}},
+		topicsdb.Budget{},
+		func(l *types.Log) bool {
+			if len(l.Topics) < 3 {
+				return true
+			}
+			id := delegationID{
+				addr: common.BytesToAddress(l.Topics[1][12:]),
+				to:   idx.ValidatorID(new(big.Int).SetBytes(l.Topics[2][:]).Uint64()),
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	totalStake := new(big.Int)
+	receivedStakes := make(map[idx.ValidatorID]*big.Int)
+	reported := time.Now()
+	for i, id := range ids {
+		toID := big.NewInt(int64(id.to))
+		stake, err := sfcc.GetStake(nil, id.addr, toID)
+		if err != nil {
+			return nil, err
+		}
+		if stake.Sign() == 0 {
+			continue
+		}
+		rewards, err := sfcc.PendingRewards(nil, id.addr, toID)
+		if err != nil {
+			return nil, err
+		}
+		lockup, err := sfcc.GetLockupInfo(nil, id.addr, toID)
+		if err != nil {
+			return nil, err
+		}
+		stashed, err := sfcc.GetStashedLockupRewards(nil, id.addr, toID)
+		if err != nil {
+			return nil, err
+		}
+		// lockup times are kept in SFC units
+		out.SetDelegation(id.addr, id.to, genesis.Delegation{
+			Stake:              stake,
+			Rewards:            rewards,
+			LockedStake:        lockup.LockedStake,
+			LockupFromEpoch:    idx.Epoch(lockup.FromEpoch.Uint64()),
+			LockupEndTime:      inter.Timestamp(lockup.EndTime.Uint64()),
+			LockupDuration:     inter.Timestamp(lockup.Duration.Uint64()),
+			EarlyUnlockPenalty: stashed.LockupExtraReward,
+		})
+		totalStake.Add(totalStake, stake)
+		if receivedStakes[id.to] == nil {
+			receivedStakes[id.to] = new(big.Int)
+		}
+		receivedStakes[id.to].Add(receivedStakes[id.to], stake)
+		if time.Since(reported) >= exportReportPeriod {
+			s.Log.Info("Exporting delegations", "done", i+1, "total", len(ids))
+			reported = time.Now()
+		}
+	}
+
+	for _, v := range validators {
+		info, err := sfcc.GetValidator(nil, big.NewInt(int64(v.ID)))
+		if err != nil {
+			return nil, err
+		}
+		found := receivedStakes[v.ID]
+		if found == nil {
+			found = new(big.Int)
+		}
+		if found.Cmp(info.ReceivedStake) != 0 {
+			return nil, fmt.Errorf("delegations to validator %d are missing in the logs index, found stake %s of %s", v.ID, found, info.ReceivedStake)
+		}
+	}
+	expTotalStake, err := sfcc.TotalStake(nil)
+	if err != nil {
+		return nil, err
+	}
+	if totalStake.Cmp(expTotalStake) != 0 {
+		return nil, fmt.Errorf("delegations are missing in the logs index, found stake %s of %s", totalStake, expTotalStake)
+	}
+	return totalStake, nil
+}
+
+// ForEachEvmStateItem calls fn for every trie node and contract code reachable from the state root.
+// Keys are the same as in the EVM DB, i.e. trie nodes are keyed by hash and codes are prefixed by "c".
+func (s *Store) ForEachEvmStateItem(root hash.Hash, fn func(key, value []byte) error) error {
+	evmd := s.evm.EvmDatabase()
+	trieDB := evmd.TrieDB()
+	copyNode := func(h common.Hash) error {
+		if h == (common.Hash{}) {
+			// embedded node
+			return nil
+		}
+		blob, err := trieDB.Node(h)
+		if err != nil {
+			return err
+		}
+		return fn(h.Bytes(), blob)
+	}
+
+	stateTrie, err := evmd.OpenTrie(common.Hash(root))
+	if err != nil {
+		return err
+	}
+	nodes, reported := 0, time.Now()
+	stateIt := stateTrie.NodeIterator(nil)
+	for stateIt.Next(true) {
+		if err := copyNode(stateIt.Hash()); err != nil {
+			return err
+		}
+		nodes++
+		if !stateIt.Leaf() {
+			continue
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(stateIt.LeafBlob(), &account); err != nil {
+			return err
+		}
+		addrHash := common.BytesToHash(stateIt.LeafKey())
+		codeHash := common.BytesToHash(account.CodeHash)
+		if codeHash != emptyCodeHash {
+			code, err := evmd.ContractCode(addrHash, codeHash)
+			if err != nil {
+				return fmt.Errorf("code %s: %v", codeHash.String(), err)
+			}
+			if err := fn(append([]byte("c"), codeHash.Bytes()...), code); err != nil {
+				return err
+			}
+		}
+		if account.Root != types.EmptyRootHash {
+			storageTrie, err := evmd.OpenStorageTrie(addrHash, account.Root)
+			if err != nil {
+				return err
+			}
+			storageIt := storageTrie.NodeIterator(nil)
+			for storageIt.Next(true) {
+				if err := copyNode(storageIt.Hash()); err != nil {
+					return err
+				}
+				nodes++
+			}
+			if storageIt.Error() != nil {
+				return storageIt.Error()
+			}
+		}
+		if time.Since(reported) >= exportReportPeriod {
+			s.Log.Info("Exporting EVM state", "nodes", nodes)
+			reported = time.Now()
+		}
+	}
+	return stateIt.Error()
+}
+
+func (s *Store) exportBlock(n idx.Block, block *inter.Block) (genesis.Block, error) {
+	evmBlock := (&EvmStateReader{store: s}).GetBlock(common.Hash{}, uint64(n))
+	internal := make(map[common.Hash]bool, len(block.InternalTxs))
+	for _, txid := range block.InternalTxs {
+		internal[txid] = true
+	}
+	res := genesis.Block{
+		Time:        block.Time,
+		Atropos:     block.Atropos,
+		Txs:         types.Transactions{},
+		InternalTxs: types.Transactions{},
+		Root:        block.Root,
+		Receipts:    []*types.ReceiptForStorage{},
+	}
+	// internal txs go first
+	for _, tx := range evmBlock.Transactions {
+		if internal[tx.Hash()] {
+			res.InternalTxs = append(res.InternalTxs, tx)
+		} else {
+			res.Txs = append(res.Txs, tx)
+		}
+	}
+	receipts := s.evm.GetReceipts(n)
+	if len(receipts) != 0 && len(receipts) != len(evmBlock.Transactions) {
+		return res, fmt.Errorf("block %d has %d receipts for %d txs", n, len(receipts), len(evmBlock.Transactions))
+	}
+	for _, r := range receipts {
+		res.Receipts = append(res.Receipts, (*types.ReceiptForStorage)(r))
+	}
+	return res, nil
+}
+
+// stateCaller is a bind.ContractCaller which executes calls over a fixed EVM state.
+type stateCaller struct {
+	reader  *EvmStateReader
+	header  *evmcore.EvmHeader
+	statedb *state.StateDB
+	rules   opera.Rules
+}
+
+func (c *stateCaller) CodeAt(_ context.Context, contract common.Address, _ *big.Int) ([]byte, error) {
+	return c.statedb.GetCode(contract), nil
+}
+
+func (c *stateCaller) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	msg := types.NewMessage(call.From, call.To, 0, new(big.Int), 1e10, new(big.Int), call.Data, nil, false)
+
+	snapshot := c.statedb.Snapshot()
+	defer c.statedb.RevertToSnapshot(snapshot)
+
+	blockContext := evmcore.NewEVMBlockContext(c.header, c.reader, &common.Address{})
+	vmenv := vm.NewEVM(blockContext, evmcore.NewEVMTxContext(msg), c.statedb, c.rules.EvmChainConfig(), opera.DefaultVMConfig)
+	res, err := evmcore.NewStateTransition(vmenv, msg, new(evmcore.GasPool).AddGas(math.MaxUint64)).TransitionDb()
+	if err != nil {
+		return nil, err
+	}
+	if res.Failed() {
+		return nil, res.Err
+	}
+	return res.Return(), nil
+}
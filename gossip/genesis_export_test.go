@@ -0,0 +1,89 @@
+package gossip
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/contract/sfc100"
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/logger"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/sfc"
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+	"github.com/Fantom-foundation/go-opera/utils"
+)
+
+func TestStoreExportGenesis(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	genStore := makegenesis.FakeGenesisStore(genesisStakers, utils.ToFtm(genesisBalance), utils.ToFtm(genesisStake))
+	g := genStore.GetGenesis()
+	src := NewMemStore()
+	_, err := src.ApplyGenesis(DefaultBlockProc(g), g)
+	require.NoError(err)
+
+	sealed := src.GetEpoch() - 1
+	require.Error(src.ExportGenesis(src.GetEpoch(), genesisstore.NewMemStore()))
+
+	out := genesisstore.NewMemStore()
+	require.NoError(src.ExportGenesis(sealed, out))
+	exported := out.GetGenesis()
+	require.Len(exported.Validators, genesisStakers)
+
+	dst := NewMemStore()
+	_, err = dst.ApplyGenesis(DefaultBlockProc(exported), exported)
+	require.NoError(err)
+	require.Equal(sealed+2, dst.GetEpoch())
+
+	// validators of the next epoch are the same
+	require.Equal(src.GetValidators().SortedIDs(), dst.GetValidators().SortedIDs())
+	require.Equal(src.GetValidators().SortedWeights(), dst.GetValidators().SortedWeights())
+
+	caller := func(s *Store) (*stateCaller, *sfc100.ContractCaller) {
+		n := s.GetLatestBlockIndex()
+		statedb, err := s.evm.StateDB(s.GetBlock(n).Root)
+		require.NoError(err)
+		c := &stateCaller{
+			reader:  &EvmStateReader{store: s},
+			header:  (&EvmStateReader{store: s}).GetHeader(common.Hash{}, uint64(n)),
+			statedb: statedb,
+			rules:   s.GetRules(),
+		}
+		sfcc, err := sfc100.NewContractCaller(sfc.ContractAddress, c)
+		require.NoError(err)
+		return c, sfcc
+	}
+	srcState, srcSfc := caller(src)
+	dstState, dstSfc := caller(dst)
+
+	// balances and stakes are preserved
+	require.Equal(srcState.statedb.GetBalance(sfc.ContractAddress), dstState.statedb.GetBalance(sfc.ContractAddress))
+	for _, v := range g.Validators {
+		require.Equal(srcState.statedb.GetBalance(v.Address), dstState.statedb.GetBalance(v.Address))
+		id := big.NewInt(int64(v.ID))
+		srcStake, err := srcSfc.GetStake(nil, v.Address, id)
+		require.NoError(err)
+		dstStake, err := dstSfc.GetStake(nil, v.Address, id)
+		require.NoError(err)
+		require.Equal(srcStake, dstStake)
+		require.NotZero(dstStake.Sign())
+	}
+	srcSnapshot, err := srcSfc.GetEpochSnapshot(nil, big.NewInt(int64(sealed)))
+	require.NoError(err)
+	dstSnapshot, err := dstSfc.GetEpochSnapshot(nil, big.NewInt(int64(sealed+1)))
+	require.NoError(err)
+	require.Equal(srcSnapshot.TotalSupply, dstSnapshot.TotalSupply)
+	require.Equal(srcSnapshot.TotalStake, dstSnapshot.TotalStake)
+
+	// delegations aren't exported if the logs index is behind
+	n, _ := src.findEpochLastBlock(sealed)
+	receipts := append(src.evm.GetReceipts(n), &types.Receipt{Logs: []*types.Log{{Address: sfc.ContractAddress}}})
+	src.evm.SetReceipts(n, receipts)
+	err = src.ExportGenesis(sealed, genesisstore.NewMemStore())
+	require.Error(err)
+	require.Contains(err.Error(), "logs index")
+}
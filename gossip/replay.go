@@ -0,0 +1,145 @@
+package gossip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc"
+	"github.com/Fantom-foundation/go-opera/inter"
+)
+
+// BlockReplay is the result of a re-executed block.
+type BlockReplay struct {
+	Index idx.Block
+	// Block is the stored block
+	Block *inter.Block
+	// Txs are the executed (not skipped) transactions
+	Txs        types.Transactions
+	Receipts   types.Receipts
+	SkippedTxs []uint32
+	GasUsed    uint64
+	Root       hash.Hash
+	Elapsed    time.Duration
+}
+
+// RootMismatch returns true if the re-executed state root differs from the stored one.
+func (r *BlockReplay) RootMismatch() bool {
+	return r.Root != r.Block.Root
+}
+
+// GasMismatch returns true if the re-executed gas usage differs from the stored one.
+func (r *BlockReplay) GasMismatch() bool {
+	return r.GasUsed != r.Block.GasUsed
+}
+
+// SkippedMismatch returns true if the re-executed skipped txs differ from the stored ones.
+func (r *BlockReplay) SkippedMismatch() bool {
+	if len(r.SkippedTxs) != len(r.Block.SkippedTxs) {
+		return true
+	}
+	for i, n := range r.SkippedTxs {
+		if n != r.Block.SkippedTxs[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Mismatch returns true if the re-executed block differs from the stored one.
+func (r *BlockReplay) Mismatch() bool {
+	return r.RootMismatch() || r.GasMismatch() || r.SkippedMismatch()
+}
+
+// ReplayBlocks re-executes the stored blocks [from, to] through the EVM module without writing anything into the DB.
+// Every block is executed on top of the stored state root of the previous block, so a mismatch doesn't propagate.
+// Note:
+//   - the current network rules are used for all the blocks
+//   - the internal txs of the driver and sealer modules are taken from the stored blocks, because the historical
+//     block and epoch states aren't stored
+func (s *Store) ReplayBlocks(evmModule blockproc.EVM, from, to idx.Block, onBlock func(*BlockReplay) error) error {
+	if genesis := s.GetGenesisBlockIndex(); genesis != nil && from <= *genesis {
+		// genesis blocks aren't executed on top of the previous state
+		from = *genesis + 1
+	}
+	if from == 0 {
+		from = 1
+	}
+	prev := s.GetBlock(from - 1)
+	if prev == nil {
+		return fmt.Errorf("block %d not found", from-1)
+	}
+	rules := s.GetRules()
+	reader := &EvmStateReader{store: s}
+	evmDB := s.evm.EvmDatabase()
+
+	for n := from; n <= to; n++ {
+		block := s.GetBlock(n)
+		if block == nil {
+			return fmt.Errorf("block %d not found", n)
+		}
+		internalTxs, txs, err := s.getReplayTxs(block)
+		if err != nil {
+			return fmt.Errorf("block %d: %v", n, err)
+		}
+		statedb, err := state.New(common.Hash(prev.Root), evmDB, nil)
+		if err != nil {
+			return fmt.Errorf("state of block %d: %v", n-1, err)
+		}
+
+		start := time.Now()
+		blockCtx := blockproc.BlockCtx{
+			Idx:     n,
+			Time:    block.Time,
+			Atropos: block.Atropos,
+		}
+		evmProcessor := evmModule.Start(blockCtx, statedb, reader, func(*types.Log) {}, nil, rules)
+		evmProcessor.Execute(internalTxs, true)
+		evmProcessor.Execute(txs, false)
+		evmBlock, skippedTxs, receipts := evmProcessor.Finalize()
+
+		res := &BlockReplay{
+			Index:      n,
+			Block:      block,
+			Txs:        evmBlock.Transactions,
+			Receipts:   receipts,
+			SkippedTxs: skippedTxs,
+			GasUsed:    evmBlock.GasUsed,
+			Root:       hash.Hash(evmBlock.Root),
+			Elapsed:    time.Since(start),
+		}
+		// drop the re-executed state from memory, the stored state remains on disk
+		evmDB.TrieDB().Dereference(evmBlock.Root)
+
+		if err := onBlock(res); err != nil {
+			return err
+		}
+		prev = block
+	}
+	return nil
+}
+
+// getReplayTxs returns the block txs in the order of execution.
+func (s *Store) getReplayTxs(block *inter.Block) (internalTxs, txs types.Transactions, err error) {
+	internalTxs, err = s.getTxs(block.InternalTxs)
+	if err != nil {
+		return nil, nil, err
+	}
+	txs, err = s.getTxs(block.Txs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range block.Events {
+		e := s.GetEventPayload(id)
+		if e == nil {
+			return nil, nil, fmt.Errorf("event %s not found", id.String())
+		}
+		txs = append(txs, e.Txs()...)
+	}
+	return internalTxs, txs, nil
+}
@@ -0,0 +1,102 @@
+package gossip
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc"
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/logger"
+	"github.com/Fantom-foundation/go-opera/utils"
+)
+
+func TestReplayBlocks(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	genStore := makegenesis.FakeGenesisStore(genesisStakers, utils.ToFtm(genesisBalance), utils.ToFtm(genesisStake))
+	g := genStore.GetGenesis()
+	store := NewMemStore()
+	blockProc := DefaultBlockProc(g)
+	_, err := store.ApplyGenesis(blockProc, g)
+	require.NoError(err)
+
+	// execute a block with an internal and a signed tx
+	prevIdx := store.GetLatestBlockIndex()
+	prev := store.GetBlock(prevIdx)
+	statedb, err := store.evm.StateDB(prev.Root)
+	require.NoError(err)
+	to := common.Address{1}
+	internalTx := types.NewTransaction(statedb.GetNonce(common.Address{}), to, big.NewInt(0), 1e6, big.NewInt(0), nil)
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	signer := types.LatestSignerForChainID(store.GetRules().EvmChainConfig().ChainID)
+	tx, err := types.SignTx(types.NewTransaction(0, to, big.NewInt(0), 1e6, big.NewInt(0), nil), signer, key)
+	require.NoError(err)
+
+	blockCtx := blockproc.BlockCtx{
+		Idx:     prevIdx + 1,
+		Time:    prev.Time + 1,
+		Atropos: hash.FakeEvent(),
+	}
+	evmProcessor := blockProc.EVMModule.Start(blockCtx, statedb, &EvmStateReader{store: store}, func(*types.Log) {}, nil, store.GetRules())
+	evmProcessor.Execute(types.Transactions{internalTx}, true)
+	evmProcessor.Execute(types.Transactions{tx}, false)
+	evmBlock, skippedTxs, _ := evmProcessor.Finalize()
+	block := &inter.Block{
+		Time:        blockCtx.Time,
+		Atropos:     blockCtx.Atropos,
+		InternalTxs: []common.Hash{internalTx.Hash()},
+		Txs:         []common.Hash{tx.Hash()},
+		SkippedTxs:  skippedTxs,
+		GasUsed:     evmBlock.GasUsed,
+		Root:        hash.Hash(evmBlock.Root),
+	}
+	store.evm.SetTx(internalTx.Hash(), internalTx)
+	store.evm.SetTx(tx.Hash(), tx)
+	store.SetBlock(blockCtx.Idx, block)
+	require.NoError(store.evm.Commit(block.Root))
+
+	replay := func() []*BlockReplay {
+		var res []*BlockReplay
+		require.NoError(store.ReplayBlocks(blockProc.EVMModule, 0, blockCtx.Idx, func(r *BlockReplay) error {
+			res = append(res, r)
+			return nil
+		}))
+		return res
+	}
+
+	// genesis blocks are skipped
+	res := replay()
+	require.Len(res, 1)
+	require.Equal(blockCtx.Idx, res[0].Index)
+	require.False(res[0].Mismatch())
+	require.Equal(len(evmBlock.Transactions), len(res[0].Txs))
+	require.Len(res[0].Receipts, len(res[0].Txs))
+
+	// replay doesn't modify the DB
+	require.Equal(block, store.GetBlock(blockCtx.Idx))
+
+	// mismatches are detected
+	tampered := *block
+	tampered.GasUsed++
+	tampered.Root = hash.Hash(hash.FakeHash())
+	store.SetBlock(blockCtx.Idx, &tampered)
+	res = replay()
+	require.True(res[0].GasMismatch())
+	require.True(res[0].RootMismatch())
+	require.False(res[0].SkippedMismatch())
+	require.Equal(block.Root, res[0].Root)
+
+	// missing blocks are reported
+	require.Error(store.ReplayBlocks(blockProc.EVMModule, blockCtx.Idx, blockCtx.Idx+1, func(*BlockReplay) error {
+		return nil
+	}))
+}
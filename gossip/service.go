@@ -1,10 +1,13 @@
 package gossip
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/Fantom-foundation/lachesis-base/hash"
 	"github.com/Fantom-foundation/lachesis-base/inter/dag"
@@ -38,6 +41,7 @@ import (
 	"github.com/Fantom-foundation/go-opera/gossip/blockproc/sealmodule"
 	"github.com/Fantom-foundation/go-opera/gossip/blockproc/verwatcher"
 	"github.com/Fantom-foundation/go-opera/gossip/emitter"
+	"github.com/Fantom-foundation/go-opera/gossip/emitter/lease"
 	"github.com/Fantom-foundation/go-opera/gossip/filters"
 	"github.com/Fantom-foundation/go-opera/gossip/gasprice"
 	"github.com/Fantom-foundation/go-opera/inter"
@@ -120,13 +124,16 @@ type Service struct {
 	engine              lachesis.Consensus
 	dagIndexer          *vecmt.Index
 	engineMu            *sync.RWMutex
-	emitter             *emitter.Emitter
+	emitters            []*emitter.Emitter
 	txpool              *evmcore.TxPool
 	heavyCheckReader    HeavyCheckReader
 	gasPowerCheckReader GasPowerCheckReader
 	checkers            *eventcheck.Checkers
 	uniqueEventIDs      uniqueID
 
+	// events removed by the startup recovery, which are processed again before the emitters start
+	recoveredEvents inter.EventPayloads
+
 	// version watcher
 	verWatcher *verwatcher.VerWarcher
 
@@ -231,7 +238,7 @@ func newService(config Config, store *Store, signer valkeystore.SignerI, blockPr
 	// create API backend
 	svc.EthAPI = &EthAPIBackend{config.ExtRPCEnabled, svc, stateReader, config.AllowUnprotectedTxs}
 
-	svc.emitter = svc.makeEmitter(signer)
+	svc.emitters = svc.makeEmitters(signer)
 
 	svc.verWatcher = verwatcher.New(config.VersionWatcher, verwatcher.NewStore(store.table.NetworkVersion))
 
@@ -255,10 +262,24 @@ func makeCheckers(heavyCheckCfg heavycheck.Config, chainID *big.Int, heavyCheckR
 	}
 }
 
-func (s *Service) makeEmitter(signer valkeystore.SignerI) *emitter.Emitter {
+// makeEmitters creates an emitter for each validator hosted by the node.
+// The signer has to hold the keys of all the validators.
+func (s *Service) makeEmitters(signer valkeystore.SignerI) []*emitter.Emitter {
+	configs := s.config.Emitters()
+	emitters := make([]*emitter.Emitter, len(configs))
+	for i, cfg := range configs {
+		emitters[i] = s.makeEmitter(cfg, signer)
+		if len(configs) > 1 {
+			emitters[i].SetName(fmt.Sprintf("Emitter-%d", cfg.Validator.ID))
+		}
+	}
+	return emitters
+}
+
+func (s *Service) makeEmitter(cfg emitter.Config, signer valkeystore.SignerI) *emitter.Emitter {
 	txSigner := gsignercache.Wrap(types.NewEIP2930Signer(s.store.GetRules().EvmChainConfig().ChainID))
 
-	return emitter.NewEmitter(s.config.Emitter, emitter.World{
+	world := emitter.World{
 		External: &emitterWorld{
 			s:       s,
 			Store:   s.store,
@@ -267,7 +288,27 @@ func (s *Service) makeEmitter(signer valkeystore.SignerI) *emitter.Emitter {
 		TxPool:   s.txpool,
 		Signer:   signer,
 		TxSigner: txSigner,
-	})
+	}
+	if failover := cfg.Failover; len(failover.LeasePath) != 0 {
+		holder := failover.LeaseHolder
+		if len(holder) == 0 {
+			// a restarted node waits for the expiration of its previous lease
+			host, _ := os.Hostname()
+			holder = fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+		}
+		world.Lease = lease.NewFileLease(failover.LeasePath, holder)
+	}
+	return emitter.NewEmitter(cfg, world)
+}
+
+// getEmitter returns the emitter of the hosted validator, or nil if the validator isn't hosted by the node.
+func (s *Service) getEmitter(validator idx.ValidatorID) *emitter.Emitter {
+	for i, cfg := range s.config.Emitters() {
+		if cfg.Validator.ID == validator {
+			return s.emitters[i]
+		}
+	}
+	return nil
 }
 
 // MakeProtocols constructs the P2P protocol definitions for `opera`.
@@ -334,6 +375,11 @@ func (s *Service) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "emitter",
+			Version:   "1.0",
+			Service:   NewPrivateEmitterAPI(s),
+			Public:    false,
 		},
 	}...)
 
@@ -353,13 +399,47 @@ func (s *Service) Start() error {
 
 	s.pm.Start(s.p2pServer.MaxPeers)
 
-	s.emitter.Start()
+	// emitters must see their own recovered events, otherwise they may emit a fork
+	s.processRecoveredEvents()
+
+	for _, em := range s.emitters {
+		em.Start()
+	}
 
 	s.verWatcher.Start()
 
 	return nil
 }
 
+// SetRecoveredEvents sets the events removed by the startup recovery, they are processed again on the service start.
+func (s *Service) SetRecoveredEvents(events inter.EventPayloads) {
+	s.recoveredEvents = events
+}
+
+// processRecoveredEvents enqueues the recovered events into DagProcessor and waits until they are processed.
+func (s *Service) processRecoveredEvents() {
+	if len(s.recoveredEvents) == 0 {
+		return
+	}
+	done := make(chan struct{})
+	err := s.pm.processor.Enqueue("", s.recoveredEvents.Bases(), true, nil, func() {
+		close(done)
+	})
+	if err != nil {
+		s.Log.Error("Failed to process recovered events", "err", err)
+		return
+	}
+	<-done
+	processed := 0
+	for _, e := range s.recoveredEvents {
+		if s.store.HasEvent(e.ID()) {
+			processed++
+		}
+	}
+	s.Log.Info("Recovered events are processed", "events", len(s.recoveredEvents), "processed", processed)
+	s.recoveredEvents = nil
+}
+
 // WaitBlockEnd waits until parallel block processing is complete (if any)
 func (s *Service) WaitBlockEnd() {
 	s.blockProcWg.Wait()
@@ -370,7 +450,9 @@ func (s *Service) Stop() error {
 	defer log.Info("Fantom service stopped")
 	s.verWatcher.Stop()
 	close(s.done)
-	s.emitter.Stop()
+	for _, em := range s.emitters {
+		em.Stop()
+	}
 	s.pm.Stop()
 	s.wg.Wait()
 	s.feed.scope.Close()
@@ -385,6 +467,22 @@ func (s *Service) Stop() error {
 	return s.store.Commit()
 }
 
+// WithFlushedDBs flushes all the DBs and calls fn while the DBs aren't written,
+// so the DBs on disk have the same flush ID during the call.
+func (s *Service) WithFlushedDBs(fn func() error) error {
+	s.engineMu.Lock()
+	defer s.engineMu.Unlock()
+	if s.stopped {
+		return errors.New("service is stopped")
+	}
+
+	s.blockProcWg.Wait()
+	if err := s.store.Commit(); err != nil {
+		return err
+	}
+	return fn()
+}
+
 // AccountManager return node's account manager
 func (s *Service) AccountManager() *accounts.Manager {
 	return s.accountManager
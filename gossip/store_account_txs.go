@@ -0,0 +1,65 @@
+package gossip
+
+import (
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/utils/gsignercache"
+)
+
+// indexAccountTxs adds not skipped transactions of the block into the histories of their senders and recipients.
+// Internal transactions aren't indexed.
+// The txs are expected to be in the same order as in the EVM block.
+func (s *Store) indexAccountTxs(n idx.Block, block *inter.Block, txs types.Transactions, signer types.Signer) {
+	internal := make(map[common.Hash]bool, len(block.InternalTxs))
+	for _, txid := range block.InternalTxs {
+		internal[txid] = true
+	}
+	for i, tx := range txs {
+		if internal[tx.Hash()] {
+			continue
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			s.Log.Warn("Failed to recover tx sender", "txid", tx.Hash().String(), "err", err)
+			continue
+		}
+		s.evm.IndexAccountTx(sender, n, uint32(i), tx.Hash())
+
+		recipient := tx.To()
+		if recipient == nil {
+			created := crypto.CreateAddress(sender, tx.Nonce())
+			recipient = &created
+		}
+		if *recipient != sender {
+			s.evm.IndexAccountTx(*recipient, n, uint32(i), tx.Hash())
+		}
+	}
+}
+
+// IndexAccountTxs rebuilds the account transactions index for the already processed blocks [from, to].
+// The onBlock callback is called after each block is indexed.
+func (s *Store) IndexAccountTxs(from, to idx.Block, onBlock func(n idx.Block, txs int)) {
+	signer := gsignercache.Wrap(types.LatestSignerForChainID(s.GetRules().EvmChainConfig().ChainID))
+	reader := &EvmStateReader{
+		store: s,
+	}
+	if last := s.GetLatestBlockIndex(); to > last {
+		to = last
+	}
+	for n := from; n <= to; n++ {
+		block := s.GetBlock(n)
+		if block == nil {
+			continue
+		}
+		evmBlock := reader.getBlock(hash.Event{}, n, true)
+		s.indexAccountTxs(n, block, evmBlock.Transactions, signer)
+		if onBlock != nil {
+			onBlock(n, len(evmBlock.Transactions))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package gossip
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/logger"
+)
+
+func TestStoreIndexAccountTxs(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := NewMemStore()
+	signer := types.NewEIP155Signer(big.NewInt(1))
+
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	sign := func(tx *types.Transaction) *types.Transaction {
+		signed, err := types.SignTx(tx, signer, key)
+		require.NoError(err)
+		return signed
+	}
+
+	recipient := common.Address{1}
+	internalTx := types.NewTransaction(0, recipient, big.NewInt(0), 0, big.NewInt(0), nil)
+	transfer := sign(types.NewTransaction(0, recipient, big.NewInt(1), 21000, big.NewInt(1), nil))
+	creation := sign(types.NewContractCreation(1, big.NewInt(0), 100000, big.NewInt(1), nil))
+	selfTransfer := sign(types.NewTransaction(2, sender, big.NewInt(1), 21000, big.NewInt(1), nil))
+
+	block := &inter.Block{
+		InternalTxs: []common.Hash{internalTx.Hash()},
+	}
+	txs := types.Transactions{internalTx, transfer, creation, selfTransfer}
+	store.indexAccountTxs(5, block, txs, signer)
+
+	history := func(addr common.Address) []evmstore.AccountTxPosition {
+		var got []evmstore.AccountTxPosition
+		store.EvmStore().ForEachAccountTx(addr, 0, 0, func(p evmstore.AccountTxPosition) bool {
+			got = append(got, p)
+			return true
+		})
+		return got
+	}
+
+	require.Equal([]evmstore.AccountTxPosition{
+		{Block: 5, BlockOffset: 1, TxHash: transfer.Hash()},
+		{Block: 5, BlockOffset: 2, TxHash: creation.Hash()},
+		{Block: 5, BlockOffset: 3, TxHash: selfTransfer.Hash()},
+	}, history(sender))
+	require.Equal([]evmstore.AccountTxPosition{
+		{Block: 5, BlockOffset: 1, TxHash: transfer.Hash()},
+	}, history(recipient))
+	require.Equal([]evmstore.AccountTxPosition{
+		{Block: 5, BlockOffset: 2, TxHash: creation.Hash()},
+	}, history(crypto.CreateAddress(sender, 1)))
+	require.Empty(history(common.Address{}))
+}
@@ -0,0 +1,213 @@
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
+	"github.com/Fantom-foundation/go-opera/inter"
+)
+
+// BlockRecord is a finalized block with its transactions and the events it consists of.
+type BlockRecord struct {
+	Index       idx.Block
+	Block       *inter.Block
+	InternalTxs types.Transactions
+	Txs         types.Transactions
+	Events      inter.EventPayloads
+}
+
+// ReceiptsRecord is the receipts of a finalized block.
+type ReceiptsRecord struct {
+	Index    idx.Block
+	Receipts []*types.ReceiptForStorage
+}
+
+// GetBlockRecord returns the block with its transactions and events, or nil if the block isn't found.
+func (s *Store) GetBlockRecord(n idx.Block) (*BlockRecord, error) {
+	block := s.GetBlock(n)
+	if block == nil {
+		return nil, nil
+	}
+	rec := &BlockRecord{
+		Index:  n,
+		Block:  block,
+		Events: make(inter.EventPayloads, len(block.Events)),
+	}
+	var err error
+	rec.InternalTxs, err = s.getTxs(block.InternalTxs)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %v", n, err)
+	}
+	rec.Txs, err = s.getTxs(block.Txs)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %v", n, err)
+	}
+	for i, id := range block.Events {
+		rec.Events[i] = s.GetEventPayload(id)
+		if rec.Events[i] == nil {
+			return nil, fmt.Errorf("block %d: event %s not found", n, id.String())
+		}
+	}
+	return rec, nil
+}
+
+// getTxs returns the stored txs by their hashes.
+func (s *Store) getTxs(txids []common.Hash) (types.Transactions, error) {
+	txs := make(types.Transactions, len(txids))
+	for i, txid := range txids {
+		txs[i] = s.evm.GetTx(txid)
+		if txs[i] == nil {
+			return nil, fmt.Errorf("tx %s not found", txid.String())
+		}
+	}
+	return txs, nil
+}
+
+// GetReceiptsRecord returns the block receipts, or nil if the block has no receipts.
+func (s *Store) GetReceiptsRecord(n idx.Block) *ReceiptsRecord {
+	receipts := s.evm.GetReceipts(n)
+	if receipts == nil {
+		return nil
+	}
+	rec := &ReceiptsRecord{
+		Index:    n,
+		Receipts: make([]*types.ReceiptForStorage, len(receipts)),
+	}
+	for i, r := range receipts {
+		rec.Receipts[i] = (*types.ReceiptForStorage)(r)
+	}
+	return rec
+}
+
+// ImportBlockRecord writes the block, its transactions and events as they are, without running consensus.
+// Existing blocks aren't overwritten.
+func (s *Store) ImportBlockRecord(rec *BlockRecord) (bool, error) {
+	if s.GetBlock(rec.Index) != nil {
+		return false, nil
+	}
+	if err := checkBlockRecord(rec); err != nil {
+		return false, fmt.Errorf("block %d: %v", rec.Index, err)
+	}
+	for _, tx := range rec.InternalTxs {
+		s.evm.SetTx(tx.Hash(), tx)
+	}
+	for _, tx := range rec.Txs {
+		s.evm.SetTx(tx.Hash(), tx)
+	}
+	// memorize event position of each tx
+	txPositions := make(map[common.Hash]evmstore.TxPosition)
+	for _, e := range rec.Events {
+		s.SetEvent(e)
+		for i, tx := range e.Txs() {
+			// If tx was met in multiple events, then assign to first ordered event
+			if _, ok := txPositions[tx.Hash()]; ok {
+				continue
+			}
+			txPositions[tx.Hash()] = evmstore.TxPosition{
+				Event:       e.ID(),
+				EventOffset: uint32(i),
+			}
+		}
+	}
+	s.SetBlock(rec.Index, rec.Block)
+	s.SetBlockIndex(rec.Block.Atropos, rec.Index)
+
+	// memorize block position of each not skipped tx
+	evmBlock := (&EvmStateReader{store: s}).GetBlock(common.Hash{}, uint64(rec.Index))
+	for i, tx := range evmBlock.Transactions {
+		position := txPositions[tx.Hash()]
+		position.Block = rec.Index
+		position.BlockOffset = uint32(i)
+		s.evm.SetTxPosition(tx.Hash(), position)
+	}
+	return true, nil
+}
+
+// checkBlockRecord checks that the record txs and events are the ones the block refers to.
+func checkBlockRecord(rec *BlockRecord) error {
+	if rec.Block == nil {
+		return errors.New("no block")
+	}
+	checkTxs := func(txids []common.Hash, txs types.Transactions) error {
+		if len(txids) != len(txs) {
+			return fmt.Errorf("expected %d txs, got %d", len(txids), len(txs))
+		}
+		for i, tx := range txs {
+			if tx.Hash() != txids[i] {
+				return fmt.Errorf("expected tx %s, got %s", txids[i].String(), tx.Hash().String())
+			}
+		}
+		return nil
+	}
+	if err := checkTxs(rec.Block.InternalTxs, rec.InternalTxs); err != nil {
+		return fmt.Errorf("internal txs: %v", err)
+	}
+	if err := checkTxs(rec.Block.Txs, rec.Txs); err != nil {
+		return err
+	}
+	if len(rec.Block.Events) != len(rec.Events) {
+		return fmt.Errorf("expected %d events, got %d", len(rec.Block.Events), len(rec.Events))
+	}
+	for i, e := range rec.Events {
+		if e.ID() != rec.Block.Events[i] {
+			return fmt.Errorf("expected event %s, got %s", rec.Block.Events[i].String(), e.ID().String())
+		}
+	}
+	return nil
+}
+
+// ImportReceiptsRecord writes the block receipts and indexes their logs.
+// Logs are indexed only if the block is already imported, because they are derived from the block transactions.
+func (s *Store) ImportReceiptsRecord(rec *ReceiptsRecord) (logsIndexed bool, err error) {
+	s.evm.SetRawReceipts(rec.Index, rec.Receipts)
+	evmBlock := (&EvmStateReader{store: s}).GetBlock(common.Hash{}, uint64(rec.Index))
+	if evmBlock == nil {
+		return false, nil
+	}
+	receipts := s.evm.GetReceipts(rec.Index)
+	err = receipts.DeriveFields(s.GetRules().EvmChainConfig(), evmBlock.Hash, uint64(rec.Index), evmBlock.Transactions)
+	if err != nil {
+		return false, fmt.Errorf("block %d: %v", rec.Index, err)
+	}
+	for _, r := range receipts {
+		s.evm.IndexLogs(r.Logs...)
+	}
+	return true, nil
+}
+
+// FindEpochBlocks returns the range of blocks which were created in the range of epochs.
+func (s *Store) FindEpochBlocks(from, to idx.Epoch) (first, last idx.Block, ok bool) {
+	lowest := idx.Block(0)
+	if genesis := s.GetGenesisBlockIndex(); genesis != nil {
+		lowest = *genesis
+	}
+	latest := s.GetLatestBlockIndex()
+	if latest < lowest {
+		return 0, 0, false
+	}
+	// epochs of blocks are non-decreasing
+	epochOf := func(i int) idx.Epoch {
+		block := s.GetBlock(lowest + idx.Block(i))
+		if block == nil {
+			return 0
+		}
+		return block.Atropos.Epoch()
+	}
+	num := int(latest-lowest) + 1
+	firstI := sort.Search(num, func(i int) bool {
+		return epochOf(i) >= from
+	})
+	lastI := sort.Search(num, func(i int) bool {
+		return epochOf(i) > to
+	}) - 1
+	if firstI >= num || lastI < firstI {
+		return 0, 0, false
+	}
+	return lowest + idx.Block(firstI), lowest + idx.Block(lastI), true
+}
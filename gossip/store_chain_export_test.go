@@ -0,0 +1,139 @@
+package gossip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/logger"
+	"github.com/Fantom-foundation/go-opera/topicsdb"
+	"github.com/Fantom-foundation/go-opera/utils"
+)
+
+func TestStoreBlockRecords(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	genStore := makegenesis.FakeGenesisStore(genesisStakers, utils.ToFtm(genesisBalance), utils.ToFtm(genesisStake))
+	g := genStore.GetGenesis()
+	src := NewMemStore()
+	_, err := src.ApplyGenesis(DefaultBlockProc(g), g)
+	require.NoError(err)
+
+	dst := NewMemStore()
+	dst.SetBlockEpochState(src.GetBlockState(), src.GetEpochState())
+
+	latest := src.GetLatestBlockIndex()
+	first, last, ok := src.FindEpochBlocks(0, src.GetEpoch())
+	require.True(ok)
+	require.Equal(latest, last)
+	_, _, ok = src.FindEpochBlocks(src.GetEpoch()+1, src.GetEpoch()+2)
+	require.False(ok)
+
+	logs := 0
+	addrs := map[common.Address]bool{}
+	for n := first; n <= last; n++ {
+		rec, err := src.GetBlockRecord(n)
+		require.NoError(err)
+		require.NotNil(rec)
+		allTxs := append(append(types.Transactions{}, rec.InternalTxs...), rec.Txs...)
+		require.NotEmpty(allTxs)
+
+		// records which don't match the block are rejected
+		broken := *rec
+		broken.Txs = append(allTxs, allTxs[0])
+		_, err = dst.ImportBlockRecord(&broken)
+		require.Error(err)
+
+		imported, err := dst.ImportBlockRecord(rec)
+		require.NoError(err)
+		require.True(imported)
+		imported, err = dst.ImportBlockRecord(rec)
+		require.NoError(err)
+		require.False(imported)
+
+		got, err := dst.GetBlockRecord(n)
+		require.NoError(err)
+		require.Equal(rec.Block, got.Block)
+		require.Equal(rec.Events.IDs(), got.Events.IDs())
+		require.Equal(len(rec.InternalTxs), len(got.InternalTxs))
+		require.Equal(len(rec.Txs), len(got.Txs))
+
+		srcTxs := (&EvmStateReader{store: src}).GetBlock(common.Hash{}, uint64(n)).Transactions
+		dstTxs := (&EvmStateReader{store: dst}).GetBlock(common.Hash{}, uint64(n)).Transactions
+		require.Equal(len(srcTxs), len(dstTxs))
+		for i, tx := range dstTxs {
+			require.Equal(srcTxs[i].Hash(), tx.Hash())
+			require.Equal(src.evm.GetTxPosition(tx.Hash()), dst.evm.GetTxPosition(tx.Hash()))
+		}
+		require.Equal(n, *dst.GetBlockIndex(rec.Block.Atropos))
+
+		receipts := src.GetReceiptsRecord(n)
+		if receipts == nil {
+			continue
+		}
+		indexed, err := dst.ImportReceiptsRecord(receipts)
+		require.NoError(err)
+		require.True(indexed)
+		require.Equal(len(receipts.Receipts), len(dst.evm.GetReceipts(n)))
+		for _, r := range receipts.Receipts {
+			logs += len(r.Logs)
+			for _, l := range r.Logs {
+				addrs[l.Address] = true
+			}
+		}
+	}
+	require.NotZero(logs)
+
+	pattern := [][]common.Hash{{}}
+	for addr := range addrs {
+		pattern[0] = append(pattern[0], addr.Hash())
+	}
+	found := 0
+	_, err = dst.evm.EvmLogs().ForEachInBlocks(context.Background(), first, last, pattern, topicsdb.Budget{}, func(l *types.Log) bool {
+		require.NotEqual(common.Hash{}, l.TxHash)
+		found++
+		return true
+	})
+	require.NoError(err)
+	require.Equal(logs, found)
+
+	// receipts without a block aren't indexed
+	indexed, err := dst.ImportReceiptsRecord(&ReceiptsRecord{Index: last + 1})
+	require.NoError(err)
+	require.False(indexed)
+}
+
+func TestStoreBlockRecordsEvents(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	env := newTestEnv()
+	defer env.Close()
+	env.ApplyBlock(nextEpoch, env.Transfer(1, 2, utils.ToFtm(1)))
+	n := env.store.GetLatestBlockIndex()
+
+	rec, err := env.store.GetBlockRecord(n)
+	require.NoError(err)
+	require.NotEmpty(rec.Events)
+	require.Equal(rec.Block.Events, rec.Events.IDs())
+
+	dst := NewMemStore()
+	imported, err := dst.ImportBlockRecord(rec)
+	require.NoError(err)
+	require.True(imported)
+
+	srcTxs := env.GetEvmStateReader().GetBlock(common.Hash{}, uint64(n)).Transactions
+	dstTxs := (&EvmStateReader{store: dst}).GetBlock(common.Hash{}, uint64(n)).Transactions
+	require.NotEmpty(srcTxs)
+	require.Equal(len(srcTxs), len(dstTxs))
+	for i, tx := range dstTxs {
+		require.Equal(srcTxs[i].Hash(), tx.Hash())
+		require.Equal(env.store.evm.GetTxPosition(tx.Hash()), dst.evm.GetTxPosition(tx.Hash()))
+	}
+	require.NotNil(dst.GetEventPayload(rec.Events[0].ID()))
+}
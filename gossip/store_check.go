@@ -0,0 +1,274 @@
+package gossip
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/utils/concurrent"
+)
+
+// CheckConfig is a config of the DB consistency check.
+type CheckConfig struct {
+	// TxIndex enables checks of receipts and transaction positions
+	TxIndex bool
+	// Repair rewrites the inconsistent records of derivable indexes
+	Repair bool
+}
+
+// Inconsistency is a problem found by the DB consistency check.
+type Inconsistency struct {
+	Table    string
+	Msg      string
+	Repaired bool
+}
+
+func (i Inconsistency) String() string {
+	if i.Repaired {
+		return fmt.Sprintf("%s: %s (repaired)", i.Table, i.Msg)
+	}
+	return fmt.Sprintf("%s: %s", i.Table, i.Msg)
+}
+
+// CheckConsistency validates the cross-table consistency of the DB and calls onIssue for every found problem.
+// Indexes which may be derived from the blocks and events (block hashes, tx positions, DAG heads,
+// last events and highest lamport) are rewritten if cfg.Repair is set.
+// onBlock is called after each checked block, it may be nil.
+// It isn't safe for concurrent use with the events processing.
+func (s *Store) CheckConsistency(cfg CheckConfig, onIssue func(Inconsistency), onBlock func(n idx.Block)) {
+	report := func(table string, repaired bool, format string, args ...interface{}) {
+		onIssue(Inconsistency{
+			Table:    table,
+			Msg:      fmt.Sprintf(format, args...),
+			Repaired: repaired,
+		})
+	}
+
+	genesisIdx := s.GetGenesisBlockIndex()
+	if genesisIdx == nil {
+		report("Genesis", false, "genesis block index isn't written")
+		return
+	}
+
+	s.ForEachBlock(func(n idx.Block, block *inter.Block) {
+		s.checkBlock(cfg, n, block, *genesisIdx, report)
+		if onBlock != nil {
+			onBlock(n)
+		}
+	})
+	s.checkBlockHashes(cfg, report)
+	s.checkBlockEpochState(report)
+	s.checkDagIndexes(cfg, report)
+}
+
+type reportFn func(table string, repaired bool, format string, args ...interface{})
+
+func (s *Store) checkBlock(cfg CheckConfig, n idx.Block, block *inter.Block, genesisIdx idx.Block, report reportFn) {
+	// block hashes index
+	if got := s.GetBlockIndex(block.Atropos); got == nil || *got != n {
+		if cfg.Repair {
+			s.SetBlockIndex(block.Atropos, n)
+		}
+		report("BlockHashes", cfg.Repair, "block %d isn't indexed by atropos %s", n, block.Atropos.String())
+	}
+
+	// blocks before and including the genesis have no DAG events
+	if n > genesisIdx {
+		if atropos := s.GetEvent(block.Atropos); atropos == nil {
+			report("Blocks", false, "atropos %s of block %d not found", block.Atropos.String(), n)
+		} else if !atropos.NoTxs() && !containsEvent(block.Events, block.Atropos) {
+			// Events contain only the confirmed events with txs, so only an atropos without txs may be absent there
+			report("Blocks", false, "atropos %s isn't among the events of block %d", block.Atropos.String(), n)
+		}
+	}
+
+	events := make([]*inter.EventPayload, 0, len(block.Events))
+	for _, id := range block.Events {
+		e := s.GetEventPayload(id)
+		if e == nil {
+			report("Blocks", false, "event %s of block %d not found", id.String(), n)
+			continue
+		}
+		events = append(events, e)
+	}
+	if len(events) != len(block.Events) {
+		// txs of the block cannot be restored
+		return
+	}
+
+	if !cfg.TxIndex {
+		return
+	}
+
+	// restore the block txs and their positions in the same way as during the block processing
+	txs := make(types.Transactions, 0, len(block.InternalTxs)+len(block.Txs))
+	positions := make(map[common.Hash]evmstore.TxPosition)
+	for _, txids := range [][]common.Hash{block.InternalTxs, block.Txs} {
+		for _, txid := range txids {
+			tx := s.evm.GetTx(txid)
+			if tx == nil {
+				report("Txs", false, "tx %s of block %d not found", txid.String(), n)
+				return
+			}
+			txs = append(txs, tx)
+		}
+	}
+	for _, e := range events {
+		for i, tx := range e.Txs() {
+			if _, ok := positions[tx.Hash()]; !ok {
+				positions[tx.Hash()] = evmstore.TxPosition{
+					Event:       e.ID(),
+					EventOffset: uint32(i),
+				}
+			}
+		}
+		txs = append(txs, e.Txs()...)
+	}
+	txs = inter.FilterSkippedTxs(txs, block.SkippedTxs)
+
+	// genesis blocks may be written without receipts
+	if n > genesisIdx && len(txs) != 0 {
+		if receipts := s.evm.GetReceipts(n); len(receipts) != len(txs) {
+			report("Receipts", false, "block %d has %d receipts for %d txs", n, len(receipts), len(txs))
+		}
+	}
+
+	for i, tx := range txs {
+		exp := positions[tx.Hash()]
+		exp.Block = n
+		exp.BlockOffset = uint32(i)
+		if got := s.evm.GetTxPosition(tx.Hash()); got == nil || *got != exp {
+			if cfg.Repair {
+				s.evm.SetTxPosition(tx.Hash(), exp)
+			}
+			report("TxPositions", cfg.Repair, "wrong position of tx %s in block %d", tx.Hash().String(), n)
+		}
+	}
+}
+
+func containsEvent(events hash.Events, id hash.Event) bool {
+	for _, e := range events {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBlockHashes finds the block hashes which don't point to the blocks with the same atropos.
+func (s *Store) checkBlockHashes(cfg CheckConfig, report reportFn) {
+	var stale []hash.Event
+	it := s.table.BlockHashes.NewIterator(nil, nil)
+	for it.Next() {
+		id := hash.BytesToEvent(it.Key())
+		n := idx.BytesToBlock(it.Value())
+		if block := s.GetBlock(n); block == nil || block.Atropos != id {
+			stale = append(stale, id)
+		}
+	}
+	it.Release()
+
+	for _, id := range stale {
+		if cfg.Repair {
+			if err := s.table.BlockHashes.Delete(id.Bytes()); err != nil {
+				s.Log.Crit("Failed to erase key-value", "err", err)
+			}
+			s.cache.BlockHashes.Remove(id)
+		}
+		report("BlockHashes", cfg.Repair, "atropos %s doesn't point to a block with the same atropos", id.String())
+	}
+}
+
+// checkBlockEpochState ensures that the last block and its EVM state exist.
+func (s *Store) checkBlockEpochState(report reportFn) {
+	bs := s.GetBlockState()
+	last := s.GetBlock(bs.LastBlock.Idx)
+	if last == nil {
+		report("BlockEpochState", false, "last block %d not found", bs.LastBlock.Idx)
+		return
+	}
+	// block context of the genesis block has no atropos
+	if last.Atropos != bs.LastBlock.Atropos && bs.LastBlock.Atropos != (hash.Event{}) {
+		report("BlockEpochState", false, "last block %d atropos mismatch: %s != %s", bs.LastBlock.Idx, last.Atropos.String(), bs.LastBlock.Atropos.String())
+	}
+	if last.Root != bs.FinalizedStateRoot {
+		report("BlockEpochState", false, "last block %d root mismatch: %s != %s", bs.LastBlock.Idx, last.Root.String(), bs.FinalizedStateRoot.String())
+	}
+	if _, err := s.evm.EvmDatabase().OpenTrie(common.Hash(bs.FinalizedStateRoot)); err != nil {
+		report("EVM", false, "state of root %s not found: %v", bs.FinalizedStateRoot.String(), err)
+	}
+	if s.GetBlock(bs.LastBlock.Idx+1) != nil {
+		report("Blocks", false, "block %d is above the last block %d", bs.LastBlock.Idx+1, bs.LastBlock.Idx)
+	}
+}
+
+// checkDagIndexes compares the DAG heads, last events and highest lamport with the events of current epoch.
+func (s *Store) checkDagIndexes(cfg CheckConfig, report reportFn) {
+	epoch := s.GetEpoch()
+	s.loadEpochStore(epoch)
+	es := s.getEpochStore(epoch)
+	if es == nil {
+		report("Heads", false, "epoch %d DB isn't opened", epoch)
+		return
+	}
+
+	// events are ordered by lamport, so parents are processed before children
+	heads := concurrent.WrapEventsSet(hash.EventsSet{})
+	lasts := concurrent.WrapValidatorEventsSet(map[idx.ValidatorID]hash.Event{})
+	lastSeqs := map[idx.ValidatorID]idx.Event{}
+	highestLamport := idx.Lamport(0)
+	s.ForEachEpochEvent(epoch, func(e *inter.EventPayload) bool {
+		processEventHeads(heads, e)
+		processLastEvent(lasts, e)
+		lastSeqs[e.Creator()] = e.Seq()
+		if e.Lamport() > highestLamport {
+			highestLamport = e.Lamport()
+		}
+		return true
+	})
+
+	if got := s.GetHighestLamport(); got != highestLamport {
+		if cfg.Repair {
+			s.SetHighestLamport(highestLamport)
+			s.FlushHighestLamport()
+		}
+		report("HighestLamport", cfg.Repair, "highest lamport %d != %d", got, highestLamport)
+	}
+
+	gotHeads := es.GetHeads()
+	gotHeads.RLock()
+	headsOk := len(gotHeads.Val) == len(heads.Val)
+	for id := range heads.Val {
+		headsOk = headsOk && gotHeads.Val.Contains(id)
+	}
+	gotHeads.RUnlock()
+	if !headsOk {
+		if cfg.Repair {
+			es.SetHeads(heads)
+			es.FlushHeads()
+		}
+		report("Heads", cfg.Repair, "epoch %d heads mismatch the DAG", epoch)
+	}
+
+	// forks are possible, so last events are compared by seq
+	gotLasts := es.GetLastEvents()
+	gotLasts.RLock()
+	lastsOk := len(gotLasts.Val) == len(lastSeqs)
+	for creator, id := range gotLasts.Val {
+		e := s.GetEvent(id)
+		lastsOk = lastsOk && e != nil && e.Creator() == creator && e.Seq() == lastSeqs[creator]
+	}
+	gotLasts.RUnlock()
+	if !lastsOk {
+		if cfg.Repair {
+			es.SetLastEvents(lasts)
+			es.FlushLastEvents()
+		}
+		report("LastEvents", cfg.Repair, "epoch %d last events mismatch the DAG", epoch)
+	}
+}
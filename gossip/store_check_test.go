@@ -0,0 +1,127 @@
+package gossip
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc"
+	"github.com/Fantom-foundation/go-opera/gossip/evmstore"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/logger"
+	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/utils/concurrent"
+)
+
+func TestStoreCheckConsistency(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := NewMemStore()
+	const epoch = idx.Epoch(2)
+
+	newEvent := func(creator idx.ValidatorID, seq idx.Event, lamport idx.Lamport, parents hash.Events, txs types.Transactions) *inter.EventPayload {
+		me := inter.MutableEventPayload{}
+		me.SetEpoch(epoch)
+		me.SetCreator(creator)
+		me.SetSeq(seq)
+		me.SetLamport(lamport)
+		me.SetParents(parents)
+		me.SetTxs(txs)
+		me.SetTxHash(hash.Hash(types.DeriveSha(txs, new(trie.Trie))))
+		e := me.Build()
+		store.SetEvent(e)
+		return e
+	}
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	a := newEvent(1, 1, 1, nil, types.Transactions{tx})
+	b := newEvent(2, 1, 1, nil, nil)
+	c := newEvent(1, 2, 2, hash.Events{a.ID(), b.ID()}, nil)
+
+	root := hash.Hash(types.EmptyRootHash)
+	genesisAtropos := hash.Event{1}
+	store.SetGenesisBlockIndex(1)
+	store.SetBlock(1, &inter.Block{Atropos: genesisAtropos, Root: root})
+	store.SetBlockIndex(genesisAtropos, 1)
+	store.SetBlock(2, &inter.Block{Atropos: c.ID(), Events: hash.Events{a.ID()}, Root: root})
+	store.SetBlockIndex(c.ID(), 2)
+	store.EvmStore().SetTx(tx.Hash(), tx)
+	store.EvmStore().SetTxPosition(tx.Hash(), evmstore.TxPosition{Block: 2, Event: a.ID()})
+	store.EvmStore().SetReceipts(2, types.Receipts{{TxHash: tx.Hash(), Logs: []*types.Log{}}})
+	store.SetBlockEpochState(blockproc.BlockState{
+		LastBlock:          blockproc.BlockCtx{Idx: 2, Atropos: c.ID()},
+		FinalizedStateRoot: root,
+		DirtyRules:         opera.FakeNetRules(),
+	}, blockproc.EpochState{
+		Epoch: epoch,
+		Rules: opera.FakeNetRules(),
+	})
+	store.loadEpochStore(epoch)
+	store.SetHeads(epoch, concurrent.WrapEventsSet(hash.NewEventsSet(c.ID())))
+	store.SetLastEvents(epoch, concurrent.WrapValidatorEventsSet(map[idx.ValidatorID]hash.Event{1: c.ID(), 2: b.ID()}))
+	store.SetHighestLamport(2)
+
+	check := func(cfg CheckConfig) []Inconsistency {
+		var issues []Inconsistency
+		store.CheckConsistency(cfg, func(issue Inconsistency) {
+			issues = append(issues, issue)
+		}, nil)
+		return issues
+	}
+	require.Empty(check(CheckConfig{TxIndex: true}))
+
+	// corrupt the derivable indexes
+	store.SetHeads(epoch, concurrent.WrapEventsSet(hash.NewEventsSet(a.ID())))
+	store.SetLastEvents(epoch, concurrent.WrapValidatorEventsSet(map[idx.ValidatorID]hash.Event{1: a.ID()}))
+	store.SetHighestLamport(5)
+	store.EvmStore().SetTxPosition(tx.Hash(), evmstore.TxPosition{Block: 1})
+	store.SetBlockIndex(c.ID(), 3)
+	store.SetBlockIndex(hash.Event{2}, 1)
+
+	issues := check(CheckConfig{TxIndex: true})
+	tables := make([]string, len(issues))
+	for i, issue := range issues {
+		require.False(issue.Repaired)
+		tables[i] = issue.Table
+	}
+	require.ElementsMatch([]string{"BlockHashes", "TxPositions", "BlockHashes", "BlockHashes", "HighestLamport", "Heads", "LastEvents"}, tables)
+
+	issues = check(CheckConfig{TxIndex: true, Repair: true})
+	require.NotEmpty(issues)
+	for _, issue := range issues {
+		require.True(issue.Repaired)
+	}
+	require.Empty(check(CheckConfig{TxIndex: true}))
+
+	// the genesis block is written without receipts
+	genesisTx := types.NewTransaction(1, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	store.SetBlock(1, &inter.Block{Atropos: genesisAtropos, Txs: []common.Hash{genesisTx.Hash()}, Root: root})
+	store.EvmStore().SetTx(genesisTx.Hash(), genesisTx)
+	store.EvmStore().SetTxPosition(genesisTx.Hash(), evmstore.TxPosition{Block: 1})
+	require.Empty(check(CheckConfig{TxIndex: true}))
+
+	// non-derivable data isn't repaired
+	store.EvmStore().SetReceipts(2, types.Receipts{})
+	issues = check(CheckConfig{TxIndex: true, Repair: true})
+	require.Len(issues, 1)
+	require.Equal("Receipts", issues[0].Table)
+	require.False(issues[0].Repaired)
+
+	// atropos with txs has to be among the block events
+	checkBlock := func(block *inter.Block) []string {
+		var tables []string
+		store.checkBlock(CheckConfig{}, 2, block, 1, func(table string, repaired bool, format string, args ...interface{}) {
+			tables = append(tables, table)
+		})
+		return tables
+	}
+	require.Equal([]string{"BlockHashes"}, checkBlock(&inter.Block{Atropos: a.ID(), Events: hash.Events{a.ID()}}))
+	require.Equal([]string{"BlockHashes", "Blocks"}, checkBlock(&inter.Block{Atropos: a.ID()}))
+	require.Equal([]string{"BlockHashes", "Blocks"}, checkBlock(&inter.Block{Atropos: hash.Event{3}}))
+}
@@ -9,7 +9,11 @@ import (
 	"github.com/Fantom-foundation/go-opera/opera"
 )
 
-const sKey = "s"
+const (
+	sKey = "s"
+	// pKey is the BlockEpochState of the previous commit, which is restored if the last commit was interrupted
+	pKey = "p"
+)
 
 type BlockEpochState struct {
 	BlockState *blockproc.BlockState
@@ -34,11 +38,26 @@ func (s *Store) getBlockEpochState() BlockEpochState {
 	return *v
 }
 
-// FlushBlockEpochState stores the latest epoch and block state in DB
+// FlushBlockEpochState stores the latest epoch and block state in DB, keeping the previously stored state
 func (s *Store) FlushBlockEpochState() {
+	prev, err := s.table.BlockEpochState.Get([]byte(sKey))
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	if prev != nil {
+		if err := s.table.BlockEpochState.Put([]byte(pKey), prev); err != nil {
+			s.Log.Crit("Failed to put key-value", "err", err)
+		}
+	}
 	s.rlp.Set(s.table.BlockEpochState, []byte(sKey), s.getBlockEpochState())
 }
 
+// getPrevBlockEpochState returns the BlockEpochState stored by the previous commit, or nil if there's none
+func (s *Store) getPrevBlockEpochState() *BlockEpochState {
+	v, _ := s.rlp.Get(s.table.BlockEpochState, []byte(pKey), &BlockEpochState{}).(*BlockEpochState)
+	return v
+}
+
 // GetBlockState retrieves the latest block state
 func (s *Store) GetBlockState() blockproc.BlockState {
 	return *s.getBlockEpochState().BlockState
@@ -0,0 +1,96 @@
+package gossip
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Fantom-foundation/go-opera/evmcore"
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc"
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc/evmmodule"
+)
+
+// indexInternalTransfers stores internal transfers of not skipped transactions of the block.
+// The txs are expected to be in the same order as in the EVM block.
+func (s *Store) indexInternalTransfers(n idx.Block, txs types.Transactions, transfers map[common.Hash][]evmcore.InternalTransfer) {
+	if len(transfers) == 0 {
+		return
+	}
+	for i, tx := range txs {
+		if tt, ok := transfers[tx.Hash()]; ok {
+			s.evm.SetInternalTransfers(n, uint32(i), tx.Hash(), tt)
+		}
+	}
+}
+
+// IndexInternalTransfers rebuilds the internal transfers index for the already processed blocks [from, to]
+// by re-executing their transactions. The EVM state of the previous blocks must not be pruned.
+// Blocks whose re-execution results in a different state root aren't indexed and are reported to onMismatch.
+// The onBlock callback is called after each block is processed.
+func (s *Store) IndexInternalTransfers(from, to idx.Block, onBlock func(n idx.Block, txs int), onMismatch func(n idx.Block)) error {
+	if genesis := s.GetGenesisBlockIndex(); genesis != nil && from <= *genesis {
+		// genesis blocks have no previous state
+		from = *genesis + 1
+	}
+	if last := s.GetLatestBlockIndex(); to > last {
+		to = last
+	}
+	rules := s.GetRules()
+	reader := &EvmStateReader{
+		store: s,
+	}
+	evmModule := evmmodule.New()
+
+	for n := from; n <= to; n++ {
+		block := s.GetBlock(n)
+		prev := s.GetBlock(n - 1)
+		if block == nil || prev == nil {
+			continue
+		}
+		statedb, err := s.evm.StateDB(prev.Root)
+		if err != nil {
+			return fmt.Errorf("state of block %d isn't found: %v", n-1, err)
+		}
+
+		evmBlock := reader.getBlock(hash.Event{}, n, true)
+		internal := make(map[common.Hash]bool, len(block.InternalTxs))
+		for _, txid := range block.InternalTxs {
+			internal[txid] = true
+		}
+		var internalTxs, externalTxs types.Transactions
+		for _, tx := range evmBlock.Transactions {
+			if internal[tx.Hash()] {
+				internalTxs = append(internalTxs, tx)
+			} else {
+				externalTxs = append(externalTxs, tx)
+			}
+		}
+
+		blockCtx := blockproc.BlockCtx{
+			Idx:     n,
+			Time:    block.Time,
+			Atropos: block.Atropos,
+		}
+		transfers := make(map[common.Hash][]evmcore.InternalTransfer)
+		evmProcessor := evmModule.Start(blockCtx, statedb, reader, func(*types.Log) {}, func(txid common.Hash, tt []evmcore.InternalTransfer) {
+			transfers[txid] = tt
+		}, rules)
+		evmProcessor.Execute(internalTxs, true)
+		evmProcessor.Execute(externalTxs, false)
+
+		if root := statedb.IntermediateRoot(true); hash.Hash(root) != block.Root {
+			if onMismatch != nil {
+				onMismatch(n)
+			}
+			continue
+		}
+		s.indexInternalTransfers(n, evmBlock.Transactions, transfers)
+		if onBlock != nil {
+			onBlock(n, len(evmBlock.Transactions))
+		}
+	}
+	return nil
+}
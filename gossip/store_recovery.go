@@ -0,0 +1,93 @@
+package gossip
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/dag"
+
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/vecmt"
+)
+
+// CheckBlockEpochState validates the BlockEpochState against the stored blocks and the committed EVM state.
+func (s *Store) CheckBlockEpochState() []Inconsistency {
+	var issues []Inconsistency
+	s.checkBlockEpochState(func(table string, repaired bool, format string, args ...interface{}) {
+		issues = append(issues, Inconsistency{
+			Table:    table,
+			Msg:      fmt.Sprintf(format, args...),
+			Repaired: repaired,
+		})
+	})
+	return issues
+}
+
+// DanglingEvents returns the events of current epoch which aren't connected to the local DAG:
+// events with missing parents, events without vector clocks in the DAG index,
+// events rejected by isConnected, and all the descendants of them.
+// Events are returned in the processing order.
+func (s *Store) DanglingEvents(isConnected func(e *inter.EventPayload) bool) inter.EventPayloads {
+	epoch := s.GetEpoch()
+	s.loadEpochStore(epoch)
+	es := s.getEpochStore(epoch)
+
+	dagIndex := vecmt.NewIndex(func(err error) {
+		s.Log.Crit("DAG index error", "err", err)
+	}, vecmt.LiteConfig())
+	dagIndex.Reset(s.GetValidators(), es.table.DagIndex, func(id hash.Event) dag.Event {
+		return s.GetEvent(id)
+	})
+
+	// events are ordered by lamport, so parents are visited before children
+	dangling := make(hash.EventsSet)
+	var res inter.EventPayloads
+	s.ForEachEpochEvent(epoch, func(e *inter.EventPayload) bool {
+		connected := dagIndex.GetHighestBeforeTime(e.ID()) != nil && isConnected(e)
+		for _, p := range e.Parents() {
+			connected = connected && !dangling.Contains(p) && s.HasEvent(p)
+		}
+		if !connected {
+			dangling.Add(e.ID())
+			res = append(res, e)
+		}
+		return true
+	})
+	return res
+}
+
+// RemoveEvents erases the events of current epoch and rewrites the DAG heads, last events and highest lamport.
+// The vector clocks of the events aren't erased, they are overwritten if the events are processed again.
+func (s *Store) RemoveEvents(events inter.EventPayloads) {
+	for _, e := range events {
+		s.DelEvent(e.ID())
+	}
+	s.checkDagIndexes(CheckConfig{Repair: true}, func(string, bool, string, ...interface{}) {})
+}
+
+// RollbackBlockEpochState restores the BlockEpochState of the previous commit and erases the blocks above its last block,
+// so the blocks are processed again. Returns false if there's no previous BlockEpochState.
+func (s *Store) RollbackBlockEpochState() bool {
+	prev := s.getPrevBlockEpochState()
+	if prev == nil {
+		return false
+	}
+	for n := prev.BlockState.LastBlock.Idx + 1; ; n++ {
+		block := s.GetBlock(n)
+		if block == nil {
+			break
+		}
+		if err := s.table.BlockHashes.Delete(block.Atropos.Bytes()); err != nil {
+			s.Log.Crit("Failed to delete key", "err", err)
+		}
+		s.cache.BlockHashes.Remove(block.Atropos)
+		if err := s.table.Blocks.Delete(n.Bytes()); err != nil {
+			s.Log.Crit("Failed to delete key", "err", err)
+		}
+		s.cache.Blocks.Remove(n)
+	}
+	s.SetBlockEpochState(*prev.BlockState, *prev.EpochState)
+	// overwrite the rolled back state, so it's not kept as the previous one by the next commit
+	s.rlp.Set(s.table.BlockEpochState, []byte(sKey), prev)
+	return true
+}
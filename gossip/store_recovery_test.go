@@ -0,0 +1,124 @@
+package gossip
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/dag"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/inter/pos"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc"
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/logger"
+	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/utils"
+	"github.com/Fantom-foundation/go-opera/vecmt"
+)
+
+func TestStoreDanglingEvents(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	store := NewMemStore()
+	const epoch = idx.Epoch(2)
+	store.SetBlockEpochState(blockproc.BlockState{
+		DirtyRules: opera.FakeNetRules(),
+	}, blockproc.EpochState{
+		Epoch:      epoch,
+		Validators: pos.ArrayToValidators([]idx.ValidatorID{1, 2}, []pos.Weight{1, 1}),
+		Rules:      opera.FakeNetRules(),
+	})
+	store.loadEpochStore(epoch)
+
+	dagIndex := vecmt.NewIndex(func(err error) { panic(err) }, vecmt.LiteConfig())
+	dagIndex.Reset(store.GetValidators(), store.getEpochStore(epoch).table.DagIndex, func(id hash.Event) dag.Event {
+		return store.GetEvent(id)
+	})
+	newEvent := func(creator idx.ValidatorID, seq idx.Event, lamport idx.Lamport, parents hash.Events, indexed bool) *inter.EventPayload {
+		me := inter.MutableEventPayload{}
+		me.SetEpoch(epoch)
+		me.SetCreator(creator)
+		me.SetSeq(seq)
+		me.SetLamport(lamport)
+		me.SetParents(parents)
+		me.SetFrame(1)
+		e := me.Build()
+		store.SetEvent(e)
+		if indexed {
+			require.NoError(dagIndex.Add(e))
+			dagIndex.Flush()
+		}
+		return e
+	}
+	a := newEvent(1, 1, 1, nil, true)
+	b := newEvent(2, 1, 1, nil, true)
+	c := newEvent(1, 2, 2, hash.Events{a.ID(), b.ID()}, true)
+	d := newEvent(2, 2, 3, hash.Events{b.ID(), c.ID()}, true)
+	e := newEvent(1, 3, 4, hash.Events{c.ID(), d.ID()}, true)
+	// not indexed event
+	x := newEvent(2, 3, 5, hash.Events{d.ID(), e.ID()}, false)
+
+	all := func(*inter.EventPayload) bool { return true }
+	dangling := func(isConnected func(*inter.EventPayload) bool) hash.Events {
+		return store.DanglingEvents(isConnected).IDs()
+	}
+	require.Equal(hash.Events{x.ID()}, dangling(all))
+	// descendants of a not connected event are dangling
+	require.Equal(hash.Events{c.ID(), d.ID(), e.ID(), x.ID()}, dangling(func(e *inter.EventPayload) bool {
+		return e.ID() != c.ID()
+	}))
+
+	store.RemoveEvents(inter.EventPayloads{x})
+	require.Empty(dangling(all))
+	require.Equal(hash.Events{e.ID()}, store.GetHeadsSlice(epoch))
+	require.Equal(idx.Lamport(4), store.GetHighestLamport())
+	require.Equal(map[idx.ValidatorID]hash.Event{1: e.ID(), 2: d.ID()}, store.GetLastEvents(epoch).Val)
+
+	// missing parent
+	store.DelEvent(b.ID())
+	require.Equal(hash.Events{c.ID(), d.ID(), e.ID()}, dangling(all))
+}
+
+func TestStoreRollbackBlockEpochState(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	genStore := makegenesis.FakeGenesisStore(genesisStakers, utils.ToFtm(genesisBalance), utils.ToFtm(genesisStake))
+	g := genStore.GetGenesis()
+	store := NewMemStore()
+	require.False(store.RollbackBlockEpochState())
+	_, err := store.ApplyGenesis(DefaultBlockProc(g), g)
+	require.NoError(err)
+	require.NoError(store.Commit())
+	bs, es := store.GetBlockEpochState()
+	require.Empty(store.CheckBlockEpochState())
+
+	// the state of an interrupted commit refers to a block which isn't committed completely
+	block := &inter.Block{
+		Atropos: hash.FakeEvent(),
+		Root:    hash.Hash(hash.FakeHash()),
+	}
+	n := bs.LastBlock.Idx + 1
+	store.SetBlock(n, block)
+	store.SetBlockIndex(block.Atropos, n)
+	dirty := bs.Copy()
+	dirty.LastBlock = blockproc.BlockCtx{Idx: n, Atropos: block.Atropos}
+	dirty.FinalizedStateRoot = block.Root
+	store.SetBlockEpochState(dirty, es)
+	require.NoError(store.Commit())
+	require.NotEmpty(store.CheckBlockEpochState())
+
+	require.True(store.RollbackBlockEpochState())
+	require.Empty(store.CheckBlockEpochState())
+	require.Equal(bs.LastBlock, store.GetBlockState().LastBlock)
+	require.Nil(store.GetBlock(n))
+	require.Nil(store.GetBlockIndex(block.Atropos))
+
+	// the rolled back state isn't kept by the next commit
+	require.NoError(store.Commit())
+	require.True(store.RollbackBlockEpochState())
+	require.Equal(bs.LastBlock, store.GetBlockState().LastBlock)
+}
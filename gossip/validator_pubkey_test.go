@@ -0,0 +1,57 @@
+package gossip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/evmcore"
+	"github.com/Fantom-foundation/go-opera/gossip/blockproc/drivermodule"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/logger"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driver/drivercall"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driverauth"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/sfc"
+)
+
+func TestUpdateValidatorPubkeyCall(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	env := newTestEnv()
+	defer env.Close()
+
+	pubkey := validatorpk.PubKey{
+		Type: validatorpk.Types.Secp256k1,
+		Raw:  common.FromHex("04f1a3a3e3a5a3c7a2a5e4c6e0c1f2d3b4a5968778695a4b3c2d1e0f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f7"),
+	}
+	to := driverauth.ContractAddress
+	data := drivercall.UpdateValidatorPubkey(1, pubkey)
+	call := func(from common.Address) (bool, int) {
+		h := env.GetEvmStateReader().GetHeader(common.Hash{}, uint64(env.lastBlock))
+		statedb := env.State()
+		_, _, failed, err := env.callContract(context.Background(), ethereum.CallMsg{From: from, To: &to, Data: data}, &evmcore.EvmBlock{EvmHeader: *h}, statedb)
+		require.NoError(err)
+		logs := statedb.Logs()
+		if len(logs) != 0 {
+			validatorID, got, err := drivermodule.DecodeUpdateValidatorPubkey(logs[0])
+			require.NoError(err)
+			require.Equal(idx.ValidatorID(1), validatorID)
+			require.Equal(pubkey, got)
+		}
+		return failed, len(logs)
+	}
+
+	// only SFC is allowed to update the key
+	failed, logs := call(env.Address(1))
+	require.True(failed)
+	require.Equal(0, logs)
+
+	failed, logs = call(sfc.ContractAddress)
+	require.False(failed)
+	require.Equal(1, logs)
+}
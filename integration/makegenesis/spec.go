@@ -0,0 +1,224 @@
+package makegenesis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/opera/genesis"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driver"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/driverauth"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/evmwriter"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/gpos"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/netinit"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/sfc"
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+)
+
+// Spec is a declarative description of a genesis of a private network.
+type Spec struct {
+	// BaseRules is the name of the rules preset: "main", "test" or "fake"
+	BaseRules string
+	// Rules overrides fields of the preset, it has the same layout as JSON of opera.Rules
+	Rules map[string]interface{}
+	// Time is the genesis UNIX time in seconds
+	Time uint64
+	// ExtraData is an arbitrary data which makes the genesis hash unique
+	ExtraData string
+	// DriverOwner is the owner of NodeDriverAuth and SFC, it's the first validator if not set
+	DriverOwner *common.Address
+
+	Validators []ValidatorSpec
+	Accounts   []AccountSpec
+}
+
+// ValidatorSpec is a genesis validator.
+type ValidatorSpec struct {
+	// ID is the validator ID, it's the position in the list (starting from 1) if not set
+	ID idx.ValidatorID
+	// Address is the validator's auth address, it's derived from PubKey if not set
+	Address common.Address
+	PubKey  validatorpk.PubKey
+	// Stake is the self-stake, which is minted into the SFC
+	Stake *math.HexOrDecimal256
+}
+
+// AccountSpec is a genesis account with its balance, code and storage.
+type AccountSpec struct {
+	Address common.Address
+	Balance *math.HexOrDecimal256
+	Nonce   uint64
+	Code    hexutil.Bytes
+	Storage map[common.Hash]common.Hash
+}
+
+// BaseRules returns the rules preset by its name.
+func BaseRules(name string) (opera.Rules, error) {
+	switch name {
+	case "main":
+		return opera.MainNetRules(), nil
+	case "test":
+		return opera.TestNetRules(), nil
+	case "fake", "":
+		return opera.FakeNetRules(), nil
+	default:
+		return opera.Rules{}, fmt.Errorf("unknown base rules '%s'", name)
+	}
+}
+
+// BuildRules applies the spec rules overrides to the base rules.
+func (spec *Spec) BuildRules() (opera.Rules, error) {
+	rules, err := BaseRules(spec.BaseRules)
+	if err != nil {
+		return rules, err
+	}
+	if len(spec.Rules) == 0 {
+		return rules, nil
+	}
+	overrides, err := json.Marshal(spec.Rules)
+	if err != nil {
+		return rules, err
+	}
+	err = json.Unmarshal(overrides, &rules)
+	if err != nil {
+		return rules, fmt.Errorf("failed to apply rules overrides: %v", err)
+	}
+	return rules, nil
+}
+
+// BuildValidators fills the default validators IDs and addresses.
+func (spec *Spec) BuildValidators(genesisTime inter.Timestamp) (gpos.Validators, error) {
+	if len(spec.Validators) == 0 {
+		return nil, errors.New("no genesis validators")
+	}
+	validators := make(gpos.Validators, 0, len(spec.Validators))
+	ids := make(map[idx.ValidatorID]bool, len(spec.Validators))
+	pubkeys := make(map[string]idx.ValidatorID, len(spec.Validators))
+	for i, v := range spec.Validators {
+		id := v.ID
+		if id == 0 {
+			id = idx.ValidatorID(i + 1)
+		}
+		if ids[id] {
+			return nil, fmt.Errorf("validator %d is specified twice", id)
+		}
+		ids[id] = true
+		if v.PubKey.Type != validatorpk.Types.Secp256k1 {
+			return nil, fmt.Errorf("validator %d has a pubkey of unsupported type %d", id, v.PubKey.Type)
+		}
+		pubkey, err := crypto.UnmarshalPubkey(v.PubKey.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("validator %d has a malformed pubkey: %v", id, err)
+		}
+		if prev, ok := pubkeys[string(v.PubKey.Bytes())]; ok {
+			return nil, fmt.Errorf("validators %d and %d have the same pubkey", prev, id)
+		}
+		pubkeys[string(v.PubKey.Bytes())] = id
+		addr := v.Address
+		if addr == (common.Address{}) {
+			addr = crypto.PubkeyToAddress(*pubkey)
+		}
+		if v.Stake == nil || (*big.Int)(v.Stake).Sign() <= 0 {
+			return nil, fmt.Errorf("validator %d has no stake", id)
+		}
+		validators = append(validators, gpos.Validator{
+			ID:           id,
+			Address:      addr,
+			PubKey:       v.PubKey,
+			CreationTime: genesisTime,
+		})
+	}
+	return validators, nil
+}
+
+// Build writes the genesis described by the spec into the genesis store.
+// Stakes of validators are minted into the SFC and aren't counted in the total supply, like in FakeGenesisStore.
+func (spec *Spec) Build(genStore *genesisstore.Store) error {
+	rules, err := spec.BuildRules()
+	if err != nil {
+		return err
+	}
+	if spec.Time == 0 {
+		return errors.New("genesis time isn't specified")
+	}
+	genesisTime := inter.Timestamp(spec.Time) * inter.Timestamp(time.Second)
+	validators, err := spec.BuildValidators(genesisTime)
+	if err != nil {
+		return err
+	}
+
+	totalSupply := new(big.Int)
+	for _, acc := range spec.Accounts {
+		if isSystemContract(acc.Address) {
+			return fmt.Errorf("account %s overrides a system contract", acc.Address.String())
+		}
+		balance := new(big.Int)
+		if acc.Balance != nil {
+			balance.Set((*big.Int)(acc.Balance))
+		}
+		genStore.SetEvmAccount(acc.Address, genesis.Account{
+			Code:    acc.Code,
+			Balance: balance,
+			Nonce:   acc.Nonce,
+		})
+		for key, value := range acc.Storage {
+			genStore.SetEvmState(acc.Address, key, value)
+		}
+		totalSupply.Add(totalSupply, balance)
+	}
+	for i, val := range validators {
+		genStore.SetDelegation(val.Address, val.ID, genesis.Delegation{
+			Stake:              new(big.Int).Set((*big.Int)(spec.Validators[i].Stake)),
+			Rewards:            new(big.Int),
+			LockedStake:        new(big.Int),
+			EarlyUnlockPenalty: new(big.Int),
+		})
+	}
+
+	owner := validators[0].Address
+	if spec.DriverOwner != nil {
+		owner = *spec.DriverOwner
+	}
+
+	genStore.SetRules(rules)
+	genStore.SetMetadata(genesisstore.Metadata{
+		Validators:    validators,
+		FirstEpoch:    2,
+		Time:          genesisTime,
+		PrevEpochTime: genesisTime - inter.Timestamp(time.Hour),
+		ExtraData:     []byte(spec.ExtraData),
+		DriverOwner:   owner,
+		TotalSupply:   totalSupply,
+	})
+	genStore.SetBlock(0, genesis.Block{
+		Time:        genesisTime - inter.Timestamp(time.Minute),
+		Atropos:     hash.Event{},
+		Txs:         types.Transactions{},
+		InternalTxs: types.Transactions{},
+		Root:        hash.Hash{},
+		Receipts:    []*types.ReceiptForStorage{},
+	})
+	setSystemContracts(genStore)
+	return nil
+}
+
+func isSystemContract(addr common.Address) bool {
+	switch addr {
+	case netinit.ContractAddress, driver.ContractAddress, driverauth.ContractAddress, sfc.ContractAddress, evmwriter.ContractAddress:
+		return true
+	}
+	return false
+}
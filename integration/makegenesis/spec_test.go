@@ -0,0 +1,109 @@
+package makegenesis
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/opera"
+	"github.com/Fantom-foundation/go-opera/opera/genesis"
+	"github.com/Fantom-foundation/go-opera/opera/genesis/sfc"
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+)
+
+func TestSpecBuild(t *testing.T) {
+	require := require.New(t)
+
+	key := FakeKey(1)
+	pubkey := validatorpk.PubKey{
+		Type: validatorpk.Types.Secp256k1,
+		Raw:  crypto.FromECDSAPub(&key.PublicKey),
+	}
+	key2 := FakeKey(2)
+	pubkey2 := validatorpk.PubKey{
+		Type: validatorpk.Types.Secp256k1,
+		Raw:  crypto.FromECDSAPub(&key2.PublicKey),
+	}
+	var spec Spec
+	require.NoError(json.Unmarshal([]byte(`{
+		"BaseRules": "fake",
+		"Rules": {"Name": "private", "NetworkID": 4100, "Economy": {"MinGasPrice": 5}},
+		"Time": 1608600000,
+		"ExtraData": "private",
+		"Validators": [
+			{"PubKey": "`+pubkey.String()+`", "Stake": "0x10"},
+			{"ID": 5, "Address": "0x0000000000000000000000000000000000000005", "PubKey": "`+pubkey2.String()+`", "Stake": "32"}
+		],
+		"Accounts": [
+			{"Address": "0x0000000000000000000000000000000000000001", "Balance": "100", "Code": "0x6001",
+			 "Storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"}},
+			{"Address": "0x0000000000000000000000000000000000000002", "Balance": "0x10"}
+		]
+	}`), &spec))
+
+	genStore := genesisstore.NewMemStore()
+	require.NoError(spec.Build(genStore))
+	g := genStore.GetGenesis()
+
+	exp := opera.FakeNetRules()
+	exp.Name = "private"
+	exp.NetworkID = 4100
+	exp.Economy.MinGasPrice = big.NewInt(5)
+	require.Equal(exp.String(), g.Rules.String())
+
+	require.Len(g.Validators, 2)
+	require.Equal(idx.ValidatorID(1), g.Validators[0].ID)
+	require.Equal(crypto.PubkeyToAddress(key.PublicKey), g.Validators[0].Address)
+	require.Equal(idx.ValidatorID(5), g.Validators[1].ID)
+	require.Equal(common.Address{19: 5}, g.Validators[1].Address)
+	require.Equal(g.Validators[0].Address, g.DriverOwner)
+	require.Equal(big.NewInt(116), g.TotalSupply)
+	require.Equal([]byte("private"), g.ExtraData)
+
+	stakes := map[idx.ValidatorID]*big.Int{}
+	g.Delegations.ForEach(func(_ common.Address, id idx.ValidatorID, d genesis.Delegation) {
+		stakes[id] = d.Stake
+	})
+	require.Equal(map[idx.ValidatorID]*big.Int{1: big.NewInt(16), 5: big.NewInt(32)}, stakes)
+
+	accounts := map[common.Address]genesis.Account{}
+	g.Accounts.ForEach(func(addr common.Address, acc genesis.Account) {
+		accounts[addr] = acc
+	})
+	require.Equal([]byte{0x60, 0x01}, accounts[common.Address{19: 1}].Code)
+	require.Equal(sfc.GetContractBin(), accounts[sfc.ContractAddress].Code)
+	slots := 0
+	g.Storage.ForEach(func(addr common.Address, key common.Hash, value common.Hash) {
+		require.Equal(common.Address{19: 1}, addr)
+		require.Equal(common.Hash{31: 2}, value)
+		slots++
+	})
+	require.Equal(1, slots)
+
+	// invalid specs
+	for _, modify := range []func(s *Spec){
+		func(s *Spec) { s.BaseRules = "unknown" },
+		func(s *Spec) { s.Time = 0 },
+		func(s *Spec) { s.Validators = nil },
+		func(s *Spec) { s.Validators[1].ID = 1 },
+		func(s *Spec) { s.Validators[1].PubKey = pubkey },
+		func(s *Spec) { s.Validators[0].Stake = (*math.HexOrDecimal256)(new(big.Int)) },
+		func(s *Spec) {
+			s.Validators[0].PubKey = validatorpk.PubKey{Type: validatorpk.Types.Secp256k1, Raw: []byte{1}}
+		},
+		func(s *Spec) { s.Accounts = append(s.Accounts, AccountSpec{Address: sfc.ContractAddress}) },
+		func(s *Spec) { s.Rules = map[string]interface{}{"NetworkID": "x"} },
+	} {
+		invalid := spec
+		invalid.Validators = append([]ValidatorSpec{}, spec.Validators...)
+		modify(&invalid)
+		require.Error(invalid.Build(genesisstore.NewMemStore()))
+	}
+}
@@ -0,0 +1,449 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Fantom-foundation/lachesis-base/abft"
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb"
+	"github.com/Fantom-foundation/lachesis-base/kvdb/flushable"
+	"github.com/Fantom-foundation/lachesis-base/kvdb/table"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/Fantom-foundation/go-opera/gossip"
+	"github.com/Fantom-foundation/go-opera/inter"
+)
+
+// flushIDSize is the size of flush IDs written by gossip.Store.Commit
+const flushIDSize = 8
+
+var errUnrecoverable = errors.New("databases cannot be recovered, restore them from a backup or resync the node")
+
+// RecoveryReport describes the inconsistencies of databases found by the startup recovery.
+type RecoveryReport struct {
+	// Issues are the found inconsistencies
+	Issues []string
+	// Repairs are the applied repairs
+	Repairs []string
+	// Events are the removed dangling events and events above the decided frame, which should be processed again after the node start
+	Events inter.EventPayloads
+}
+
+func (r *RecoveryReport) issue(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Warn("Recovery: " + msg)
+	r.Issues = append(r.Issues, msg)
+}
+
+func (r *RecoveryReport) repair(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Info("Recovery: " + msg)
+	r.Repairs = append(r.Repairs, msg)
+}
+
+// Clean returns true if no inconsistencies were found.
+func (r *RecoveryReport) Clean() bool {
+	return len(r.Issues) == 0
+}
+
+// RecoverDBs audits the databases left after an unclean shutdown and rolls them back to the last consistent point:
+//   - flush IDs of databases are rolled back or forward if a flush was interrupted while writing the flush marks;
+//   - BlockEpochState is rolled back to the previous commit if it's ahead of the committed EVM state or consensus state;
+//   - Lachesis decided frame is rolled back to the last frame applied to BlockEpochState;
+//   - dangling events and events above the last decided frame are removed with their roots, to be processed again from the local DAG.
+//
+// Databases contents are audited only if the flush marks show that a flush was interrupted.
+// Returns an error if databases cannot be repaired. It's a no-op for an empty or a consistent datadir.
+func RecoverDBs(rawProducer kvdb.IterableDBProducer, cfg Configs) (*RecoveryReport, error) {
+	report := &RecoveryReport{}
+	if len(rawProducer.Names()) == 0 {
+		return report, nil
+	}
+	err := recoverFlushIDs(rawProducer, report)
+	if err != nil {
+		return report, err
+	}
+	if report.Clean() {
+		return report, nil
+	}
+	err = recoverStores(rawProducer, cfg, report)
+	if err != nil {
+		return report, err
+	}
+	log.Info("Recovery is complete", "issues", len(report.Issues), "repairs", len(report.Repairs), "replay", len(report.Events))
+	return report, nil
+}
+
+type flushMark struct {
+	dirty bool
+	prev  []byte // previous mark of a dirty DB, or nil if DB was created during the flush
+	id    []byte
+}
+
+func parseFlushMark(mark []byte) (flushMark, error) {
+	if len(mark) == 1+flushIDSize && mark[0] == flushable.CleanPrefix {
+		return flushMark{id: mark[1:]}, nil
+	}
+	if len(mark) > 1+flushIDSize && mark[0] == flushable.DirtyPrefix {
+		m := flushMark{
+			dirty: true,
+			prev:  mark[1 : len(mark)-flushIDSize],
+			id:    mark[len(mark)-flushIDSize:],
+		}
+		if bytes.Equal(m.prev, []byte("initial")) {
+			m.prev = nil
+		}
+		return m, nil
+	}
+	return flushMark{}, fmt.Errorf("malformed flush mark %x", mark)
+}
+
+// recoverFlushIDs makes the flush marks of all DBs equal.
+// A flush writes dirty marks into all DBs first, then the data, and then clean marks.
+// If some DB still has the previous clean mark, the data wasn't written yet, so the marks are rolled back.
+// If some DB has the new clean mark, the data was written completely, so the marks are rolled forward.
+// If all DBs have the dirty mark, the data may be partially written, so the marks are rolled forward
+// and the DBs contents are left to recoverStores.
+func recoverFlushIDs(rawProducer kvdb.IterableDBProducer, report *RecoveryReport) error {
+	names := rawProducer.Names()
+	sort.Strings(names)
+	marks := make(map[string]flushMark, len(names))
+	var unmarked []string
+	for _, name := range names {
+		db, err := rawProducer.OpenDB(name)
+		if err != nil {
+			return fmt.Errorf("failed to open '%s' database: %v", name, err)
+		}
+		mark, err := db.Get(FlushIDKey)
+		_ = db.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read flush ID of '%s' database: %v", name, err)
+		}
+		if mark == nil {
+			unmarked = append(unmarked, name)
+			continue
+		}
+		m, err := parseFlushMark(mark)
+		if err != nil {
+			return fmt.Errorf("'%s' database: %v", name, err)
+		}
+		marks[name] = m
+	}
+	if len(marks) == 0 {
+		// genesis processing was interrupted
+		return nil
+	}
+	for _, name := range unmarked {
+		if name == "gossip" || name == "lachesis" || name == "genesis" {
+			report.issue("'%s' database has no flush ID", name)
+			return errUnrecoverable
+		}
+	}
+
+	var (
+		dirtyIDs, dirtyPrevs = map[string]bool{}, map[string]bool{}
+		cleanIDs             = map[string]bool{}
+		dirtyID, dirtyPrev   []byte
+		cleanID              []byte
+	)
+	for _, m := range marks {
+		if m.dirty {
+			dirtyID = m.id
+			dirtyIDs[string(m.id)] = true
+			if m.prev != nil {
+				dirtyPrev = m.prev
+				dirtyPrevs[string(m.prev)] = true
+			}
+		} else {
+			cleanID = m.id
+			cleanIDs[string(m.id)] = true
+		}
+	}
+	if len(dirtyIDs) == 0 && len(cleanIDs) == 1 && len(unmarked) == 0 {
+		return nil
+	}
+	report.issue("databases aren't synced: %s", describeFlushMarks(names, marks))
+	if len(dirtyIDs) > 1 || len(dirtyPrevs) > 1 || len(cleanIDs) > 1 {
+		return errUnrecoverable
+	}
+
+	var targetID []byte
+	switch {
+	case len(dirtyIDs) == 0:
+		// only DBs which weren't flushed are inconsistent
+		targetID = cleanID
+	case len(cleanIDs) == 0:
+		targetID = dirtyID
+		report.issue("flush %x was interrupted while writing the data", dirtyID)
+	case bytes.Equal(cleanID, dirtyID):
+		targetID = dirtyID
+	case dirtyPrev == nil || bytes.Equal(dirtyPrev, append([]byte{flushable.CleanPrefix}, cleanID...)):
+		targetID = cleanID
+		// DBs created during the interrupted flush didn't exist at the previous flush
+		for name, m := range marks {
+			if m.prev == nil && m.dirty {
+				unmarked = append(unmarked, name)
+				delete(marks, name)
+			}
+		}
+	default:
+		return errUnrecoverable
+	}
+	target := append([]byte{flushable.CleanPrefix}, targetID...)
+
+	for _, name := range unmarked {
+		db, err := rawProducer.OpenDB(name)
+		if err != nil {
+			return fmt.Errorf("failed to open '%s' database: %v", name, err)
+		}
+		_ = db.Close()
+		db.Drop()
+		report.repair("dropped '%s' database which wasn't flushed", name)
+	}
+	for _, name := range names {
+		m, ok := marks[name]
+		if !ok || !m.dirty && bytes.Equal(m.id, targetID) {
+			continue
+		}
+		db, err := rawProducer.OpenDB(name)
+		if err != nil {
+			return fmt.Errorf("failed to open '%s' database: %v", name, err)
+		}
+		err = db.Put(FlushIDKey, target)
+		_ = db.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write flush ID of '%s' database: %v", name, err)
+		}
+	}
+	report.repair("set flush ID of databases to %x", targetID)
+	return nil
+}
+
+func describeFlushMarks(names []string, marks map[string]flushMark) string {
+	buf := bytes.Buffer{}
+	for _, name := range names {
+		if buf.Len() != 0 {
+			buf.WriteString(", ")
+		}
+		m, ok := marks[name]
+		switch {
+		case !ok:
+			fmt.Fprintf(&buf, "%s: none", name)
+		case m.dirty:
+			fmt.Fprintf(&buf, "%s: dirty %x", name, m.id)
+		default:
+			fmt.Fprintf(&buf, "%s: %x", name, m.id)
+		}
+	}
+	return buf.String()
+}
+
+// recoverStores checks the DBs contents and removes the dangling events.
+func recoverStores(rawProducer kvdb.IterableDBProducer, cfg Configs, report *RecoveryReport) error {
+	dbs, err := makeFlushableProducer(rawProducer)
+	if err != nil {
+		return err
+	}
+	gdb, cdb, genesisStore := getStores(dbs, cfg)
+	defer gdb.Close()
+	defer cdb.Close()
+	defer genesisStore.Close()
+	if gdb.GetGenesisHash() == nil {
+		// reported by MakeEngine
+		return nil
+	}
+
+	// BlockEpochState must not be ahead of the committed EVM state and the consensus state
+	epoch, lastDecided := cdb.GetEpoch(), cdb.GetLastDecidedFrame()
+	checkBlockEpochState := func() bool {
+		ok := true
+		for _, issue := range gdb.CheckBlockEpochState() {
+			report.issue("%s", issue.String())
+			ok = false
+		}
+		if gEpoch := gdb.GetEpoch(); gEpoch != epoch {
+			report.issue("Lachesis epoch %d mismatches Gossip epoch %d", epoch, gEpoch)
+			ok = false
+		}
+		if atropos := gdb.GetBlockState().LastBlock.Atropos; atropos.Epoch() == epoch {
+			if e := gdb.GetEvent(atropos); e != nil && e.Frame() > lastDecided {
+				report.issue("last block atropos %s is above the last decided frame %d", atropos.String(), lastDecided)
+				ok = false
+			}
+		}
+		return ok
+	}
+	if !checkBlockEpochState() {
+		if !gdb.RollbackBlockEpochState() {
+			return errUnrecoverable
+		}
+		report.repair("rolled back BlockEpochState to block %d", gdb.GetLatestBlockIndex())
+		if !checkBlockEpochState() {
+			return errUnrecoverable
+		}
+	}
+
+	// abft.Store keeps roots of the epoch in the 'r' table, keyed by frame, validator and event ID,
+	// and frames of the confirmed events in the 'C' table, keyed by event ID
+	epochDB, err := dbs.OpenDB(fmt.Sprintf("lachesis-%d", epoch))
+	if err != nil {
+		return err
+	}
+	defer epochDB.Close()
+	roots := table.New(epochDB, []byte("r"))
+
+	// Lachesis must not be ahead of BlockEpochState, otherwise the blocks of the frames decided after it are never processed
+	decided, err := recoverDecidedFrame(gdb, cdb, table.New(epochDB, []byte("C")), report)
+	if err != nil {
+		return err
+	}
+	hasRoot := func(f idx.Frame, e *inter.EventPayload) bool {
+		key := append(append(f.Bytes(), e.Creator().Bytes()...), e.ID().Bytes()...)
+		ok, err := roots.Has(key)
+		return err == nil && ok
+	}
+
+	dangling := gdb.DanglingEvents(func(e *inter.EventPayload) bool {
+		// events above the decided frame are processed again, so the frames are decided again
+		if e.Frame() > decided {
+			return false
+		}
+		// the root must be known to Lachesis
+		selfParentFrame := idx.Frame(0)
+		if sp := e.SelfParent(); sp != nil {
+			if spe := gdb.GetEvent(*sp); spe != nil {
+				selfParentFrame = spe.Frame()
+			}
+		}
+		return selfParentFrame == e.Frame() || hasRoot(e.Frame(), e)
+	})
+	danglingIDs := make(hash.EventsSet, len(dangling))
+	for _, e := range dangling {
+		if e.Frame() <= decided {
+			report.issue("dangling event %s of frame %d isn't above the last decided frame %d", e.ID().String(), e.Frame(), decided)
+			return errUnrecoverable
+		}
+		danglingIDs.Add(e.ID())
+	}
+
+	// roots of the dangling events and roots of unknown events were processed only by Lachesis
+	var staleRoots [][]byte
+	it := roots.NewIterator(nil, nil)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != 4+4+32 {
+			continue
+		}
+		f := idx.BytesToFrame(key[:4])
+		id := hash.BytesToEvent(key[8:])
+		if danglingIDs.Contains(id) || !gdb.HasEvent(id) {
+			if f <= decided {
+				it.Release()
+				report.issue("root %s of the decided frame %d isn't connected", id.String(), f)
+				return errUnrecoverable
+			}
+			staleRoots = append(staleRoots, common.CopyBytes(key))
+		}
+	}
+	it.Release()
+	if len(dangling) == 0 && len(staleRoots) == 0 {
+		return gdb.Commit()
+	}
+
+	report.issue("found %d dangling events and %d not connected roots above the last decided frame %d", len(dangling), len(staleRoots), decided)
+	for _, key := range staleRoots {
+		if err := roots.Delete(key); err != nil {
+			return err
+		}
+	}
+	gdb.RemoveEvents(dangling)
+	report.Events = dangling
+	report.repair("removed %d dangling events and %d roots, events will be processed again", len(dangling), len(staleRoots))
+	return gdb.Commit()
+}
+
+// recoverDecidedFrame rolls Lachesis back to the last decided frame which is applied to BlockState and returns the frame.
+// The events above the frame are treated as dangling ones, so the frames above it are decided again when the events are processed again.
+// A frame is applied if BlockState has the highest confirmed events of validators not below the events confirmed on the frame.
+// Lachesis cannot be just rolled back to the frame of the last block atropos, because skipped frames are applied to BlockState too.
+func recoverDecidedFrame(gdb *gossip.Store, cdb *abft.Store, confirmed kvdb.Store, report *RecoveryReport) (idx.Frame, error) {
+	epoch, lastDecided := cdb.GetEpoch(), cdb.GetLastDecidedFrame()
+	bs, es := gdb.GetBlockEpochState()
+	cheaters := make(map[idx.ValidatorID]bool, len(bs.EpochCheaters))
+	for _, v := range bs.EpochCheaters {
+		cheaters[v] = true
+	}
+
+	var (
+		lastApplied  = idx.Frame(0)
+		firstPending = lastDecided + 1
+		// highest events of cheaters aren't tracked by BlockState
+		known     = map[idx.Frame]bool{}
+		ambiguous = map[idx.Frame]bool{}
+	)
+	it := confirmed.NewIterator(nil, nil)
+	for it.Next() {
+		if len(it.Key()) != 32 || len(it.Value()) != 4 {
+			continue
+		}
+		id := hash.BytesToEvent(it.Key())
+		f := idx.BytesToFrame(it.Value())
+		e := gdb.GetEvent(id)
+		if e == nil {
+			it.Release()
+			report.issue("event %s confirmed on frame %d isn't found", id.String(), f)
+			return 0, errUnrecoverable
+		}
+		if cheaters[e.Creator()] || !es.Validators.Exists(e.Creator()) {
+			ambiguous[f] = true
+			continue
+		}
+		known[f] = true
+		last := bs.ValidatorStates[es.Validators.GetIdx(e.Creator())].LastEvent
+		if last.Epoch() == epoch && last.Lamport() >= e.Lamport() {
+			if f > lastApplied {
+				lastApplied = f
+			}
+		} else if f < firstPending {
+			firstPending = f
+		}
+	}
+	it.Release()
+	if firstPending > lastDecided {
+		return lastDecided, nil
+	}
+	report.issue("Lachesis decided frames %d-%d aren't applied to BlockEpochState", firstPending, lastDecided)
+	if lastApplied >= firstPending {
+		report.issue("decided frame %d is applied to BlockEpochState, but frame %d isn't", lastApplied, firstPending)
+		return 0, errUnrecoverable
+	}
+	for f := range ambiguous {
+		if !known[f] && f > lastApplied && f < firstPending {
+			report.issue("decided frame %d confirms only events of cheaters, it's unknown if it's applied", f)
+			return 0, errUnrecoverable
+		}
+	}
+
+	rollback := firstPending - 1
+	var unconfirmed [][]byte
+	it = confirmed.NewIterator(nil, nil)
+	for it.Next() {
+		if len(it.Value()) == 4 && idx.BytesToFrame(it.Value()) > rollback {
+			unconfirmed = append(unconfirmed, common.CopyBytes(it.Key()))
+		}
+	}
+	it.Release()
+	for _, key := range unconfirmed {
+		if err := confirmed.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	cdb.SetLastDecidedState(&abft.LastDecidedState{LastDecidedFrame: rollback})
+	report.repair("rolled back Lachesis decided frame to %d and unconfirmed %d events", rollback, len(unconfirmed))
+	return rollback, nil
+}
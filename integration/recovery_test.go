@@ -0,0 +1,248 @@
+package integration
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/abft"
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/Fantom-foundation/lachesis-base/kvdb"
+	"github.com/Fantom-foundation/lachesis-base/kvdb/flushable"
+	"github.com/Fantom-foundation/lachesis-base/kvdb/memorydb"
+	"github.com/Fantom-foundation/lachesis-base/utils/cachescale"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/gossip"
+	"github.com/Fantom-foundation/go-opera/integration/makegenesis"
+	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/logger"
+	"github.com/Fantom-foundation/go-opera/opera/genesisstore"
+	"github.com/Fantom-foundation/go-opera/utils"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+	"github.com/Fantom-foundation/go-opera/vecmt"
+)
+
+func TestRecoverFlushIDs(t *testing.T) {
+	var (
+		prev  = []byte{flushable.CleanPrefix, 0, 0, 0, 0, 0, 0, 0, 1}
+		id    = []byte{0, 0, 0, 0, 0, 0, 0, 2}
+		clean = append([]byte{flushable.CleanPrefix}, id...)
+		dirty = append(append([]byte{flushable.DirtyPrefix}, prev...), id...)
+		fresh = append(append([]byte{flushable.DirtyPrefix}, "initial"...), id...)
+	)
+	makeDBs := func(marks map[string][]byte) kvdb.IterableDBProducer {
+		producer := memorydb.NewProducer("")
+		for name, mark := range marks {
+			db, _ := producer.OpenDB(name)
+			if mark != nil {
+				require.NoError(t, db.Put(FlushIDKey, mark))
+			}
+		}
+		return producer
+	}
+	readMarks := func(producer kvdb.IterableDBProducer) map[string][]byte {
+		marks := map[string][]byte{}
+		for _, name := range producer.Names() {
+			db, _ := producer.OpenDB(name)
+			marks[name], _ = db.Get(FlushIDKey)
+		}
+		return marks
+	}
+
+	for _, tt := range []struct {
+		name   string
+		marks  map[string][]byte
+		expect map[string][]byte
+		err    bool
+	}{
+		{
+			name:   "synced",
+			marks:  map[string][]byte{"gossip": prev, "lachesis": prev},
+			expect: map[string][]byte{"gossip": prev, "lachesis": prev},
+		},
+		{
+			name:   "interrupted dirty marks",
+			marks:  map[string][]byte{"gossip": dirty, "lachesis": prev, "gossip-2": fresh, "gossip-1": nil},
+			expect: map[string][]byte{"gossip": prev, "lachesis": prev},
+		},
+		{
+			name:   "interrupted clean marks",
+			marks:  map[string][]byte{"gossip": clean, "lachesis": dirty, "gossip-2": fresh},
+			expect: map[string][]byte{"gossip": clean, "lachesis": clean, "gossip-2": clean},
+		},
+		{
+			name:   "interrupted data",
+			marks:  map[string][]byte{"gossip": dirty, "lachesis": dirty},
+			expect: map[string][]byte{"gossip": clean, "lachesis": clean},
+		},
+		{
+			name:  "not synced",
+			marks: map[string][]byte{"gossip": prev, "lachesis": clean},
+			err:   true,
+		},
+		{
+			name:  "main DB without flush ID",
+			marks: map[string][]byte{"gossip": prev, "lachesis": nil},
+			err:   true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := makeDBs(tt.marks)
+			report := &RecoveryReport{}
+			err := recoverFlushIDs(producer, report)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, readMarks(producer))
+			require.Equal(t, tt.name == "synced", report.Clean())
+		})
+	}
+}
+
+func TestRecoverDecidedFrame(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+
+	genStore := makegenesis.FakeGenesisStore(1, utils.ToFtm(1e6), utils.ToFtm(4e6))
+	genesis := InputGenesis{
+		Hash: genStore.Hash(),
+		Read: func(store *genesisstore.Store) error {
+			buf := bytes.NewBuffer(nil)
+			err := genStore.Export(buf)
+			if err != nil {
+				return err
+			}
+			return store.Import(buf)
+		},
+		Close: func() error {
+			return nil
+		},
+	}
+	cfg := Configs{
+		Opera:         gossip.FakeConfig(1, cachescale.Identity),
+		OperaStore:    gossip.DefaultStoreConfig(cachescale.Identity),
+		Lachesis:      abft.DefaultConfig(),
+		LachesisStore: abft.DefaultStoreConfig(cachescale.Identity),
+		VectorClock:   vecmt.DefaultConfig(cachescale.Identity),
+	}
+	cfg.Opera.Emitter.Validator.ID = 1
+	cfg.Opera.Emitter.Validator.PubKey = makegenesis.GetFakeValidators(1)[0].PubKey
+	producer := memorydb.NewProducer("")
+
+	var (
+		gdb      *gossip.Store
+		cdb      *abft.Store
+		engine   *abft.Lachesis
+		dagIndex *vecmt.Index
+		svc      *gossip.Service
+		stack    *node.Node
+	)
+	// startNode makes the engine and the service like the node does, blocks are decided again by the engine bootstrap
+	startNode := func() {
+		var (
+			blockProc gossip.BlockProc
+			err       error
+		)
+		engine, dagIndex, gdb, cdb, _, blockProc = MakeEngine(producer, genesis, cfg)
+		stack, err = node.New(&node.Config{})
+		require.NoError(err)
+		svc, err = gossip.NewService(stack, cfg.Opera, gdb, valkeystore.NewSigner(valkeystore.NewDefaultMemKeystore()), blockProc, engine, dagIndex)
+		require.NoError(err)
+		require.NoError(engine.Bootstrap(svc.GetConsensusCallbacks()))
+		svc.WaitBlockEnd()
+	}
+	// the in-memory stores aren't closed, because the tx pool of the service may still read them
+	stopNode := func() {
+		_ = stack.Close()
+	}
+	startNode()
+
+	// every event of the single validator is a root which decides a frame,
+	// empty blocks created sooner than MaxEmptyBlockSkipPeriod are skipped
+	var (
+		prev *inter.EventPayload
+		seq  idx.Event
+		now  = gdb.GetBlockState().LastBlock.Time
+	)
+	process := func(e *inter.EventPayload) {
+		gdb.SetEvent(e)
+		require.NoError(dagIndex.Add(e))
+		require.NoError(engine.Process(e))
+		dagIndex.Flush()
+		svc.WaitBlockEnd()
+	}
+	emit := func(n int) {
+		for i := 0; i < n; i++ {
+			seq++
+			spent := time.Second
+			if seq%3 == 0 {
+				spent = 10 * time.Second
+			}
+			now += inter.Timestamp(spent)
+			me := inter.MutableEventPayload{}
+			me.SetEpoch(gdb.GetEpoch())
+			me.SetCreator(1)
+			me.SetSeq(seq)
+			me.SetLamport(idx.Lamport(seq))
+			me.SetFrame(idx.Frame(seq))
+			me.SetMedianTime(now)
+			if prev != nil {
+				me.SetParents(hash.Events{prev.ID()})
+			}
+			prev = me.Build()
+			process(prev)
+		}
+	}
+	emit(10)
+	require.NoError(gdb.Commit())
+	emit(10)
+	require.NoError(gdb.Commit())
+
+	bs := gdb.GetBlockState().Copy()
+	decided := cdb.GetLastDecidedFrame()
+	atropos := map[idx.Block]hash.Event{}
+	for n := idx.Block(1); n <= bs.LastBlock.Idx; n++ {
+		atropos[n] = gdb.GetBlock(n).Atropos
+	}
+
+	// the latest BlockEpochState is lost by an interrupted commit, while Lachesis keeps the decided frames
+	require.True(gdb.RollbackBlockEpochState())
+	rolledBack := gdb.GetLatestBlockIndex()
+	require.Less(uint64(rolledBack), uint64(bs.LastBlock.Idx))
+	require.NoError(gdb.Commit())
+	stopNode()
+
+	report := &RecoveryReport{}
+	require.NoError(recoverStores(producer, cfg, report))
+	require.False(report.Clean())
+	require.NotEmpty(report.Events)
+
+	// the block sequence continues from the rolled back block once the events are processed again,
+	// the frames are applied exactly once
+	startNode()
+	require.Less(uint64(cdb.GetLastDecidedFrame()), uint64(decided))
+	for _, e := range report.Events {
+		process(e)
+	}
+	require.Equal(decided, cdb.GetLastDecidedFrame())
+	require.Equal(bs, gdb.GetBlockState())
+	for n := rolledBack + 1; n <= bs.LastBlock.Idx; n++ {
+		require.Equal(atropos[n], gdb.GetBlock(n).Atropos, n)
+	}
+	emit(10)
+	require.Greater(uint64(gdb.GetLatestBlockIndex()), uint64(bs.LastBlock.Idx))
+	for n := idx.Block(1); n <= gdb.GetLatestBlockIndex(); n++ {
+		require.NotNil(gdb.GetBlock(n), n)
+	}
+
+	// recovery of consistent DBs is a no-op
+	stopNode()
+	report = &RecoveryReport{}
+	require.NoError(recoverStores(producer, cfg, report))
+	require.True(report.Clean())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/Fantom-foundation/go-opera/inter"
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
 	"github.com/Fantom-foundation/go-opera/opera"
 	"github.com/Fantom-foundation/go-opera/opera/genesis"
 	"github.com/Fantom-foundation/go-opera/opera/genesis/gpos"
@@ -70,3 +71,9 @@ func DeactivateValidator(validatorID idx.ValidatorID, status uint64) []byte {
 	data, _ := sAbi.Pack("deactivateValidator", utils.U64toBig(uint64(validatorID)), utils.U64toBig(status))
 	return data
 }
+
+// UpdateValidatorPubkey is the call of NodeDriverAuth, only SFC is allowed to make it
+func UpdateValidatorPubkey(validatorID idx.ValidatorID, pubkey validatorpk.PubKey) []byte {
+	data, _ := sAbi.Pack("updateValidatorPubkey", utils.U64toBig(uint64(validatorID)), pubkey.Bytes())
+	return data
+}
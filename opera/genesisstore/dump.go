@@ -44,12 +44,26 @@ func checkFileHeader(reader io.Reader) error {
 	return nil
 }
 
-func OpenGenesisStore(rawReader io.Reader) (h hash.Hash, readGenesisStore func(*Store) error, err error) {
+// HeaderSize is the size of genesis file header, which contains the genesis hash.
+func HeaderSize() int {
+	return len(fileHeader) + len(fileVersion) + len(hash.Hash{})
+}
+
+// ReadGenesisHash reads the genesis hash from the genesis file header.
+func ReadGenesisHash(rawReader io.Reader) (h hash.Hash, err error) {
 	err = checkFileHeader(rawReader)
 	if err != nil {
-		return hash.Zero, nil, err
+		return hash.Zero, err
 	}
 	err = ioread.ReadAll(rawReader, h[:])
+	if err != nil {
+		return hash.Zero, err
+	}
+	return h, nil
+}
+
+func OpenGenesisStore(rawReader io.Reader) (h hash.Hash, readGenesisStore func(*Store) error, err error) {
+	h, err = ReadGenesisHash(rawReader)
 	if err != nil {
 		return hash.Zero, nil, err
 	}
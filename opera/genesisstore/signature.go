@@ -0,0 +1,66 @@
+package genesisstore
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignatureFileSuffix is appended to the genesis file name to get the name of its detached signature.
+// The signature is detached, so the genesis file format and hash stay the same.
+const SignatureFileSuffix = ".sig"
+
+var signaturePrefix = []byte("opera genesis")
+
+func signatureDigest(h hash.Hash) []byte {
+	return crypto.Keccak256(signaturePrefix, h.Bytes())
+}
+
+// SignGenesisHash signs the genesis hash by the key.
+func SignGenesisHash(h hash.Hash, key *ecdsa.PrivateKey) ([]byte, error) {
+	return crypto.Sign(signatureDigest(h), key)
+}
+
+// VerifyGenesisSignature checks that the genesis hash is signed by the signer.
+func VerifyGenesisSignature(h hash.Hash, sig []byte, signer common.Address) error {
+	if len(sig) != crypto.SignatureLength {
+		return errors.New("malformed genesis signature")
+	}
+	pubkey, err := crypto.SigToPub(signatureDigest(h), sig)
+	if err != nil {
+		return err
+	}
+	if got := crypto.PubkeyToAddress(*pubkey); got != signer {
+		return fmt.Errorf("genesis is signed by %s, expected %s", got.String(), signer.String())
+	}
+	return nil
+}
+
+// WriteSignatureFile writes the hex signature of the genesis hash next to the genesis file.
+func WriteSignatureFile(genesisPath string, h hash.Hash, key *ecdsa.PrivateKey) error {
+	sig, err := SignGenesisHash(h, key)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(genesisPath+SignatureFileSuffix, []byte(hexutil.Encode(sig)+"\n"), 0644)
+}
+
+// VerifySignatureFile checks the signature written by WriteSignatureFile.
+func VerifySignatureFile(genesisPath string, h hash.Hash, signer common.Address) error {
+	data, err := ioutil.ReadFile(genesisPath + SignatureFileSuffix)
+	if err != nil {
+		return err
+	}
+	sig, err := hexutil.Decode(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("malformed genesis signature: %v", err)
+	}
+	return VerifyGenesisSignature(h, sig, signer)
+}
@@ -0,0 +1,39 @@
+package genesisstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/hash"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenesisSignature(t *testing.T) {
+	require := require.New(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	other, err := crypto.GenerateKey()
+	require.NoError(err)
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	h := hash.Of([]byte("genesis"))
+
+	sig, err := SignGenesisHash(h, key)
+	require.NoError(err)
+	require.NoError(VerifyGenesisSignature(h, sig, signer))
+	require.Error(VerifyGenesisSignature(hash.Of([]byte("other")), sig, signer))
+	require.Error(VerifyGenesisSignature(h, sig, crypto.PubkeyToAddress(other.PublicKey)))
+	require.Error(VerifyGenesisSignature(h, sig[:64], signer))
+
+	dir, err := ioutil.TempDir("", "genesis-signature")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "genesis.g")
+	require.Error(VerifySignatureFile(path, h, signer))
+	require.NoError(WriteSignatureFile(path, h, key))
+	require.NoError(VerifySignatureFile(path, h, signer))
+	require.Error(VerifySignatureFile(path, hash.Of([]byte("other")), signer))
+}
@@ -0,0 +1,71 @@
+package topicsdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	queryTimer          = metrics.NewRegisteredTimer("topicsdb/query/time", nil)
+	queryScannedHist    = metrics.NewRegisteredHistogram("topicsdb/query/scanned", nil, metrics.NewExpDecaySample(1028, 0.015))
+	queryResultsHist    = metrics.NewRegisteredHistogram("topicsdb/query/results", nil, metrics.NewExpDecaySample(1028, 0.015))
+	queryTruncatedMeter = metrics.NewRegisteredMeter("topicsdb/query/truncated", nil)
+)
+
+// Budget limits the resources a single query is allowed to spend.
+// Zero value of a field means no limit.
+type Budget struct {
+	// MaxResults is a max number of matched log records.
+	MaxResults int
+	// MaxScannedKeys is a max number of index keys read.
+	MaxScannedKeys int
+}
+
+// Stats is a cost of a query.
+type Stats struct {
+	ScannedKeys int
+	Results     int
+	// Next is the log record to continue the query from if it was stopped by Budget.
+	// Nil if the query is complete.
+	Next *ID
+}
+
+// searchState is a context of a single query.
+type searchState struct {
+	pattern   [][]common.Hash
+	budget    Budget
+	stats     Stats
+	progress  bool
+	onMatched logHandler
+}
+
+func (s *searchState) scanned() {
+	s.stats.ScannedKeys++
+}
+
+// keysExhausted returns true if MaxScannedKeys is reached.
+// At least one log record is processed by each query to guarantee progress.
+func (s *searchState) keysExhausted() bool {
+	return s.progress && s.budget.MaxScannedKeys > 0 && s.stats.ScannedKeys >= s.budget.MaxScannedKeys
+}
+
+func (s *searchState) stop(next ID) {
+	s.stats.Next = &next
+}
+
+func (s *searchState) matched(rec *logrec) (gonext bool, err error) {
+	if s.budget.MaxResults > 0 && s.stats.Results >= s.budget.MaxResults {
+		s.stop(rec.ID)
+		return false, nil
+	}
+	s.stats.Results++
+	return s.onMatched(rec)
+}
+
+func recordQueryMetrics(stats Stats) {
+	queryScannedHist.Update(int64(stats.ScannedKeys))
+	queryResultsHist.Update(int64(stats.Results))
+	if stats.Next != nil {
+		queryTruncatedMeter.Mark(1)
+	}
+}
@@ -27,6 +27,16 @@ func NewID(block uint64, tx common.Hash, logIndex uint) (id ID) {
 	return
 }
 
+// BytesToID converts bytes to ID.
+func BytesToID(b []byte) (id ID, err error) {
+	if len(b) != logrecKeySize {
+		err = ErrInvalidID
+		return
+	}
+	copy(id[:], b)
+	return
+}
+
 func (id *ID) Bytes() []byte {
 	return (*id)[:]
 }
@@ -45,6 +55,17 @@ func (id *ID) Index() uint {
 		(*id)[uint64Size+hashSize : uint64Size+hashSize+uint64Size]))
 }
 
+// next returns the ID following the given one in keys order.
+func (id ID) next() ID {
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i]++
+		if id[i] != 0 {
+			break
+		}
+	}
+	return id
+}
+
 func topicKey(topic common.Hash, pos uint8, logrec ID) []byte {
 	key := make([]byte, 0, topicKeySize)
 
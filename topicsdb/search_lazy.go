@@ -1,34 +1,48 @@
 package topicsdb
 
 import (
+	"bytes"
 	"context"
 
+	"github.com/Fantom-foundation/lachesis-base/kvdb"
 	"github.com/ethereum/go-ethereum/common"
 )
 
 type logHandler func(rec *logrec) (gonext bool, err error)
 
 func (tt *Index) searchLazy(ctx context.Context, pattern [][]common.Hash, blockStart []byte, blockEnd uint64, onMatched logHandler) (err error) {
+	_, err = tt.searchLimited(ctx, pattern, blockStart, blockEnd, Budget{}, onMatched)
+	return
+}
+
+func (tt *Index) searchLimited(ctx context.Context, pattern [][]common.Hash, blockStart []byte, blockEnd uint64, budget Budget, onMatched logHandler) (stats Stats, err error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	_, err = tt.walkFirst(ctx, blockStart, blockEnd, pattern, 0, onMatched)
-	return
+	s := &searchState{
+		pattern:   pattern,
+		budget:    budget,
+		onMatched: onMatched,
+	}
+	_, err = tt.walkFirst(ctx, s, blockStart, blockEnd, 0)
+	return s.stats, err
 }
 
 // walkFirst for topics recursive.
+// Variants of the first position are merged to walk through log records in ID order,
+// so the search may be continued from any record.
 func (tt *Index) walkFirst(
-	ctx context.Context, blockStart []byte, blockEnd uint64, pattern [][]common.Hash, pos uint8, onMatched logHandler,
+	ctx context.Context, s *searchState, blockStart []byte, blockEnd uint64, pos uint8,
 ) (
 	gonext bool, err error,
 ) {
-	patternLen := uint8(len(pattern))
+	patternLen := uint8(len(s.pattern))
 	gonext = true
 	for {
 		if pos >= patternLen {
 			return
 		}
-		if len(pattern[pos]) < 1 {
+		if len(s.pattern[pos]) < 1 {
 			pos++
 			continue
 		}
@@ -42,59 +56,98 @@ func (tt *Index) walkFirst(
 	copy(prefix[prefLen:], posToBytes(pos))
 	prefLen += uint8Size
 
-	for _, variant := range pattern[pos] {
+	its := make([]kvdb.Iterator, len(s.pattern[pos]))
+	for i, variant := range s.pattern[pos] {
 		copy(prefix[0:], variant.Bytes())
-		it := tt.table.Topic.NewIterator(prefix[:prefLen], blockStart)
-		for it.Next() {
-			err = ctx.Err()
-			if err != nil {
-				it.Release()
-				return
-			}
+		its[i] = tt.table.Topic.NewIterator(prefix[:prefLen], blockStart)
+	}
+	defer func() {
+		for _, it := range its {
+			it.Release()
+		}
+	}()
 
+	heads := make([]*logrec, len(its))
+	advance := func(i int) error {
+		heads[i] = nil
+		it := its[i]
+		for it.Next() {
+			s.scanned()
 			topicCount := bytesToPos(it.Value())
 			if topicCount < (patternLen - 1) {
 				continue
 			}
-			id := extractLogrecID(it.Key())
-			rec := newLogrec(id, topicCount)
-
+			rec := newLogrec(extractLogrecID(it.Key()), topicCount)
 			if blockStart != nil && rec.ID.BlockNumber() > blockEnd {
 				break
 			}
+			heads[i] = rec
+			break
+		}
+		return it.Error()
+	}
 
-			gonext, err = tt.walkNexts(ctx, rec, pattern, pos+1, onMatched)
-			if err != nil || !gonext {
-				it.Release()
-				return
-			}
+	for i := range its {
+		err = advance(i)
+		if err != nil {
+			return
 		}
+	}
 
-		err = it.Error()
-		it.Release()
+	for {
+		err = ctx.Err()
 		if err != nil {
 			return
 		}
 
+		var rec *logrec
+		for _, head := range heads {
+			if head != nil && (rec == nil || bytes.Compare(head.ID.Bytes(), rec.ID.Bytes()) < 0) {
+				rec = head
+			}
+		}
+		if rec == nil {
+			return
+		}
+		if s.keysExhausted() {
+			s.stop(rec.ID)
+			gonext = false
+			return
+		}
+
+		gonext, err = tt.walkNexts(ctx, s, rec, pos+1)
+		if err != nil || !gonext {
+			return
+		}
+		s.progress = true
+
+		// the same record may be matched by several (duplicated) variants
+		for i, head := range heads {
+			if head != nil && head.ID == rec.ID {
+				err = advance(i)
+				if err != nil {
+					return
+				}
+			}
+		}
 	}
-	return
 }
 
 // walkNexts for topics recursive.
 func (tt *Index) walkNexts(
-	ctx context.Context, rec *logrec, pattern [][]common.Hash, pos uint8, onMatched logHandler,
+	ctx context.Context, s *searchState, rec *logrec, pos uint8,
 ) (
 	gonext bool, err error,
 ) {
-	patternLen := uint8(len(pattern))
+	patternLen := uint8(len(s.pattern))
 	gonext = true
 	for {
 		// Max recursion depth is equal to len(topics) and limited by MaxCount.
 		if pos >= patternLen {
-			gonext, err = onMatched(rec)
+			gonext, err = s.matched(rec)
 			return
 		}
-		if len(pattern[pos]) < 1 {
+		if len(s.pattern[pos]) < 1 {
 			pos++
 			continue
 		}
@@ -110,17 +163,18 @@ func (tt *Index) walkNexts(
 	copy(prefix[prefLen:], rec.ID.Bytes())
 	prefLen += logrecKeySize
 
-	for _, variant := range pattern[pos] {
+	for _, variant := range s.pattern[pos] {
 		copy(prefix[0:], variant.Bytes())
 		it := tt.table.Topic.NewIterator(prefix[:prefLen], nil)
 		for it.Next() {
+			s.scanned()
 			err = ctx.Err()
 			if err != nil {
 				it.Release()
 				return
 			}
 
-			gonext, err = tt.walkNexts(ctx, rec, pattern, pos+1, onMatched)
+			gonext, err = tt.walkNexts(ctx, s, rec, pos+1)
 			if err != nil || !gonext {
 				it.Release()
 				return
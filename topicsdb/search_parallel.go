@@ -0,0 +1,294 @@
+package topicsdb
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// parallelBatchSize is a max number of index keys read by a worker at once.
+const parallelBatchSize = 256
+
+type (
+	idEntry struct {
+		id         ID
+		topicCount uint8
+	}
+
+	// variantScan reads log record IDs of a single pattern variant in ascending order.
+	variantScan struct {
+		prefix []byte
+		buf    []idEntry
+		next   ID // the key to continue reading from
+		done   bool
+	}
+
+	// posScan merges variants of a single pattern position.
+	posScan struct {
+		variants []*variantScan
+	}
+)
+
+type posState int
+
+const (
+	posReady posState = iota
+	posNeedsRefill
+	posExhausted
+)
+
+// head drops the buffered IDs below cand and returns the lowest remaining ID of the position.
+func (p *posScan) head(cand ID) (head *idEntry, state posState) {
+	exhausted := true
+	for _, v := range p.variants {
+		for len(v.buf) > 0 && bytes.Compare(v.buf[0].id[:], cand[:]) < 0 {
+			v.buf = v.buf[1:]
+		}
+		if len(v.buf) == 0 {
+			if !v.done {
+				return nil, posNeedsRefill
+			}
+			continue
+		}
+		exhausted = false
+		if head == nil || bytes.Compare(v.buf[0].id[:], head.id[:]) < 0 {
+			head = &v.buf[0]
+		}
+	}
+	if exhausted {
+		return nil, posExhausted
+	}
+	return head, posReady
+}
+
+// searchParallel is an alternative of searchLimited for patterns with many variants.
+// Instead of nested iteration, it merges variants of each position by log record ID
+// and intersects positions (leapfrog join). Variants are read in batches
+// and log records are fetched by a bounded pool of workers.
+func (tt *Index) searchParallel(ctx context.Context, pattern [][]common.Hash, blockStart []byte, blockEnd uint64, budget Budget, onMatched logHandler) (stats Stats, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := &searchState{
+		pattern:   pattern,
+		budget:    budget,
+		onMatched: onMatched,
+	}
+
+	var (
+		patternLen = uint8(len(pattern))
+		positions  = make([]*posScan, 0, len(pattern))
+		cand       ID
+	)
+	copy(cand[:], blockStart)
+	start := cand
+	for pos, variants := range pattern {
+		if len(variants) < 1 {
+			continue
+		}
+		p := &posScan{
+			variants: make([]*variantScan, len(variants)),
+		}
+		for i, variant := range variants {
+			prefix := make([]byte, 0, hashSize+uint8Size)
+			prefix = append(prefix, variant.Bytes()...)
+			prefix = append(prefix, posToBytes(uint8(pos))...)
+			p.variants[i] = &variantScan{
+				prefix: prefix,
+			}
+		}
+		positions = append(positions, p)
+	}
+	if len(positions) == 0 {
+		return
+	}
+
+	threads := tt.cfg.ParallelThreads
+	if threads < 1 {
+		threads = 1
+	}
+	sem := make(chan struct{}, threads)
+	for {
+		err = ctx.Err()
+		if err != nil {
+			return s.stats, err
+		}
+		if s.keysExhausted() {
+			s.stop(cand)
+			return s.stats, nil
+		}
+
+		err = tt.refillParallel(ctx, s, sem, positions, cand, blockEnd, patternLen-1)
+		if err != nil {
+			return s.stats, err
+		}
+
+		matched, finished := intersect(positions, &cand)
+		s.progress = s.progress || cand != start
+
+		gonext, err := tt.deliverParallel(s, sem, matched)
+		if err != nil || !gonext || finished {
+			return s.stats, err
+		}
+	}
+}
+
+// refillParallel reads the next batch of every drained variant.
+func (tt *Index) refillParallel(ctx context.Context, s *searchState, sem chan struct{}, positions []*posScan, cand ID, blockEnd uint64, minTopics uint8) error {
+	var tasks []*variantScan
+	for _, p := range positions {
+		for _, v := range p.variants {
+			if len(v.buf) == 0 && !v.done {
+				tasks = append(tasks, v)
+			}
+		}
+	}
+
+	// don't read much more than allowed by the budget
+	batch := parallelBatchSize
+	if s.budget.MaxScannedKeys > 0 && len(tasks) > 0 {
+		remain := (s.budget.MaxScannedKeys - s.stats.ScannedKeys) / len(tasks)
+		if remain < 1 {
+			remain = 1
+		}
+		if remain < batch {
+			batch = remain
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		scanned = make([]int, len(tasks))
+		errs    = make([]error, len(tasks))
+	)
+	for i, v := range tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, v *variantScan) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			scanned[i], errs[i] = tt.scanVariant(ctx, v, cand, blockEnd, minTopics, batch)
+		}(i, v)
+	}
+	wg.Wait()
+
+	for i := range tasks {
+		s.stats.ScannedKeys += scanned[i]
+		if errs[i] != nil {
+			return errs[i]
+		}
+	}
+	return nil
+}
+
+// scanVariant reads a batch of IDs which are not lower than cand.
+func (tt *Index) scanVariant(ctx context.Context, v *variantScan, cand ID, blockEnd uint64, minTopics uint8, batch int) (scanned int, err error) {
+	start := v.next
+	if bytes.Compare(cand[:], start[:]) > 0 {
+		start = cand
+	}
+	if cap(v.buf) < batch {
+		v.buf = make([]idEntry, 0, batch)
+	}
+	v.buf = v.buf[:0]
+
+	it := tt.table.Topic.NewIterator(v.prefix, start.Bytes())
+	defer it.Release()
+	for scanned < batch {
+		if !it.Next() {
+			v.done = true
+			break
+		}
+		scanned++
+		err = ctx.Err()
+		if err != nil {
+			return
+		}
+
+		id := extractLogrecID(it.Key())
+		if id.BlockNumber() > blockEnd {
+			v.done = true
+			break
+		}
+		v.next = id.next()
+		topicCount := bytesToPos(it.Value())
+		if topicCount < minTopics {
+			continue
+		}
+		v.buf = append(v.buf, idEntry{
+			id:         id,
+			topicCount: topicCount,
+		})
+	}
+	err = it.Error()
+	return
+}
+
+// intersect finds IDs buffered by all the positions, until any position needs a refill.
+// The cand is moved to the lowest undecided ID.
+func intersect(positions []*posScan, cand *ID) (matched []*logrec, finished bool) {
+	for {
+		var (
+			max  = *cand
+			head *idEntry
+		)
+		for _, p := range positions {
+			h, state := p.head(*cand)
+			switch state {
+			case posNeedsRefill:
+				return
+			case posExhausted:
+				finished = true
+				return
+			}
+			if bytes.Compare(h.id[:], max[:]) > 0 {
+				max = h.id
+			}
+			head = h
+		}
+		if max != *cand {
+			*cand = max
+			continue
+		}
+		// all the positions are at cand
+		matched = append(matched, newLogrec(*cand, head.topicCount))
+		*cand = cand.next()
+	}
+}
+
+// deliverParallel fetches matched records by the workers and passes them to the handler in order.
+func (tt *Index) deliverParallel(s *searchState, sem chan struct{}, matched []*logrec) (gonext bool, err error) {
+	fetch := matched
+	if s.budget.MaxResults > 0 {
+		remain := s.budget.MaxResults - s.stats.Results
+		if remain < len(fetch) {
+			fetch = fetch[:remain]
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, rec := range fetch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(rec *logrec) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			rec.fetch(tt.table.Logrec)
+		}(rec)
+	}
+	wg.Wait()
+
+	gonext = true
+	for _, rec := range matched {
+		if rec.err != nil {
+			return false, rec.err
+		}
+		gonext, err = s.matched(rec)
+		if err != nil || !gonext {
+			return
+		}
+	}
+	return
+}
@@ -38,8 +38,9 @@ func BenchmarkSearch(b *testing.B) {
 	}
 
 	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash) ([]*types.Log, error){
-		"sync":  index.FindInBlocks,
-		"async": index.FindInBlocksAsync,
+		"sync":     index.FindInBlocks,
+		"async":    index.FindInBlocksAsync,
+		"parallel": index.FindInBlocksParallel,
 	} {
 		b.Run(dsc, func(b *testing.B) {
 			b.ResetTimer()
@@ -52,3 +53,40 @@ func BenchmarkSearch(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkSearchManyVariants(b *testing.B) {
+	topics, recs, _ := genTestData(1000)
+
+	mem := memorydb.New()
+	mem.SetDelay(1 * time.Millisecond)
+	index := NewWithConfig(mem, Config{ParallelThreads: 8})
+
+	var addresses []common.Hash
+	for i, rec := range recs {
+		err := index.Push(rec)
+		require.NoError(b, err)
+		if i%10 == 0 {
+			addresses = append(addresses, rec.Address.Hash())
+		}
+	}
+
+	query := [][]common.Hash{
+		addresses,
+		topics,
+		topics,
+	}
+
+	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash) ([]*types.Log, error){
+		"sync":     index.FindInBlocksSequential,
+		"parallel": index.FindInBlocksParallel,
+	} {
+		b.Run(dsc, func(b *testing.B) {
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := method(nil, 0, 0xffffffff, query)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
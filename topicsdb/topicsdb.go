@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"runtime"
+	"time"
 
 	"github.com/Fantom-foundation/lachesis-base/inter/idx"
 	"github.com/Fantom-foundation/lachesis-base/kvdb"
@@ -17,10 +19,26 @@ const MaxTopicsCount = math.MaxUint8
 
 var (
 	ErrEmptyTopics = fmt.Errorf("Empty topics")
+	ErrInvalidID   = fmt.Errorf("Invalid log record ID")
 )
 
+// Config of Index.
+type Config struct {
+	// ParallelThreads is a max number of workers of a single parallel search.
+	// Parallel search is used for patterns with many variants if ParallelThreads > 1.
+	ParallelThreads int
+}
+
+// DefaultConfig for product.
+func DefaultConfig() Config {
+	return Config{
+		ParallelThreads: runtime.NumCPU(),
+	}
+}
+
 // Index is a specialized indexes for log records storing and fetching.
 type Index struct {
+	cfg   Config
 	db    kvdb.Store
 	table struct {
 		// topic+topicN+(blockN+TxHash+logIndex) -> topic_count (where topicN=0 is for address)
@@ -30,10 +48,16 @@ type Index struct {
 	}
 }
 
-// New Index instance.
+// New Index instance with sequential search only.
 func New(db kvdb.Store) *Index {
+	return NewWithConfig(db, Config{})
+}
+
+// NewWithConfig creates Index instance.
+func NewWithConfig(db kvdb.Store, cfg Config) *Index {
 	tt := &Index{
-		db: db,
+		cfg: cfg,
+		db:  db,
 	}
 
 	table.MigrateTables(&tt.table, tt.db)
@@ -44,10 +68,11 @@ func New(db kvdb.Store) *Index {
 // FindInBlocks returns all log records of block range by pattern. 1st pattern element is an address.
 // The same as FindInBlocksAsync but fetches log's body sync.
 func (tt *Index) FindInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash) (logs []*types.Log, err error) {
-	err = tt.ForEachInBlocks(
+	_, err = tt.ForEachInBlocks(
 		ctx,
 		from, to,
 		pattern,
+		Budget{},
 		func(l *types.Log) bool {
 			logs = append(logs, l)
 			return true
@@ -63,32 +88,54 @@ func (tt *Index) ForEach(ctx context.Context, pattern [][]common.Hash, onLog fun
 		return err
 	}
 
-	onMatched := func(rec *logrec) (gonext bool, err error) {
-		rec.fetch(tt.table.Logrec)
-		if rec.err != nil {
-			err = rec.err
-			return
-		}
-		gonext = onLog(rec.result)
+	return tt.searchLazy(ctx, pattern, nil, 0, tt.onLogMatched(onLog))
+}
+
+// ForEachInBlocks matches log records of block range by pattern. 1st pattern element is an address.
+// Log records are visited in ID order. If the budget is exhausted, the search stops
+// and stats.Next points to the record to continue from with ContinueInBlocks.
+func (tt *Index) ForEachInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash, budget Budget, onLog func(*types.Log) (gonext bool)) (stats Stats, err error) {
+	if from > to {
 		return
 	}
 
-	return tt.searchLazy(ctx, pattern, nil, 0, onMatched)
+	return tt.forEachInRange(ctx, uintToBytes(uint64(from)), to, pattern, budget, onLog)
 }
 
-// ForEachInBlocks matches log records of block range by pattern. 1st pattern element is an address.
-func (tt *Index) ForEachInBlocks(ctx context.Context, from, to idx.Block, pattern [][]common.Hash, onLog func(*types.Log) (gonext bool)) error {
-	if from > to {
-		return nil
+// ContinueInBlocks is the same as ForEachInBlocks, but starts from the cursor returned by a previous query.
+func (tt *Index) ContinueInBlocks(ctx context.Context, cursor ID, to idx.Block, pattern [][]common.Hash, budget Budget, onLog func(*types.Log) (gonext bool)) (stats Stats, err error) {
+	if idx.Block(cursor.BlockNumber()) > to {
+		return
 	}
 
-	pattern, err := limitPattern(pattern)
+	return tt.forEachInRange(ctx, cursor.Bytes(), to, pattern, budget, onLog)
+}
+
+func (tt *Index) forEachInRange(ctx context.Context, start []byte, to idx.Block, pattern [][]common.Hash, budget Budget, onLog func(*types.Log) (gonext bool)) (stats Stats, err error) {
+	pattern, err = limitPattern(pattern)
 	if err != nil {
-		return err
+		return
+	}
+
+	search := tt.searchLimited
+	if tt.cfg.ParallelThreads > 1 && hasManyVariants(pattern) {
+		search = tt.searchParallel
 	}
 
-	onMatched := func(rec *logrec) (gonext bool, err error) {
-		rec.fetch(tt.table.Logrec)
+	started := time.Now()
+	stats, err = search(ctx, pattern, start, uint64(to), budget, tt.onLogMatched(onLog))
+	queryTimer.UpdateSince(started)
+	recordQueryMetrics(stats)
+
+	return
+}
+
+// onLogMatched fetches the matched log record, unless the search has fetched it already, and passes it to onLog.
+func (tt *Index) onLogMatched(onLog func(*types.Log) (gonext bool)) logHandler {
+	return func(rec *logrec) (gonext bool, err error) {
+		if rec.result == nil {
+			rec.fetch(tt.table.Logrec)
+		}
 		if rec.err != nil {
 			err = rec.err
 			return
@@ -96,8 +143,15 @@ func (tt *Index) ForEachInBlocks(ctx context.Context, from, to idx.Block, patter
 		gonext = onLog(rec.result)
 		return
 	}
+}
 
-	return tt.searchLazy(ctx, pattern, uintToBytes(uint64(from)), uint64(to), onMatched)
+func hasManyVariants(pattern [][]common.Hash) bool {
+	for _, variants := range pattern {
+		if len(variants) > 1 {
+			return true
+		}
+	}
+	return false
 }
 
 func limitPattern(pattern [][]common.Hash) (limited [][]common.Hash, err error) {
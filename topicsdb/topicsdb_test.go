@@ -66,6 +66,39 @@ func (tt *Index) FindInBlocksAsync(ctx context.Context, from, to idx.Block, patt
 	return
 }
 
+// FindInBlocksParallel returns all log records of block range by pattern.
+// Uses parallel search regardless of pattern.
+func (tt *Index) FindInBlocksParallel(ctx context.Context, from, to idx.Block, pattern [][]common.Hash) (logs []*types.Log, err error) {
+	return tt.findInBlocksBy(tt.searchParallel, ctx, from, to, pattern)
+}
+
+// FindInBlocksSequential returns all log records of block range by pattern.
+// Uses sequential search regardless of pattern and config.
+func (tt *Index) FindInBlocksSequential(ctx context.Context, from, to idx.Block, pattern [][]common.Hash) (logs []*types.Log, err error) {
+	return tt.findInBlocksBy(tt.searchLimited, ctx, from, to, pattern)
+}
+
+func (tt *Index) findInBlocksBy(
+	search func(context.Context, [][]common.Hash, []byte, uint64, Budget, logHandler) (Stats, error),
+	ctx context.Context, from, to idx.Block, pattern [][]common.Hash,
+) (logs []*types.Log, err error) {
+	if from > to {
+		return
+	}
+
+	pattern, err = limitPattern(pattern)
+	if err != nil {
+		return
+	}
+
+	_, err = search(ctx, pattern, uintToBytes(uint64(from)), uint64(to), Budget{}, tt.onLogMatched(func(l *types.Log) bool {
+		logs = append(logs, l)
+		return true
+	}))
+
+	return
+}
+
 func TestIndexSearchMultyVariants(t *testing.T) {
 	logger.SetTestMode(t)
 	var (
@@ -120,8 +153,9 @@ func TestIndexSearchMultyVariants(t *testing.T) {
 	}
 
 	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash) ([]*types.Log, error){
-		"sync":  index.FindInBlocks,
-		"async": index.FindInBlocksAsync,
+		"sync":     index.FindInBlocks,
+		"async":    index.FindInBlocksAsync,
+		"parallel": index.FindInBlocksParallel,
 	} {
 		t.Run(dsc, func(t *testing.T) {
 
@@ -204,8 +238,9 @@ func TestIndexSearchSingleVariant(t *testing.T) {
 	}
 
 	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash) ([]*types.Log, error){
-		"sync":  index.FindInBlocks,
-		"async": index.FindInBlocksAsync,
+		"sync":     index.FindInBlocks,
+		"async":    index.FindInBlocksAsync,
+		"parallel": index.FindInBlocksParallel,
 	} {
 		t.Run(dsc, func(t *testing.T) {
 			require := require.New(t)
@@ -279,8 +314,9 @@ func TestIndexSearchSimple(t *testing.T) {
 	)
 
 	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash) ([]*types.Log, error){
-		"sync":  index.FindInBlocks,
-		"async": index.FindInBlocksAsync,
+		"sync":     index.FindInBlocks,
+		"async":    index.FindInBlocksAsync,
+		"parallel": index.FindInBlocksParallel,
 	} {
 		t.Run(dsc, func(t *testing.T) {
 			require := require.New(t)
@@ -331,8 +367,9 @@ func TestMaxTopicsCount(t *testing.T) {
 	require.NoError(t, err)
 
 	for dsc, method := range map[string]func(context.Context, idx.Block, idx.Block, [][]common.Hash) ([]*types.Log, error){
-		"sync":  index.FindInBlocks,
-		"async": index.FindInBlocksAsync,
+		"sync":     index.FindInBlocks,
+		"async":    index.FindInBlocksAsync,
+		"parallel": index.FindInBlocksParallel,
 	} {
 		t.Run(dsc, func(t *testing.T) {
 			require := require.New(t)
@@ -348,6 +385,74 @@ func TestMaxTopicsCount(t *testing.T) {
 	require.Equal(t, MaxTopicsCount+1, len(pattern[0]))
 }
 
+func TestIndexSearchBudget(t *testing.T) {
+	logger.SetTestMode(t)
+
+	topics, recs, _ := genTestData(100)
+
+	for dsc, cfg := range map[string]Config{
+		"sync":     {},
+		"parallel": {ParallelThreads: 4},
+	} {
+		t.Run(dsc, func(t *testing.T) {
+			require := require.New(t)
+
+			index := NewWithConfig(memorydb.New(), cfg)
+			for _, rec := range recs {
+				err := index.Push(rec)
+				require.NoError(err)
+			}
+
+			pattern := [][]common.Hash{
+				{},
+				topics,
+			}
+			expect, err := index.FindInBlocks(nil, 0, 1000, pattern)
+			require.NoError(err)
+			require.Equal(len(recs), len(expect))
+
+			for _, budget := range []Budget{
+				{MaxResults: 1},
+				{MaxResults: 7},
+				{MaxScannedKeys: 1},
+				{MaxScannedKeys: 10},
+				{MaxResults: 3, MaxScannedKeys: 5},
+			} {
+				var (
+					got    []*types.Log
+					stats  Stats
+					cursor *ID
+					pages  int
+				)
+				onLog := func(l *types.Log) bool {
+					got = append(got, l)
+					return true
+				}
+				for {
+					if cursor == nil {
+						stats, err = index.ForEachInBlocks(nil, 0, 1000, pattern, budget, onLog)
+					} else {
+						stats, err = index.ContinueInBlocks(nil, *cursor, 1000, pattern, budget, onLog)
+					}
+					require.NoError(err)
+					pages++
+					if budget.MaxResults > 0 {
+						require.LessOrEqual(stats.Results, budget.MaxResults)
+					}
+					if stats.Next == nil {
+						break
+					}
+					require.Greater(stats.Results, 0, "no progress")
+					cursor = stats.Next
+				}
+
+				require.Greater(pages, 1)
+				require.Equal(expect, got, budget)
+			}
+		})
+	}
+}
+
 func genTestData(count int) (
 	topics []common.Hash,
 	recs []*types.Log,
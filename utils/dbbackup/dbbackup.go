@@ -0,0 +1,481 @@
+// Package dbbackup makes consistent checkpoints of a directory of LevelDB databases.
+//
+// A checkpoint is a directory with the same layout as the source directory and a manifest file.
+// Table files are immutable in LevelDB, so they are hardlinked when the checkpoint is on the same
+// filesystem and copied otherwise. Journals, manifests and CURRENT files are always copied.
+// The manifest is written last, so a checkpoint without the manifest is incomplete.
+package dbbackup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Fantom-foundation/lachesis-base/kvdb/flushable"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+const (
+	// ManifestFile is the name of the manifest file in the checkpoint directory.
+	ManifestFile = "manifest.json"
+	// ManifestVersion is the version of the manifest format.
+	ManifestVersion = 1
+
+	// maxAttempts is the number of attempts to checkpoint a database which is modified by background compactions.
+	maxAttempts = 10
+)
+
+var errModified = errors.New("database is modified during the checkpoint")
+
+// File is a file of a checkpointed database.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Linked bool   `json:"linked"`
+}
+
+// Database is a checkpointed database.
+type Database struct {
+	Name  string `json:"name"`
+	Files []File `json:"files"`
+}
+
+// Manifest describes a checkpoint.
+type Manifest struct {
+	Version   int           `json:"version"`
+	Time      time.Time     `json:"time"`
+	FlushID   hexutil.Bytes `json:"flushId"`
+	Databases []Database    `json:"databases"`
+}
+
+// Size returns the total size of the checkpoint files, and the size of hardlinked files.
+func (m *Manifest) Size() (total, linked int64) {
+	for _, db := range m.Databases {
+		for _, f := range db.Files {
+			total += f.Size
+			if f.Linked {
+				linked += f.Size
+			}
+		}
+	}
+	return total, linked
+}
+
+// Checkpoint copies every database of srcDir into dir and writes the manifest.
+// The databases must not be written during the call, except for background compactions of open databases.
+// All the databases must have the same clean flush ID stored under flushIDKey.
+func Checkpoint(srcDir, dir string, flushIDKey []byte) (*Manifest, error) {
+	if err := checkEmptyDir(dir); err != nil {
+		return nil, err
+	}
+	names, err := dbNames(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no databases in %s", srcDir)
+	}
+
+	m := &Manifest{
+		Version: ManifestVersion,
+		Time:    time.Now().UTC(),
+	}
+	for _, name := range names {
+		files, err := checkpointDB(filepath.Join(srcDir, name), filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("%s DB: %v", name, err)
+		}
+		m.Databases = append(m.Databases, Database{
+			Name:  name,
+			Files: files,
+		})
+	}
+
+	m.FlushID, err = checkFlushIDs(dir, names, flushIDKey, false)
+	if err != nil {
+		return nil, err
+	}
+	return m, writeManifest(dir, m)
+}
+
+// Verify checks the files of the checkpoint against the manifest, reads every record of every database
+// verifying checksums, and checks that every database has the flush ID of the manifest.
+func Verify(dir string, flushIDKey []byte) (*Manifest, error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	names := make([]string, len(m.Databases))
+	for i, db := range m.Databases {
+		names[i] = db.Name
+		for _, f := range db.Files {
+			info, err := os.Stat(filepath.Join(dir, db.Name, f.Name))
+			if err != nil {
+				return nil, fmt.Errorf("%s DB: %v", db.Name, err)
+			}
+			if info.Size() != f.Size {
+				return nil, fmt.Errorf("%s DB: file %s has size %d, expected %d", db.Name, f.Name, info.Size(), f.Size)
+			}
+		}
+	}
+	existing, err := dbNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Join(existing, ",") != strings.Join(names, ",") {
+		return nil, fmt.Errorf("databases %v don't match the manifest %v", existing, names)
+	}
+
+	flushID, err := checkFlushIDs(dir, names, flushIDKey, true)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(flushID, m.FlushID) {
+		return nil, fmt.Errorf("flush ID %s doesn't match the manifest %s", hexutil.Encode(flushID), m.FlushID.String())
+	}
+	return m, nil
+}
+
+// Restore copies the databases of the checkpoint into dir, which must be empty or not exist.
+// Table files are hardlinked where possible. The checkpoint should be verified beforehand.
+func Restore(checkpointDir, dir string) error {
+	m, err := ReadManifest(checkpointDir)
+	if err != nil {
+		return err
+	}
+	if err := checkEmptyDir(dir); err != nil {
+		return err
+	}
+	for _, db := range m.Databases {
+		if err := os.MkdirAll(filepath.Join(dir, db.Name), 0700); err != nil {
+			return err
+		}
+		for _, f := range db.Files {
+			src := filepath.Join(checkpointDir, db.Name, f.Name)
+			dst := filepath.Join(dir, db.Name, f.Name)
+			if isTableFile(f.Name) {
+				_, err = linkOrCopy(src, dst)
+			} else {
+				_, err = copyFile(src, dst)
+			}
+			if err != nil {
+				return fmt.Errorf("%s DB: %v", db.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// LockDBs locks the databases of the directory against opening for writing, i.e. fails if they are in use.
+func LockDBs(dir string) (release func(), err error) {
+	names, err := dbNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	locked := make([]storage.Storage, 0, len(names))
+	release = func() {
+		for _, s := range locked {
+			_ = s.Close()
+		}
+	}
+	for _, name := range names {
+		s, err := storage.OpenFile(filepath.Join(dir, name), true)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("%s DB is in use: %v", name, err)
+		}
+		locked = append(locked, s)
+	}
+	return release, nil
+}
+
+// ReadManifest reads the manifest of the checkpoint.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("malformed manifest: %v", err)
+	}
+	return m, nil
+}
+
+func writeManifest(dir string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ManifestFile+".tmp")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, ManifestFile))
+}
+
+// checkpointDB checkpoints a database, retrying if it's modified by a background compaction.
+func checkpointDB(src, dst string) ([]File, error) {
+	for attempt := 0; ; attempt++ {
+		files, err := tryCheckpointDB(src, dst)
+		if err == nil {
+			return files, nil
+		}
+		if rmErr := os.RemoveAll(dst); rmErr != nil {
+			return nil, rmErr
+		}
+		if err != errModified || attempt+1 >= maxAttempts {
+			return nil, err
+		}
+	}
+}
+
+// tryCheckpointDB copies the LevelDB manifest first and then the files, so the copied manifest refers only
+// to existing files. Compactions append to the manifest, so the checkpoint is consistent if the manifest
+// isn't changed after the files are copied.
+func tryCheckpointDB(src, dst string) ([]File, error) {
+	current, manifestSize, err := readCurrent(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return nil, err
+	}
+
+	var files []File
+	// the manifest is copied up to the size observed before, the CURRENT file is written after it
+	manifestFile := File{Name: current, Size: manifestSize}
+	if err := copyFilePrefix(filepath.Join(src, current), filepath.Join(dst, current), manifestSize); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errModified
+		}
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isDataFile(name) {
+			continue
+		}
+		var (
+			size   int64
+			linked bool
+		)
+		if isTableFile(name) {
+			linked, err = linkOrCopy(filepath.Join(src, name), filepath.Join(dst, name))
+		} else {
+			size, err = copyFile(filepath.Join(src, name), filepath.Join(dst, name))
+		}
+		if os.IsNotExist(err) {
+			// deleted by a compaction
+			return nil, errModified
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isTableFile(name) {
+			info, err := os.Stat(filepath.Join(dst, name))
+			if err != nil {
+				return nil, err
+			}
+			size = info.Size()
+		}
+		files = append(files, File{Name: name, Size: size, Linked: linked})
+	}
+
+	afterCurrent, afterSize, err := readCurrent(src)
+	if err != nil {
+		return nil, err
+	}
+	if afterCurrent != current || afterSize != manifestSize {
+		return nil, errModified
+	}
+
+	currentData := []byte(current + "\n")
+	if err := ioutil.WriteFile(filepath.Join(dst, "CURRENT"), currentData, 0600); err != nil {
+		return nil, err
+	}
+	files = append(files, manifestFile, File{Name: "CURRENT", Size: int64(len(currentData))})
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// readCurrent returns the name of the current LevelDB manifest and its size.
+func readCurrent(dir string) (string, int64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "CURRENT"))
+	if err != nil {
+		return "", 0, err
+	}
+	name := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(name, "MANIFEST-") || strings.ContainsAny(name, "/\\") {
+		return "", 0, fmt.Errorf("malformed CURRENT file in %s", dir)
+	}
+	info, err := os.Stat(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		// the manifest is being rotated
+		return "", 0, errModified
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return name, info.Size(), nil
+}
+
+// checkFlushIDs opens the databases read-only and checks that all of them have the same clean flush ID.
+// If fullScan is true, every record is read to verify the checksums.
+func checkFlushIDs(dir string, names []string, flushIDKey []byte, fullScan bool) ([]byte, error) {
+	var flushID []byte
+	for _, name := range names {
+		mark, err := readFlushID(filepath.Join(dir, name), flushIDKey, fullScan)
+		if err != nil {
+			return nil, fmt.Errorf("%s DB: %v", name, err)
+		}
+		if len(mark) == 0 || mark[0] != flushable.CleanPrefix {
+			return nil, fmt.Errorf("%s DB isn't flushed: flush ID %s", name, hexutil.Encode(mark))
+		}
+		if flushID == nil {
+			flushID = mark
+		} else if !bytes.Equal(mark, flushID) {
+			return nil, fmt.Errorf("%s DB isn't synced with other DBs: flush ID %s, expected %s", name, hexutil.Encode(mark), hexutil.Encode(flushID))
+		}
+	}
+	return flushID, nil
+}
+
+func readFlushID(path string, flushIDKey []byte, fullScan bool) ([]byte, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		ReadOnly:       true,
+		ErrorIfMissing: true,
+		Strict:         opt.StrictAll,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	if fullScan {
+		it := db.NewIterator(nil, nil)
+		for it.Next() {
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return nil, err
+		}
+	}
+	mark, err := db.Get(flushIDKey, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, errors.New("flush ID isn't found")
+	}
+	return mark, err
+}
+
+// dbNames returns the sorted names of database directories.
+func dbNames(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func checkEmptyDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, 0700)
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fmt.Errorf("directory %s isn't empty", dir)
+	}
+	return nil
+}
+
+// isDataFile returns true for LevelDB files which are required to open the database,
+// except for the manifest and CURRENT files.
+func isDataFile(name string) bool {
+	return isTableFile(name) || strings.HasSuffix(name, ".log")
+}
+
+func isTableFile(name string) bool {
+	return strings.HasSuffix(name, ".ldb") || strings.HasSuffix(name, ".sst")
+}
+
+// linkOrCopy hardlinks the file, or copies it if hardlinks aren't supported.
+func linkOrCopy(src, dst string) (linked bool, err error) {
+	if err := os.Link(src, dst); err == nil {
+		return true, nil
+	} else if os.IsNotExist(err) {
+		return false, err
+	}
+	_, err = copyFile(src, dst)
+	return false, err
+}
+
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+func copyFilePrefix(src, dst string, size int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(out, in, size)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
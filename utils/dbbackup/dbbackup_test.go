@@ -0,0 +1,116 @@
+package dbbackup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/kvdb/flushable"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+var testFlushIDKey = []byte("flushID")
+
+func writeTestDBs(t *testing.T, dir string, flushIDs map[string][]byte) {
+	for name, flushID := range flushIDs {
+		db, err := leveldb.OpenFile(filepath.Join(dir, name), &opt.Options{WriteBuffer: 1024})
+		require.NoError(t, err)
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, db.Put([]byte(fmt.Sprintf("%s-%d", name, i)), make([]byte, 100), nil))
+		}
+		require.NoError(t, db.Put(testFlushIDKey, flushID, nil))
+		require.NoError(t, db.Close())
+	}
+}
+
+func readTestDB(t *testing.T, path string) int {
+	db, err := leveldb.OpenFile(path, &opt.Options{ErrorIfMissing: true})
+	require.NoError(t, err)
+	defer db.Close()
+	num := 0
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		num++
+	}
+	it.Release()
+	require.NoError(t, it.Error())
+	return num
+}
+
+func TestCheckpoint(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "dbbackup_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	src := filepath.Join(tmp, "src")
+	flushID := []byte{flushable.CleanPrefix, 1, 2, 3}
+	writeTestDBs(t, src, map[string][]byte{
+		"gossip":   flushID,
+		"lachesis": flushID,
+	})
+
+	release, err := LockDBs(src)
+	require.NoError(t, err)
+	dir := filepath.Join(tmp, "checkpoint")
+	m, err := Checkpoint(src, dir, testFlushIDKey)
+	require.NoError(t, err)
+	release()
+	require.Equal(t, []byte(m.FlushID), flushID)
+	require.Len(t, m.Databases, 2)
+	total, linked := m.Size()
+	require.NotZero(t, linked)
+	require.Greater(t, total, linked)
+
+	// not empty target
+	_, err = Checkpoint(src, dir, testFlushIDKey)
+	require.Error(t, err)
+
+	verified, err := Verify(dir, testFlushIDKey)
+	require.NoError(t, err)
+	require.Equal(t, m.FlushID, verified.FlushID)
+
+	// restored DBs are independent of the checkpoint
+	restored := filepath.Join(tmp, "restored")
+	require.NoError(t, Restore(dir, restored))
+	require.Equal(t, 1001, readTestDB(t, filepath.Join(restored, "gossip")))
+	require.Equal(t, 1001, readTestDB(t, filepath.Join(restored, "lachesis")))
+	_, err = Verify(dir, testFlushIDKey)
+	require.NoError(t, err)
+
+	// truncated file
+	f := m.Databases[0].Files[0]
+	require.NoError(t, os.Truncate(filepath.Join(dir, m.Databases[0].Name, f.Name), f.Size-1))
+	_, err = Verify(dir, testFlushIDKey)
+	require.Error(t, err)
+}
+
+func TestCheckpointNotSynced(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "dbbackup_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	src := filepath.Join(tmp, "src")
+	writeTestDBs(t, src, map[string][]byte{
+		"gossip":   {flushable.CleanPrefix, 1},
+		"lachesis": {flushable.CleanPrefix, 2},
+	})
+	_, err = Checkpoint(src, filepath.Join(tmp, "checkpoint"), testFlushIDKey)
+	require.Error(t, err)
+
+	dirty := filepath.Join(tmp, "dirty")
+	writeTestDBs(t, dirty, map[string][]byte{
+		"gossip": {flushable.DirtyPrefix, 1},
+	})
+	_, err = Checkpoint(dirty, filepath.Join(tmp, "checkpoint2"), testFlushIDKey)
+	require.Error(t, err)
+
+	// DBs in use
+	db, err := leveldb.OpenFile(filepath.Join(src, "gossip"), nil)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = LockDBs(src)
+	require.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Fantom-foundation/go-opera/utils/ioread"
+)
+
+// PartSuffix is appended to the path of a not completed download.
+const PartSuffix = ".part"
+
+// ProgressFn is called while a file is downloaded, total is -1 if the size is unknown.
+type ProgressFn func(done, total int64)
+
+// Head fetches the first n bytes of the url.
+func Head(client *http.Client, url string, n int) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	// the server may ignore the range and send the whole content
+	head := make([]byte, n)
+	err = ioread.ReadAll(resp.Body, head)
+	if err != nil {
+		return nil, err
+	}
+	return head, nil
+}
+
+// File downloads the url into the path.
+// The content is written into the path with PartSuffix first, so an interrupted download is resumed
+// if the server supports ranges. The file is renamed to the path when the download is complete.
+func File(client *http.Client, url, path string, progress ProgressFn) error {
+	part := path + PartSuffix
+	f, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	err = resume(client, url, f, progress)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(part, path)
+}
+
+func resume(client *http.Client, url string, f *os.File, progress ProgressFn) error {
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset != 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	total := int64(-1)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset != 0:
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+		if start != offset {
+			return fmt.Errorf("server resumed from %d instead of %d", start, offset)
+		}
+		total = size
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset != 0:
+		// the part is complete already, or the remote file has changed
+		_, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err == nil && size == offset {
+			return nil
+		}
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		return resume(client, url, f, progress)
+	case resp.StatusCode == http.StatusOK:
+		// ranges aren't supported, start from scratch
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		offset = 0
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+	default:
+		return fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+
+	done := offset
+	buf := make([]byte, 256*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n != 0 {
+			if _, werr := f.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			done += int64(n)
+			if progress != nil {
+				progress(done, total)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if total >= 0 && done != total {
+		return fmt.Errorf("incomplete download: %d of %d bytes", done, total)
+	}
+	return nil
+}
+
+// parseContentRange parses the "bytes start-end/size" header value.
+func parseContentRange(s string) (start, size int64, err error) {
+	errMalformed := errors.New("malformed Content-Range header: " + s)
+	if !strings.HasPrefix(s, "bytes ") {
+		return 0, 0, errMalformed
+	}
+	s = strings.TrimPrefix(s, "bytes ")
+	slash := strings.IndexByte(s, '/')
+	if slash < 0 {
+		return 0, 0, errMalformed
+	}
+	size, err = strconv.ParseInt(s[slash+1:], 10, 64)
+	if err != nil {
+		return 0, 0, errMalformed
+	}
+	if s[:slash] == "*" {
+		return 0, size, nil
+	}
+	dash := strings.IndexByte(s[:slash], '-')
+	if dash < 0 {
+		return 0, 0, errMalformed
+	}
+	start, err = strconv.ParseInt(s[:dash], 10, 64)
+	if err != nil {
+		return 0, 0, errMalformed
+	}
+	return start, size, nil
+}
@@ -0,0 +1,67 @@
+package download
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	content := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(1)).Read(content)
+	ranges := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "genesis.g", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ranges.Close()
+	noRanges := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer noRanges.Close()
+
+	dir, err := ioutil.TempDir("", "download_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	head, err := Head(ranges.Client(), ranges.URL, 8)
+	require.NoError(t, err)
+	require.Equal(t, content[:8], head)
+	head, err = Head(noRanges.Client(), noRanges.URL, 8)
+	require.NoError(t, err)
+	require.Equal(t, content[:8], head)
+
+	for name, srv := range map[string]*httptest.Server{"ranges": ranges, "noRanges": noRanges} {
+		for _, partSize := range []int{0, 1000, len(content)} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, ioutil.WriteFile(path+PartSuffix, content[:partSize], 0644))
+			var last int64
+			err := File(srv.Client(), srv.URL, path, func(done, total int64) {
+				require.Contains(t, []int64{-1, int64(len(content))}, total)
+				last = done
+			})
+			require.NoError(t, err)
+			got, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, content, got)
+			_, err = os.Stat(path + PartSuffix)
+			require.True(t, os.IsNotExist(err))
+			if partSize != len(content) {
+				require.Equal(t, int64(len(content)), last)
+			}
+		}
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	err = File(notFound.Client(), notFound.URL, filepath.Join(dir, "missing"), nil)
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "missing"))
+	require.True(t, os.IsNotExist(err))
+}
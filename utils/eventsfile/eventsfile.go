@@ -0,0 +1,60 @@
+// Package eventsfile implements the v2 format of events files.
+//
+// The file consists of a header, chunks of events and an index trailer:
+//
+//	header:  magic (4 bytes) | version (4 bytes) | compression (1 byte)
+//	chunk:   'c' | epoch (4 bytes) | events num (4 bytes) | payload size (4 bytes) | sha256 of raw payload (32 bytes) | payload
+//	index:   'i' | entries num (4 bytes) | entries | sha256 of entries (32 bytes)
+//	entry:   epoch (4 bytes) | events num (4 bytes) | chunk offset (8 bytes)
+//	footer:  index offset (8 bytes) | magic (4 bytes)
+//
+// Raw payload is the concatenated RLP of the events, which is compressed if the compression is enabled.
+// Chunks never contain events of different epochs, but events of one epoch may be split into several chunks.
+// All the integers are big-endian.
+package eventsfile
+
+import (
+	"errors"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/status-im/keycard-go/hexutils"
+)
+
+const (
+	// CompressionNone stores chunks payload as is
+	CompressionNone byte = 0
+	// CompressionZstd compresses chunks payload with zstd
+	CompressionZstd byte = 1
+
+	// HeaderSize is the offset of the first chunk
+	HeaderSize = 9
+
+	chunkKind = 'c'
+	indexKind = 'i'
+
+	chunkHeaderSize = 1 + 4 + 4 + 4 + 32
+	indexEntrySize  = 4 + 4 + 8
+	footerSize      = 8 + 4
+
+	// DefaultMaxChunkSize is the default limit of chunk raw payload size
+	DefaultMaxChunkSize = 32 * 1024 * 1024
+	// maxPayloadSize limits the size of allocated buffers while reading a possibly corrupted file
+	maxPayloadSize = 1024 * 1024 * 1024
+)
+
+var (
+	// FileHeader is the magic of events files, which is the same for all the versions
+	FileHeader = hexutils.HexToBytes("7e995678")
+	// FileVersion is the version of the format
+	FileVersion = hexutils.HexToBytes("00020001")
+
+	ErrChecksum = errors.New("checksum mismatch")
+	ErrNoIndex  = errors.New("index trailer not found")
+)
+
+// IndexEntry is a position of a chunk in the file.
+type IndexEntry struct {
+	Epoch  idx.Epoch
+	Events uint32
+	Offset uint64
+}
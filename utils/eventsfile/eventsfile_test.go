@@ -0,0 +1,128 @@
+package eventsfile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	Epoch idx.Epoch
+	Data  []byte
+}
+
+func writeTestFile(t *testing.T, compression byte, events []testEvent) []byte {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, compression)
+	require.NoError(t, err)
+	w.MaxChunkSize = 100
+	for _, e := range events {
+		raw, err := rlp.EncodeToBytes(e)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(e.Epoch, raw))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func readTestFile(r *Reader) ([]*Chunk, error) {
+	var chunks []*Chunk
+	for {
+		chunk, err := r.Next()
+		if err == io.EOF {
+			return chunks, nil
+		}
+		if err != nil {
+			return chunks, err
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestEventsFile(t *testing.T) {
+	var events []testEvent
+	for epoch := idx.Epoch(1); epoch <= 5; epoch++ {
+		for i := 0; i < int(epoch)*3; i++ {
+			events = append(events, testEvent{epoch, bytes.Repeat([]byte{byte(i)}, 20)})
+		}
+	}
+
+	for _, compression := range []byte{CompressionNone, CompressionZstd} {
+		data := writeTestFile(t, compression, events)
+
+		r, err := NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, compression, r.Compression())
+		chunks, err := readTestFile(r)
+		require.NoError(t, err)
+		r.Close()
+
+		// chunks are split by epochs and by size
+		var got []testEvent
+		prevEpoch := idx.Epoch(0)
+		for _, chunk := range chunks {
+			require.GreaterOrEqual(t, chunk.Epoch, prevEpoch)
+			prevEpoch = chunk.Epoch
+			for _, raw := range chunk.Events {
+				var e testEvent
+				require.NoError(t, rlp.DecodeBytes(raw, &e))
+				require.Equal(t, chunk.Epoch, e.Epoch)
+				got = append(got, e)
+			}
+		}
+		require.Equal(t, events, got)
+		require.Greater(t, len(chunks), 5)
+
+		// index points to the chunks
+		rs := bytes.NewReader(data)
+		index, err := ReadIndex(rs)
+		require.NoError(t, err)
+		require.Len(t, index, len(chunks))
+		for i, chunk := range chunks {
+			require.Equal(t, IndexEntry{chunk.Epoch, uint32(len(chunk.Events)), chunk.Offset}, index[i])
+		}
+
+		// random access
+		rs = bytes.NewReader(data)
+		r, err = NewReader(rs)
+		require.NoError(t, err)
+		require.NoError(t, r.SeekChunk(index[3].Offset))
+		rest, err := readTestFile(r)
+		require.NoError(t, err)
+		require.Equal(t, chunks[3:], rest)
+		r.Close()
+
+		// corrupted chunk
+		corrupted := append([]byte{}, data...)
+		corrupted[index[2].Offset+chunkHeaderSize-1] ^= 1
+		r, err = NewReader(bytes.NewReader(corrupted))
+		require.NoError(t, err)
+		good, err := readTestFile(r)
+		require.True(t, errors.Is(err, ErrChecksum))
+		require.Equal(t, chunks[:2], good)
+		r.Close()
+
+		// truncated file
+		truncated := data[:index[4].Offset+10]
+		r, err = NewReader(bytes.NewReader(truncated))
+		require.NoError(t, err)
+		good, err = readTestFile(r)
+		require.Equal(t, io.ErrUnexpectedEOF, err)
+		require.Equal(t, chunks[:4], good)
+		r.Close()
+		_, err = ReadIndex(bytes.NewReader(truncated))
+		require.Equal(t, ErrNoIndex, err)
+	}
+}
+
+func TestEventsFileWrongOrder(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, CompressionNone)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(2, rlp.RawValue{0x80}))
+	require.Error(t, w.Write(1, rlp.RawValue{0x80}))
+}
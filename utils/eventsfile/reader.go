@@ -0,0 +1,216 @@
+package eventsfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/Fantom-foundation/go-opera/utils/ioread"
+)
+
+// Chunk is a verified chunk of events of one epoch.
+type Chunk struct {
+	Epoch  idx.Epoch
+	Offset uint64
+	Events []rlp.RawValue
+}
+
+// Reader reads chunks of a v2 events file sequentially.
+type Reader struct {
+	r           io.Reader
+	offset      uint64
+	compression byte
+	dec         *zstd.Decoder
+}
+
+// NewReader checks the file header and returns the reader positioned at the first chunk.
+func NewReader(r io.Reader) (*Reader, error) {
+	header := make([]byte, HeaderSize)
+	if err := ioread.ReadAll(r, header); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:len(FileHeader)], FileHeader) {
+		return nil, errors.New("expected an events file, mismatched file header")
+	}
+	if !bytes.Equal(header[len(FileHeader):len(FileHeader)+len(FileVersion)], FileVersion) {
+		return nil, errors.New("expected an events file of version 2")
+	}
+	fr := &Reader{
+		r:           r,
+		offset:      HeaderSize,
+		compression: header[HeaderSize-1],
+	}
+	switch fr.compression {
+	case CompressionNone:
+	case CompressionZstd:
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		fr.dec = dec
+	default:
+		return nil, fmt.Errorf("unknown compression %d", fr.compression)
+	}
+	return fr, nil
+}
+
+// Compression returns the compression of chunks.
+func (r *Reader) Compression() byte {
+	return r.compression
+}
+
+// SeekChunk moves the reader to the chunk at the offset, the underlying reader must be an io.Seeker.
+func (r *Reader) SeekChunk(offset uint64) error {
+	seeker, ok := r.r.(io.Seeker)
+	if !ok {
+		return errors.New("events file isn't seekable")
+	}
+	if _, err := seeker.Seek(int64(offset), io.SeekStart); err != nil {
+		return err
+	}
+	r.offset = offset
+	return nil
+}
+
+// Next reads and verifies the next chunk. It returns io.EOF after the last chunk.
+func (r *Reader) Next() (*Chunk, error) {
+	offset := r.offset
+	kind := make([]byte, 1)
+	if err := ioread.ReadAll(r.r, kind); err != nil {
+		if err == io.EOF {
+			// the file is truncated before the index
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if kind[0] == indexKind {
+		return nil, io.EOF
+	}
+	if kind[0] != chunkKind {
+		return nil, fmt.Errorf("unexpected record kind %d at offset %d", kind[0], offset)
+	}
+
+	header := make([]byte, chunkHeaderSize-1)
+	if err := readFull(r.r, header); err != nil {
+		return nil, err
+	}
+	chunk := &Chunk{
+		Epoch:  idx.BytesToEpoch(header[0:4]),
+		Offset: offset,
+	}
+	num := binary.BigEndian.Uint32(header[4:8])
+	size := binary.BigEndian.Uint32(header[8:12])
+	if size > maxPayloadSize {
+		return nil, fmt.Errorf("chunk at offset %d is too large", offset)
+	}
+	payload := make([]byte, size)
+	if err := readFull(r.r, payload); err != nil {
+		return nil, err
+	}
+	r.offset += chunkHeaderSize + uint64(size)
+
+	raw := payload
+	if r.dec != nil {
+		var err error
+		raw, err = r.dec.DecodeAll(payload, nil)
+		if err != nil {
+			return nil, fmt.Errorf("chunk at offset %d: %v", offset, err)
+		}
+	}
+	if checksum := sha256.Sum256(raw); !bytes.Equal(checksum[:], header[12:44]) {
+		return nil, fmt.Errorf("chunk of epoch %d at offset %d: %w", chunk.Epoch, offset, ErrChecksum)
+	}
+
+	chunk.Events = make([]rlp.RawValue, 0, num)
+	for len(raw) != 0 {
+		_, _, rest, err := rlp.Split(raw)
+		if err != nil {
+			return nil, fmt.Errorf("chunk of epoch %d at offset %d: %v", chunk.Epoch, offset, err)
+		}
+		chunk.Events = append(chunk.Events, raw[:len(raw)-len(rest)])
+		raw = rest
+	}
+	if uint32(len(chunk.Events)) != num {
+		return nil, fmt.Errorf("chunk of epoch %d at offset %d has %d events, expected %d", chunk.Epoch, offset, len(chunk.Events), num)
+	}
+	return chunk, nil
+}
+
+// Close releases the decompressor.
+func (r *Reader) Close() {
+	if r.dec != nil {
+		r.dec.Close()
+	}
+}
+
+// ReadIndex reads and verifies the index trailer of the file.
+// The position of the file is undefined afterwards, so Reader.SeekChunk must be called before reading chunks.
+func ReadIndex(rs io.ReadSeeker) ([]IndexEntry, error) {
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if end < HeaderSize+footerSize {
+		return nil, ErrNoIndex
+	}
+	if _, err := rs.Seek(end-footerSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	footer := make([]byte, footerSize)
+	if err := readFull(rs, footer); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(footer[8:], FileHeader) {
+		return nil, ErrNoIndex
+	}
+	indexOffset := binary.BigEndian.Uint64(footer[:8])
+	if indexOffset < HeaderSize || indexOffset+1+4+32 > uint64(end-footerSize) {
+		return nil, ErrNoIndex
+	}
+
+	if _, err := rs.Seek(int64(indexOffset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, uint64(end-footerSize)-indexOffset)
+	if err := readFull(rs, data); err != nil {
+		return nil, err
+	}
+	if data[0] != indexKind {
+		return nil, ErrNoIndex
+	}
+	num := binary.BigEndian.Uint32(data[1:5])
+	entries := data[5 : len(data)-32]
+	if uint64(len(entries)) != uint64(num)*indexEntrySize {
+		return nil, errors.New("malformed index trailer")
+	}
+	if checksum := sha256.Sum256(entries); !bytes.Equal(checksum[:], data[len(data)-32:]) {
+		return nil, fmt.Errorf("index trailer: %w", ErrChecksum)
+	}
+
+	index := make([]IndexEntry, num)
+	for i := range index {
+		e := entries[i*indexEntrySize : (i+1)*indexEntrySize]
+		index[i] = IndexEntry{
+			Epoch:  idx.BytesToEpoch(e[0:4]),
+			Events: binary.BigEndian.Uint32(e[4:8]),
+			Offset: binary.BigEndian.Uint64(e[8:16]),
+		}
+	}
+	return index, nil
+}
+
+// readFull reads exactly len(b) bytes, a partially read record is reported as io.ErrUnexpectedEOF.
+func readFull(r io.Reader, b []byte) error {
+	_, err := io.ReadFull(r, b)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package eventsfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Fantom-foundation/lachesis-base/inter/idx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Writer writes events into a v2 events file.
+type Writer struct {
+	w           io.Writer
+	offset      uint64
+	compression byte
+	enc         *zstd.Encoder
+
+	MaxChunkSize int
+
+	epoch  idx.Epoch
+	events uint32
+	buf    bytes.Buffer
+	index  []IndexEntry
+}
+
+// NewWriter writes the file header and returns the writer.
+// Writer doesn't close the underlying writer.
+func NewWriter(w io.Writer, compression byte) (*Writer, error) {
+	fw := &Writer{
+		w:            w,
+		compression:  compression,
+		MaxChunkSize: DefaultMaxChunkSize,
+	}
+	switch compression {
+	case CompressionNone:
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		fw.enc = enc
+	default:
+		return nil, fmt.Errorf("unknown compression %d", compression)
+	}
+
+	header := append(append(append([]byte{}, FileHeader...), FileVersion...), compression)
+	if err := fw.write(header); err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += uint64(n)
+	return err
+}
+
+// Write appends the event into the current chunk. Events must be ordered by epoch.
+func (w *Writer) Write(epoch idx.Epoch, event rlp.RawValue) error {
+	if epoch < w.epoch {
+		return errors.New("events aren't ordered by epoch")
+	}
+	if w.events != 0 && (epoch != w.epoch || w.buf.Len()+len(event) > w.MaxChunkSize) {
+		if err := w.flushChunk(); err != nil {
+			return err
+		}
+	}
+	w.epoch = epoch
+	w.events++
+	w.buf.Write(event)
+	return nil
+}
+
+func (w *Writer) flushChunk() error {
+	if w.events == 0 {
+		return nil
+	}
+	raw := w.buf.Bytes()
+	checksum := sha256.Sum256(raw)
+	payload := raw
+	if w.enc != nil {
+		payload = w.enc.EncodeAll(raw, nil)
+	}
+
+	w.index = append(w.index, IndexEntry{
+		Epoch:  w.epoch,
+		Events: w.events,
+		Offset: w.offset,
+	})
+	header := make([]byte, 0, chunkHeaderSize)
+	header = append(header, chunkKind)
+	header = append(header, w.epoch.Bytes()...)
+	header = appendUint32(header, w.events)
+	header = appendUint32(header, uint32(len(payload)))
+	header = append(header, checksum[:]...)
+	if err := w.write(header); err != nil {
+		return err
+	}
+	if err := w.write(payload); err != nil {
+		return err
+	}
+
+	w.events = 0
+	w.buf.Reset()
+	return nil
+}
+
+// Close flushes the last chunk and writes the index trailer.
+func (w *Writer) Close() error {
+	if w.enc != nil {
+		defer w.enc.Close()
+	}
+	if err := w.flushChunk(); err != nil {
+		return err
+	}
+
+	indexOffset := w.offset
+	entries := make([]byte, 0, len(w.index)*indexEntrySize)
+	for _, e := range w.index {
+		entries = append(entries, e.Epoch.Bytes()...)
+		entries = appendUint32(entries, e.Events)
+		entries = appendUint64(entries, e.Offset)
+	}
+	checksum := sha256.Sum256(entries)
+
+	trailer := make([]byte, 0, 1+4+len(entries)+len(checksum)+footerSize)
+	trailer = append(trailer, indexKind)
+	trailer = appendUint32(trailer, uint32(len(w.index)))
+	trailer = append(trailer, entries...)
+	trailer = append(trailer, checksum[:]...)
+	trailer = appendUint64(trailer, indexOffset)
+	trailer = append(trailer, FileHeader...)
+	return w.write(trailer)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -13,19 +13,7 @@ func Write(writer io.Writer, db kvdb.Iteratee) error {
 	it := db.NewIterator(nil, nil)
 	defer it.Release()
 	for it.Next() {
-		_, err := writer.Write(bigendian.Uint32ToBytes(uint32(len(it.Key()))))
-		if err != nil {
-			return err
-		}
-		_, err = writer.Write(it.Key())
-		if err != nil {
-			return err
-		}
-		_, err = writer.Write(bigendian.Uint32ToBytes(uint32(len(it.Value()))))
-		if err != nil {
-			return err
-		}
-		_, err = writer.Write(it.Value())
+		err := WriteItem(writer, it.Key(), it.Value())
 		if err != nil {
 			return err
 		}
@@ -33,6 +21,24 @@ func Write(writer io.Writer, db kvdb.Iteratee) error {
 	return nil
 }
 
+// WriteItem writes a single key-value in the same format as Write.
+func WriteItem(writer io.Writer, key, value []byte) error {
+	_, err := writer.Write(bigendian.Uint32ToBytes(uint32(len(key))))
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(key)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(bigendian.Uint32ToBytes(uint32(len(value))))
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(value)
+	return err
+}
+
 func Read(reader io.Reader, batch kvdb.Batch) error {
 	defer batch.Reset()
 	var lenB [4]byte
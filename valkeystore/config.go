@@ -0,0 +1,42 @@
+package valkeystore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Backends of validator keys
+const (
+	FileBackend   = "file"
+	PKCS11Backend = "pkcs11"
+)
+
+// Config selects the backend which holds a validator key.
+type Config struct {
+	// Backend is either "file" (default) or "pkcs11"
+	Backend string
+	// PKCS11Lib is a path of the PKCS#11 module, e.g. /usr/lib/softhsm/libsofthsm2.so
+	PKCS11Lib string
+	// PKCS11Token is a label of the token which holds the validator key
+	PKCS11Token string
+}
+
+// IsPKCS11 returns true if the validator key is held by a PKCS#11 token.
+func (c Config) IsPKCS11() bool {
+	return c.Backend == PKCS11Backend
+}
+
+// Validate the config.
+func (c Config) Validate() error {
+	switch c.Backend {
+	case "", FileBackend:
+		return nil
+	case PKCS11Backend:
+		if len(c.PKCS11Lib) == 0 || len(c.PKCS11Token) == 0 {
+			return errors.New("PKCS#11 module and token label must be specified")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown validator keystore backend %s", c.Backend)
+	}
+}
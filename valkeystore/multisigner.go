@@ -0,0 +1,30 @@
+package valkeystore
+
+import (
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+)
+
+// MultiSigner dispatches signing requests to the signers of the keys,
+// it allows the validators hosted by a node to keep their keys in different backends.
+type MultiSigner struct {
+	signers map[string]SignerI
+}
+
+func NewMultiSigner() *MultiSigner {
+	return &MultiSigner{
+		signers: make(map[string]SignerI),
+	}
+}
+
+// Add the signer of the key. Not safe for concurrent use with Sign.
+func (s *MultiSigner) Add(pubkey validatorpk.PubKey, signer SignerI) {
+	s.signers[string(pubkey.Bytes())] = signer
+}
+
+func (s *MultiSigner) Sign(pubkey validatorpk.PubKey, digest []byte) ([]byte, error) {
+	signer, ok := s.signers[string(pubkey.Bytes())]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return signer.Sign(pubkey, digest)
+}
@@ -0,0 +1,35 @@
+package valkeystore
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiSigner(t *testing.T) {
+	require := require.New(t)
+	digest := crypto.Keccak256([]byte("event"))
+
+	keystore1 := NewDefaultMemKeystore()
+	require.NoError(keystore1.Add(pubkey1, key1, "auth1"))
+	require.NoError(keystore1.Unlock(pubkey1, "auth1"))
+	keystore2 := NewDefaultMemKeystore()
+	require.NoError(keystore2.Add(pubkey2, key2, "auth2"))
+	require.NoError(keystore2.Unlock(pubkey2, "auth2"))
+
+	signer := NewMultiSigner()
+	_, err := signer.Sign(pubkey1, digest)
+	require.EqualError(err, ErrNotFound.Error())
+
+	signer.Add(pubkey1, NewSigner(keystore1))
+	signer.Add(pubkey2, NewSigner(keystore2))
+
+	sig, err := signer.Sign(pubkey1, digest)
+	require.NoError(err)
+	require.True(crypto.VerifySignature(pubkey1.Raw, digest, sig))
+
+	sig, err = signer.Sign(pubkey2, digest)
+	require.NoError(err)
+	require.True(crypto.VerifySignature(pubkey2.Raw, digest, sig))
+}
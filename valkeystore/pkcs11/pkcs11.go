@@ -0,0 +1,356 @@
+// Package pkcs11 implements a keystore of validator keys on a PKCS#11 token, e.g. an HSM.
+//
+// The private keys are generated or imported as sensitive and non-extractable objects,
+// so the keys cannot be exported from the token, and the events are signed by the token.
+// The token PIN is used as a password of the keys.
+package pkcs11
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+	"github.com/Fantom-foundation/go-opera/valkeystore/encryption"
+)
+
+var (
+	_ valkeystore.KeystoreI = (*Keystore)(nil)
+	_ valkeystore.SignerI   = (*Keystore)(nil)
+)
+
+var (
+	ErrNotExportable = errors.New("key isn't exportable from PKCS#11 token")
+	ErrLocked        = errors.New("PKCS#11 token is locked")
+)
+
+// secp256k1Params is the DER encoded OID 1.3.132.0.10 of secp256k1 curve
+var secp256k1Params = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+
+var secp256k1N = crypto.S256().Params().N
+var secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+
+// Keystore keeps validator keys on a PKCS#11 token, and signs digests by the token.
+// The keys are identified by the CKA_ID attribute, which is the validator public key.
+type Keystore struct {
+	mu sync.Mutex
+
+	ctx       *pkcs11.Ctx
+	finalize  bool
+	token     string
+	session   pkcs11.SessionHandle
+	logged    bool
+	unlocked  map[string]pkcs11.ObjectHandle
+	closeOnce sync.Once
+}
+
+// Open loads the PKCS#11 module, and opens a session with the token with the given label.
+func Open(lib, token string) (*Keystore, error) {
+	ctx := pkcs11.New(lib)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", lib)
+	}
+	k := &Keystore{
+		ctx:      ctx,
+		token:    token,
+		unlocked: make(map[string]pkcs11.ObjectHandle),
+	}
+	err := ctx.Initialize()
+	if err != nil && !isError(err, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, err
+	}
+	k.finalize = err == nil
+
+	slot, err := k.findSlot()
+	if err == nil {
+		k.session, err = ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	}
+	if err != nil {
+		k.release()
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *Keystore) findSlot() (uint, error) {
+	slots, err := k.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		info, err := k.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, err
+		}
+		if info.Label == k.token {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("PKCS#11 token %q is not found", k.token)
+}
+
+// Close logs out and closes the session.
+func (k *Keystore) Close() {
+	k.closeOnce.Do(func() {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		if k.logged {
+			_ = k.ctx.Logout(k.session)
+		}
+		_ = k.ctx.CloseSession(k.session)
+		k.release()
+	})
+}
+
+func (k *Keystore) release() {
+	if k.finalize {
+		_ = k.ctx.Finalize()
+	}
+	k.ctx.Destroy()
+}
+
+// Token returns the label of the token.
+func (k *Keystore) Token() string {
+	return k.token
+}
+
+// login should be called under the lock
+func (k *Keystore) login(pin string) error {
+	if k.logged {
+		return nil
+	}
+	err := k.ctx.Login(k.session, pkcs11.CKU_USER, pin)
+	if err != nil && !isError(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		return err
+	}
+	k.logged = true
+	return nil
+}
+
+// findKey should be called under the lock
+func (k *Keystore) findKey(pubkey validatorpk.PubKey) (pkcs11.ObjectHandle, bool, error) {
+	err := k.ctx.FindObjectsInit(k.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, pubkey.Bytes()),
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	objs, _, err := k.ctx.FindObjects(k.session, 1)
+	if finalErr := k.ctx.FindObjectsFinal(k.session); err == nil {
+		err = finalErr
+	}
+	if err != nil || len(objs) == 0 {
+		return 0, false, err
+	}
+	return objs[0], true, nil
+}
+
+// Has returns true if the token holds the key. Private keys are visible only after the token is unlocked.
+func (k *Keystore) Has(pubkey validatorpk.PubKey) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	_, ok, _ := k.findKey(pubkey)
+	return ok
+}
+
+// Add imports the key into the token as non-extractable, auth is the token PIN.
+func (k *Keystore) Add(pubkey validatorpk.PubKey, key []byte, auth string) error {
+	if pubkey.Type != validatorpk.Types.Secp256k1 {
+		return encryption.ErrNotSupportedType
+	}
+	decoded, err := crypto.ToECDSA(key)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(crypto.FromECDSAPub(&decoded.PublicKey), pubkey.Raw) {
+		return errors.New("key doesn't match the public key")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.login(auth); err != nil {
+		return err
+	}
+	if _, ok, err := k.findKey(pubkey); err != nil {
+		return err
+	} else if ok {
+		return valkeystore.ErrAlreadyExists
+	}
+	_, err = k.ctx.CreateObject(k.session, append(privateKeyTemplate(pubkey),
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1Params),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, crypto.FromECDSA(decoded)),
+	))
+	return err
+}
+
+// Generate creates a new key on the token, auth is the token PIN. The private key never leaves the token.
+func (k *Keystore) Generate(auth string) (validatorpk.PubKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.login(auth); err != nil {
+		return validatorpk.PubKey{}, err
+	}
+	pubObj, privObj, err := k.ctx.GenerateKeyPair(k.session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1Params),
+		},
+		privateKeyTemplate(validatorpk.PubKey{}))
+	if err != nil {
+		return validatorpk.PubKey{}, err
+	}
+	attrs, err := k.ctx.GetAttributeValue(k.session, pubObj, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return validatorpk.PubKey{}, err
+	}
+	point, err := decodeECPoint(attrs[0].Value)
+	if err != nil {
+		return validatorpk.PubKey{}, err
+	}
+	pubkey := validatorpk.PubKey{
+		Type: validatorpk.Types.Secp256k1,
+		Raw:  point,
+	}
+	// identify the key pair by the validator public key
+	id := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, pubkey.Bytes())}
+	if err := k.ctx.SetAttributeValue(k.session, privObj, id); err != nil {
+		return validatorpk.PubKey{}, err
+	}
+	if err := k.ctx.SetAttributeValue(k.session, pubObj, id); err != nil {
+		return validatorpk.PubKey{}, err
+	}
+	return pubkey, nil
+}
+
+func privateKeyTemplate(pubkey validatorpk.PubKey) []*pkcs11.Attribute {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+	}
+	if !pubkey.Empty() {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, pubkey.Bytes()))
+	}
+	return template
+}
+
+// Get always fails, the keys cannot be exported from the token.
+func (k *Keystore) Get(validatorpk.PubKey, string) (*encryption.PrivateKey, error) {
+	return nil, ErrNotExportable
+}
+
+// GetUnlocked always fails, the keys cannot be exported from the token.
+func (k *Keystore) GetUnlocked(validatorpk.PubKey) (*encryption.PrivateKey, error) {
+	return nil, ErrNotExportable
+}
+
+// Unlock logs in the token, auth is the token PIN.
+func (k *Keystore) Unlock(pubkey validatorpk.PubKey, auth string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.login(auth); err != nil {
+		return err
+	}
+	obj, ok, err := k.findKey(pubkey)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return valkeystore.ErrNotFound
+	}
+	k.unlocked[string(pubkey.Bytes())] = obj
+	return nil
+}
+
+// Unlocked returns true if the key is unlocked.
+func (k *Keystore) Unlocked(pubkey validatorpk.PubKey) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	_, ok := k.unlocked[string(pubkey.Bytes())]
+	return ok
+}
+
+// Sign implements valkeystore.SignerI by the token.
+// The signature is normalized to the lower S form, and verified.
+func (k *Keystore) Sign(pubkey validatorpk.PubKey, digest []byte) ([]byte, error) {
+	if pubkey.Type != validatorpk.Types.Secp256k1 {
+		return nil, encryption.ErrNotSupportedType
+	}
+	k.mu.Lock()
+	obj, ok := k.unlocked[string(pubkey.Bytes())]
+	if !ok {
+		k.mu.Unlock()
+		return nil, ErrLocked
+	}
+	err := k.ctx.SignInit(k.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, obj)
+	var sig []byte
+	if err == nil {
+		sig, err = k.ctx.Sign(k.session, digest)
+	}
+	k.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	sig, err = normalizeSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if !crypto.VerifySignature(pubkey.Raw, digest, sig) {
+		return nil, errors.New("PKCS#11 token: invalid signature")
+	}
+	return sig, nil
+}
+
+// normalizeSignature converts the [R || S] signature to the lower S form, which is required by secp256k1 verification.
+func normalizeSignature(sig []byte) ([]byte, error) {
+	if len(sig) != 64 {
+		return nil, fmt.Errorf("PKCS#11 token: unexpected signature length %d", len(sig))
+	}
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(secp256k1HalfN) <= 0 {
+		return sig, nil
+	}
+	res := make([]byte, 64)
+	copy(res, sig[:32])
+	b := s.Sub(secp256k1N, s).Bytes()
+	copy(res[64-len(b):], b)
+	return res, nil
+}
+
+// decodeECPoint decodes CKA_EC_POINT, which is a DER octet string with the uncompressed point.
+// Some tokens return the raw point.
+func decodeECPoint(v []byte) ([]byte, error) {
+	point := v
+	if len(v) != 65 {
+		if rest, err := asn1.Unmarshal(v, &point); err != nil || len(rest) != 0 {
+			return nil, errors.New("PKCS#11 token: malformed EC point")
+		}
+	}
+	if len(point) != 65 || point[0] != 4 {
+		return nil, errors.New("PKCS#11 token: unexpected EC point")
+	}
+	return point, nil
+}
+
+func isError(err error, code uint) bool {
+	e, ok := err.(pkcs11.Error)
+	return ok && uint(e) == code
+}
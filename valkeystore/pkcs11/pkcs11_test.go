@@ -0,0 +1,209 @@
+package pkcs11
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+)
+
+var (
+	pubkey1, _ = validatorpk.FromString("0xc0045ea4ce3ab0748574f0290dadcb45545aff82d8baa72e5b4c84a19d2e1f16fb3dc487430b4189ded650a94148e57a60ca8cbf4da414dbfd3b072f0a5b9a746235")
+	key1       = common.FromHex("e77b3e0e1bfb52a1e22b73dd7941336443363c4942c5c70869302f66940eefc2")
+)
+
+const (
+	testToken = "opera-test"
+	testPIN   = "1234"
+	testSOPIN = "5678"
+)
+
+// softHSMLib returns a path of SoftHSM module, which is specified by SOFTHSM2_LIB or installed by the softhsm2 package.
+func softHSMLib(t *testing.T) string {
+	candidates := []string{
+		os.Getenv("SOFTHSM2_LIB"),
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+	}
+	for _, lib := range candidates {
+		if lib == "" {
+			continue
+		}
+		if _, err := os.Stat(lib); err == nil {
+			return lib
+		}
+	}
+	t.Skip("SoftHSM isn't found, install softhsm2 or set SOFTHSM2_LIB")
+	return ""
+}
+
+// initSoftHSM creates a SoftHSM token in a temporary directory.
+func initSoftHSM(t *testing.T) string {
+	lib := softHSMLib(t)
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "softhsm2.conf")
+	tokens := filepath.Join(dir, "tokens")
+	require.NoError(t, os.Mkdir(tokens, 0700))
+	require.NoError(t, ioutil.WriteFile(conf, []byte("directories.tokendir = "+tokens+"\nobjectstore.backend = file\n"), 0600))
+	prevConf, hadConf := os.LookupEnv("SOFTHSM2_CONF")
+	require.NoError(t, os.Setenv("SOFTHSM2_CONF", conf))
+	t.Cleanup(func() {
+		if hadConf {
+			_ = os.Setenv("SOFTHSM2_CONF", prevConf)
+		} else {
+			_ = os.Unsetenv("SOFTHSM2_CONF")
+		}
+	})
+
+	ctx := pkcs11.New(lib)
+	require.NotNil(t, ctx)
+	defer ctx.Destroy()
+	require.NoError(t, ctx.Initialize())
+	defer ctx.Finalize()
+	slots, err := ctx.GetSlotList(false)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	require.NoError(t, ctx.InitToken(slots[0], testSOPIN, testToken))
+
+	// the token is moved to another slot after the initialization
+	k := &Keystore{ctx: ctx, token: testToken}
+	slot, err := k.findSlot()
+	require.NoError(t, err)
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	require.NoError(t, err)
+	defer ctx.CloseSession(session)
+	require.NoError(t, ctx.Login(session, pkcs11.CKU_SO, testSOPIN))
+	require.NoError(t, ctx.InitPIN(session, testPIN))
+	require.NoError(t, ctx.Logout(session))
+	return lib
+}
+
+func TestKeystore(t *testing.T) {
+	require := require.New(t)
+	lib := initSoftHSM(t)
+	digest := crypto.Keccak256([]byte("event"))
+
+	_, err := Open(lib, "unknown")
+	require.Error(err)
+
+	k, err := Open(lib, testToken)
+	require.NoError(err)
+
+	// generated key
+	pubkey2, err := k.Generate(testPIN)
+	require.NoError(err)
+	require.Equal(validatorpk.Types.Secp256k1, pubkey2.Type)
+	require.True(k.Has(pubkey2))
+	require.False(k.Unlocked(pubkey2))
+	_, err = k.Sign(pubkey2, digest)
+	require.Equal(ErrLocked, err)
+	require.NoError(k.Unlock(pubkey2, testPIN))
+	require.True(k.Unlocked(pubkey2))
+	sig, err := k.Sign(pubkey2, digest)
+	require.NoError(err)
+	require.True(crypto.VerifySignature(pubkey2.Raw, digest, sig))
+
+	// imported key
+	require.Error(k.Add(pubkey1, key2Bytes(t), testPIN))
+	require.NoError(k.Add(pubkey1, key1, testPIN))
+	require.Equal(valkeystore.ErrAlreadyExists, k.Add(pubkey1, key1, testPIN))
+	require.NoError(k.Unlock(pubkey1, testPIN))
+	sig, err = k.Sign(pubkey1, digest)
+	require.NoError(err)
+	require.True(crypto.VerifySignature(pubkey1.Raw, digest, sig))
+
+	// keys aren't exportable
+	_, err = k.Get(pubkey1, testPIN)
+	require.Equal(ErrNotExportable, err)
+	_, err = k.GetUnlocked(pubkey2)
+	require.Equal(ErrNotExportable, err)
+	for _, pubkey := range []validatorpk.PubKey{pubkey1, pubkey2} {
+		k.mu.Lock()
+		obj, ok, err := k.findKey(pubkey)
+		require.NoError(err)
+		require.True(ok)
+		_, err = k.ctx.GetAttributeValue(k.session, obj, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil)})
+		require.Error(err)
+		attrs, err := k.ctx.GetAttributeValue(k.session, obj, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, nil),
+		})
+		k.mu.Unlock()
+		require.NoError(err)
+		require.Equal([]byte{0}, attrs[0].Value)
+		require.Equal([]byte{1}, attrs[1].Value)
+	}
+	k.Close()
+
+	// keys are persistent, and protected by the PIN
+	k, err = Open(lib, testToken)
+	require.NoError(err)
+	defer k.Close()
+	require.False(k.Has(pubkey1))
+	require.Error(k.Unlock(pubkey1, "wrong"))
+	require.NoError(k.Unlock(pubkey1, testPIN))
+	require.True(k.Has(pubkey2))
+}
+
+func key2Bytes(t *testing.T) []byte {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return crypto.FromECDSA(key)
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	require := require.New(t)
+
+	key, err := crypto.ToECDSA(key1)
+	require.NoError(err)
+	digest := crypto.Keccak256([]byte("event"))
+	sig, err := crypto.Sign(digest, key)
+	require.NoError(err)
+	sig = sig[:64]
+
+	res, err := normalizeSignature(sig)
+	require.NoError(err)
+	require.Equal(sig, res)
+
+	// signature with the higher S is valid ECDSA signature, which isn't accepted by secp256k1 verification
+	high := make([]byte, 64)
+	copy(high, sig[:32])
+	s := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:])).Bytes()
+	copy(high[64-len(s):], s)
+	require.False(crypto.VerifySignature(pubkey1.Raw, digest, high))
+	res, err = normalizeSignature(high)
+	require.NoError(err)
+	require.Equal(sig, res)
+	require.True(crypto.VerifySignature(pubkey1.Raw, digest, res))
+
+	_, err = normalizeSignature(sig[:63])
+	require.Error(err)
+}
+
+func TestDecodeECPoint(t *testing.T) {
+	require := require.New(t)
+
+	point := pubkey1.Raw
+	res, err := decodeECPoint(point)
+	require.NoError(err)
+	require.Equal(point, res)
+
+	res, err = decodeECPoint(append([]byte{0x04, 0x41}, point...))
+	require.NoError(err)
+	require.Equal(point, res)
+
+	_, err = decodeECPoint(point[:64])
+	require.Error(err)
+	_, err = decodeECPoint(append([]byte{0x04, 0x40}, point[:64]...))
+	require.Error(err)
+}
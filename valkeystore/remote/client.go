@@ -0,0 +1,125 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+	"github.com/Fantom-foundation/go-opera/valkeystore/encryption"
+)
+
+var _ valkeystore.SignerI = (*Signer)(nil)
+
+// Signer signs digests by an external signing daemon.
+type Signer struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewSigner creates a client of the signing daemon. tlsCfg is required for TLS endpoints.
+func NewSigner(endpoint Endpoint, tlsCfg *tls.Config, timeout time.Duration) (*Signer, error) {
+	transport := &http.Transport{}
+	s := &Signer{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+	}
+	if endpoint.Socket != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", endpoint.Socket)
+		}
+		s.baseURL = "http://signer"
+		return s, nil
+	}
+	if tlsCfg == nil {
+		return nil, errors.New("TLS config is required for a network endpoint")
+	}
+	transport.TLSClientConfig = tlsCfg
+	s.baseURL = "https://" + endpoint.Addr
+	return s, nil
+}
+
+// Sign implements valkeystore.SignerI.
+// The signature is verified, so the daemon cannot make the node to emit events with a wrong key.
+func (s *Signer) Sign(pubkey validatorpk.PubKey, digest []byte) ([]byte, error) {
+	if pubkey.Type != validatorpk.Types.Secp256k1 {
+		return nil, encryption.ErrNotSupportedType
+	}
+	var resp SignResponse
+	err := s.call(http.MethodPost, signPath, &SignRequest{
+		PubKey: pubkey,
+		Digest: digest,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("remote signer: %s", resp.Error)
+	}
+	if len(resp.Signature) != 64 || !crypto.VerifySignature(pubkey.Raw, digest, resp.Signature) {
+		return nil, errors.New("remote signer: invalid signature")
+	}
+	return resp.Signature, nil
+}
+
+// PubKeys returns the validator keys available for signing.
+func (s *Signer) PubKeys() ([]validatorpk.PubKey, error) {
+	var resp KeysResponse
+	err := s.call(http.MethodGet, keysPath, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.PubKeys, nil
+}
+
+// Has returns true if the daemon has the validator key.
+func (s *Signer) Has(pubkey validatorpk.PubKey) (bool, error) {
+	pubkeys, err := s.PubKeys()
+	if err != nil {
+		return false, err
+	}
+	for _, pk := range pubkeys {
+		if pk.Type == pubkey.Type && bytes.Equal(pk.Raw, pubkey.Raw) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Signer) call(method, path string, req, resp interface{}) error {
+	var body io.Reader
+	if req != nil {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	httpReq, err := http.NewRequest(method, s.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp, err := s.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("remote signer: %v", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK && httpResp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("remote signer: unexpected HTTP status %s", httpResp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(httpResp.Body, 1024*1024)).Decode(resp)
+}
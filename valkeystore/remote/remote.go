@@ -0,0 +1,102 @@
+// Package remote implements signing of validator events by an external signing daemon,
+// so the validator keys may be kept off the validator host.
+//
+// The daemon serves HTTP either over a unix socket, which is protected by the file permissions,
+// or over TLS with mutual authentication of the node and the daemon by certificates of a private CA.
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+)
+
+const (
+	signPath = "/v1/sign"
+	keysPath = "/v1/keys"
+)
+
+// SignRequest is a request to sign the digest by the validator key.
+type SignRequest struct {
+	PubKey validatorpk.PubKey `json:"pubkey"`
+	Digest hexutil.Bytes      `json:"digest"`
+}
+
+// SignResponse is a signature in the [R || S] format, or an error.
+type SignResponse struct {
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// KeysResponse is a list of the validator keys available for signing.
+type KeysResponse struct {
+	PubKeys []validatorpk.PubKey `json:"pubkeys"`
+}
+
+// Endpoint is a parsed address of the signing daemon.
+type Endpoint struct {
+	// Socket is a path of unix socket, or empty for a TLS endpoint
+	Socket string
+	// Addr is host:port of a TLS endpoint
+	Addr string
+}
+
+// ParseEndpoint parses the unix:///path/to/socket or https://host:port address.
+func ParseEndpoint(s string) (Endpoint, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	switch u.Scheme {
+	case "unix":
+		if u.Path == "" {
+			return Endpoint{}, errors.New("socket path isn't specified")
+		}
+		return Endpoint{Socket: u.Path}, nil
+	case "https":
+		if u.Host == "" || strings.Trim(u.Path, "/") != "" {
+			return Endpoint{}, errors.New("expected https://host:port")
+		}
+		return Endpoint{Addr: u.Host}, nil
+	default:
+		return Endpoint{}, fmt.Errorf("unsupported signer endpoint %s, expected unix:///path/to/socket or https://host:port", s)
+	}
+}
+
+// LoadTLSConfig makes a config for mutual authentication by certificates signed by the CA.
+func LoadTLSConfig(certFile, keyFile, caFile string, server bool) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("TLS certificate, key and CA certificate must be specified")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	ca := x509.NewCertPool()
+	if !ca.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates in %s", caFile)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if server {
+		cfg.ClientCAs = ca
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		cfg.RootCAs = ca
+	}
+	return cfg, nil
+}
@@ -0,0 +1,193 @@
+package remote
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+)
+
+var (
+	pubkey1, _ = validatorpk.FromString("0xc0045ea4ce3ab0748574f0290dadcb45545aff82d8baa72e5b4c84a19d2e1f16fb3dc487430b4189ded650a94148e57a60ca8cbf4da414dbfd3b072f0a5b9a746235")
+	key1       = common.FromHex("e77b3e0e1bfb52a1e22b73dd7941336443363c4942c5c70869302f66940eefc2")
+	pubkey2, _ = validatorpk.FromString("0xc00459b25a40ac4af6d114deb2f899bb371869b467955dd3106302309263c6c7786209306dae5564cbeb75805ff517bb49dce467f785c138837782a0c0becf4b122c")
+	key2       = common.FromHex("72c7c0305f3bb74720683aad5342b44bec96efed8256ed76bb3ba6421947f0a5")
+)
+
+func TestParseEndpoint(t *testing.T) {
+	require := require.New(t)
+
+	e, err := ParseEndpoint("unix:///run/signer.sock")
+	require.NoError(err)
+	require.Equal(Endpoint{Socket: "/run/signer.sock"}, e)
+
+	e, err = ParseEndpoint("https://10.0.0.1:9000")
+	require.NoError(err)
+	require.Equal(Endpoint{Addr: "10.0.0.1:9000"}, e)
+
+	for _, s := range []string{"http://10.0.0.1:9000", "unix://", "https://10.0.0.1:9000/sign", "10.0.0.1:9000"} {
+		_, err = ParseEndpoint(s)
+		require.Error(err, s)
+	}
+}
+
+func startServer(t *testing.T, endpoint Endpoint, tlsCfg *tls.Config, pubkeys ...validatorpk.PubKey) {
+	keystore := valkeystore.NewDefaultMemKeystore()
+	require.NoError(t, keystore.Add(pubkey1, key1, "auth1"))
+	require.NoError(t, keystore.Unlock(pubkey1, "auth1"))
+	require.NoError(t, keystore.Add(pubkey2, key2, "auth2"))
+	require.NoError(t, keystore.Unlock(pubkey2, "auth2"))
+
+	l, err := Listen(endpoint, tlsCfg)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: NewHandler(valkeystore.NewSigner(keystore), pubkeys)}
+	go srv.Serve(l)
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+}
+
+func testSigner(t *testing.T, signer *Signer) {
+	require := require.New(t)
+
+	pubkeys, err := signer.PubKeys()
+	require.NoError(err)
+	require.Equal([]validatorpk.PubKey{pubkey1}, pubkeys)
+	ok, err := signer.Has(pubkey1)
+	require.NoError(err)
+	require.True(ok)
+	ok, err = signer.Has(pubkey2)
+	require.NoError(err)
+	require.False(ok)
+
+	digest := crypto.Keccak256([]byte("event"))
+	sig, err := signer.Sign(pubkey1, digest)
+	require.NoError(err)
+	require.True(crypto.VerifySignature(pubkey1.Raw, digest, sig))
+
+	// the key isn't served even though it's unlocked
+	_, err = signer.Sign(pubkey2, digest)
+	require.EqualError(err, "remote signer: "+valkeystore.ErrNotFound.Error())
+
+	_, err = signer.Sign(pubkey1, digest[:31])
+	require.Error(err)
+}
+
+func TestSignerUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remotesigner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	endpoint := Endpoint{Socket: filepath.Join(dir, "signer.sock")}
+	startServer(t, endpoint, nil, pubkey1)
+
+	info, err := os.Stat(endpoint.Socket)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	// the private directory the socket was created in is removed
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	signer, err := NewSigner(endpoint, nil, time.Second)
+	require.NoError(t, err)
+	testSigner(t, signer)
+
+	// the socket is removed on close
+	l, err := Listen(Endpoint{Socket: filepath.Join(dir, "other.sock")}, nil)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+	_, err = os.Stat(filepath.Join(dir, "other.sock"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSignerMutualTLS(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "remotesigner")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	ca, caKey := makeCert(t, dir, "ca", nil, nil)
+	makeCert(t, dir, "server", ca, caKey)
+	makeCert(t, dir, "client", ca, caKey)
+	otherCA, otherCAKey := makeCert(t, dir, "other-ca", nil, nil)
+	makeCert(t, dir, "other-client", otherCA, otherCAKey)
+
+	load := func(name, caName string, server bool) *tls.Config {
+		cfg, err := LoadTLSConfig(filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key"), filepath.Join(dir, caName+".crt"), server)
+		require.NoError(err)
+		return cfg
+	}
+
+	// TLS endpoints require client authentication
+	_, err = Listen(Endpoint{Addr: "127.0.0.1:0"}, nil)
+	require.Error(err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	endpoint := Endpoint{Addr: l.Addr().String()}
+	require.NoError(l.Close())
+	startServer(t, endpoint, load("server", "ca", true), pubkey1)
+
+	_, err = NewSigner(endpoint, nil, time.Second)
+	require.Error(err)
+
+	signer, err := NewSigner(endpoint, load("client", "ca", false), time.Second)
+	require.NoError(err)
+	testSigner(t, signer)
+
+	// a client certificate of another CA is rejected
+	signer, err = NewSigner(endpoint, load("other-client", "ca", false), time.Second)
+	require.NoError(err)
+	_, err = signer.PubKeys()
+	require.Error(err)
+}
+
+// makeCert writes name.crt and name.key, the certificate is self-signed if parent is nil.
+func makeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	require := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(err)
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(err)
+	return cert, key
+}
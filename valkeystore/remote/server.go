@@ -0,0 +1,130 @@
+package remote
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/go-opera/inter/validatorpk"
+	"github.com/Fantom-foundation/go-opera/valkeystore"
+)
+
+// digestSize is the size of event hashes signed by validators
+const digestSize = 32
+
+// Handler serves signing requests for the unlocked validator keys.
+type Handler struct {
+	signer  valkeystore.SignerI
+	pubkeys []validatorpk.PubKey
+}
+
+// NewHandler creates a handler which signs by the listed validator keys only.
+func NewHandler(signer valkeystore.SignerI, pubkeys []validatorpk.PubKey) *Handler {
+	return &Handler{
+		signer:  signer,
+		pubkeys: pubkeys,
+	}
+}
+
+func (h *Handler) allowed(pubkey validatorpk.PubKey) bool {
+	for _, pk := range h.pubkeys {
+		if pk.Type == pubkey.Type && string(pk.Raw) == string(pubkey.Raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == keysPath && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(&KeysResponse{PubKeys: h.pubkeys})
+	case r.URL.Path == signPath && r.Method == http.MethodPost:
+		sig, err := h.sign(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(&SignResponse{Error: err.Error()})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&SignResponse{Signature: sig})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) sign(body io.Reader) ([]byte, error) {
+	var req SignRequest
+	err := json.NewDecoder(io.LimitReader(body, 64*1024)).Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Digest) != digestSize {
+		return nil, errors.New("digest must be 32 bytes")
+	}
+	if !h.allowed(req.PubKey) {
+		return nil, valkeystore.ErrNotFound
+	}
+	return h.signer.Sign(req.PubKey, req.Digest)
+}
+
+// Listen opens the endpoint. A unix socket is accessible by the owner only,
+// a TLS endpoint requires client certificates signed by the CA of tlsCfg.
+func Listen(endpoint Endpoint, tlsCfg *tls.Config) (net.Listener, error) {
+	if endpoint.Socket != "" {
+		// remove the socket left by a previous run
+		if info, err := os.Stat(endpoint.Socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(endpoint.Socket)
+		}
+		return listenUnix(endpoint.Socket)
+	}
+	if tlsCfg == nil || tlsCfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		return nil, errors.New("TLS config with client authentication is required for a network endpoint")
+	}
+	return tls.Listen("tcp", endpoint.Addr, tlsCfg)
+}
+
+// unixListener removes the socket file on close.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	_ = os.Remove(l.path)
+	return err
+}
+
+// listenUnix creates the socket inside a private directory, where the socket isn't reachable
+// by other users until its permissions are restricted, and then moves the socket to the path.
+func listenUnix(path string) (net.Listener, error) {
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".signer-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmpPath := filepath.Join(dir, "socket")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	l.SetUnlinkOnClose(false)
+	err = os.Chmod(tmpPath, 0600)
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return &unixListener{l, path}, nil
+}